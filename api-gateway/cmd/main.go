@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/maslow123/api-gateway/pkg/balance"
@@ -20,6 +21,16 @@ func main() {
 
 	r := gin.Default()
 
+	// the client IP throttles logins and is stored with sessions, so
+	// X-Forwarded-For is only read from the proxies named here
+	var proxies []string
+	if c.TrustedProxies != "" {
+		proxies = strings.Split(c.TrustedProxies, ",")
+	}
+	if err = r.SetTrustedProxies(proxies); err != nil {
+		log.Fatalln("Failed at TRUSTED_PROXIES", err)
+	}
+
 	userService := *users.RegisterRoutes(r, &c)
 	_ = pos.RegisterRoutes(r, &c, &userService)
 	_ = transactions.RegisterRoutes(r, &c, &userService)
@@ -17,8 +17,13 @@ func RegisterRoutes(r *gin.Engine, c *config.Config, userSvc *users.ServiceClien
 	r.Use(a.CORSMiddleware)
 	routes := r.Group("/balance")
 	routes.Use(a.AuthRequired)
-	routes.POST("/upsert", svc.UpsertBalance)
+	write := a.WriteScope(users.ScopeBalanceWrite)
+	routes.POST("/upsert", write, svc.UpsertBalance)
 	routes.GET("/user", svc.GetUserBalance)
+	routes.POST("/liabilities", write, svc.CreateLiability)
+	routes.GET("/liabilities", svc.GetUserLiabilities)
+	routes.POST("/liabilities/:id/pay", write, svc.PayLiability)
+	routes.GET("/liabilities/:id/statement", svc.GetLiabilityStatement)
 
 	return svc
 }
@@ -30,3 +35,19 @@ func (svc *ServiceClient) UpsertBalance(ctx *gin.Context) {
 func (svc *ServiceClient) GetUserBalance(ctx *gin.Context) {
 	routes.GetUserBalance(ctx, svc.Client)
 }
+
+func (svc *ServiceClient) CreateLiability(ctx *gin.Context) {
+	routes.CreateLiability(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetUserLiabilities(ctx *gin.Context) {
+	routes.GetUserLiabilities(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) PayLiability(ctx *gin.Context) {
+	routes.PayLiability(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetLiabilityStatement(ctx *gin.Context) {
+	routes.GetLiabilityStatement(ctx, svc.Client)
+}
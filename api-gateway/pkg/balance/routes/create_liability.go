@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/transactions/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type CreateLiabilityRequest struct {
+	Name           string `json:"name"`
+	Type           int32  `json:"type"`
+	CreditLimit    int32  `json:"credit_limit"`
+	StatementDay   int32  `json:"statement_day"`
+	DueDay         int32  `json:"due_day"`
+	MinimumPayment int32  `json:"minimum_payment"`
+}
+
+func CreateLiability(ctx *gin.Context, c pb.BalanceServiceClient) {
+	req := CreateLiabilityRequest{}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	res, err := c.CreateLiability(context.Background(), &pb.CreateLiabilityRequest{
+		UserId:         userID,
+		Name:           req.Name,
+		Type:           req.Type,
+		CreditLimit:    req.CreditLimit,
+		StatementDay:   req.StatementDay,
+		DueDay:         req.DueDay,
+		MinimumPayment: req.MinimumPayment,
+	})
+
+	if err != nil {
+		log.Println(err)
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+		return
+	}
+	if res.Status != int32(http.StatusCreated) {
+		ctx.JSON(int(res.Status), res)
+		return
+	}
+
+	ctx.JSON(int(res.Status), &res)
+}
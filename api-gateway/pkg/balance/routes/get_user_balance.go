@@ -12,8 +12,15 @@ import (
 
 func GetUserBalance(ctx *gin.Context, c pb.BalanceServiceClient) {
 	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
 	res, err := c.GetUserBalance(context.Background(), &pb.GetUserBalanceRequest{
-		UserId: userID,
+		UserId:      userID,
+		HouseholdId: householdID,
 	})
 
 	if err != nil {
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/transactions/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+func GetUserLiabilities(ctx *gin.Context, c pb.BalanceServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	res, err := c.GetUserLiabilities(context.Background(), &pb.GetUserLiabilitiesRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		log.Println(err)
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+		return
+	}
+	if res.Status != int32(http.StatusOK) {
+		ctx.JSON(int(res.Status), res)
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, http.StatusOK)
+}
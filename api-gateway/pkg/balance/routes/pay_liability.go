@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/transactions/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type PayLiabilityRequest struct {
+	BalanceType int32 `json:"balance_type"`
+	Total       int32 `json:"total"`
+}
+
+func PayLiability(ctx *gin.Context, c pb.BalanceServiceClient) {
+	req := PayLiabilityRequest{}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	res, err := c.PayLiability(context.Background(), &pb.PayLiabilityRequest{
+		Id:          int32(id),
+		UserId:      userID,
+		BalanceType: req.BalanceType,
+		Total:       req.Total,
+	})
+
+	if err != nil {
+		log.Println(err)
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+		return
+	}
+	if res.Status != int32(http.StatusOK) {
+		ctx.JSON(int(res.Status), res)
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, http.StatusOK)
+}
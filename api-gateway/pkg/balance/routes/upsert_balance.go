@@ -25,11 +25,18 @@ func UpsertBalance(ctx *gin.Context, c pb.BalanceServiceClient) {
 	}
 
 	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
 	res, err := c.UpsertBalance(context.Background(), &pb.UpsertBalanceRequest{
-		UserId: userID,
-		Type:   req.Type,
-		Total:  req.Total,
-		Action: pb.UpsertBalanceRequest_ActionType(req.Action),
+		UserId:      userID,
+		HouseholdId: householdID,
+		Type:        req.Type,
+		Total:       req.Total,
+		Action:      pb.UpsertBalanceRequest_ActionType(req.Action),
 	})
 
 	if err != nil {
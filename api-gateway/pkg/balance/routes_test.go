@@ -3,6 +3,7 @@ package balance
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -103,3 +104,108 @@ func TestGetUserBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateLiability(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"name":            "Kartu kredit",
+				"type":            0,
+				"credit_limit":    100000,
+				"statement_day":   25,
+				"due_day":         10,
+				"minimum_payment": 5000,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Statement Day",
+			body: gin.H{
+				"name":          "Kartu kredit",
+				"type":          0,
+				"statement_day": 32,
+				"due_day":       10,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	// set authorizationHeader
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server = NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/balance/liabilities"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetLiabilityStatement(t *testing.T) {
+	testCases := []struct {
+		name          string
+		liabilityID   int32
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Invalid ID",
+			liabilityID: 0,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "Liability Not Found",
+			liabilityID: 99999,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	// set authorizationHeader
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server = NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/balance/liabilities/%d/statement", tc.liabilityID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
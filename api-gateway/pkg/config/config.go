@@ -8,6 +8,13 @@ type Config struct {
 	PosServiceUrl         string `mapstructure:"POS_SERVICE_URL"`
 	TransactionServiceUrl string `mapstructure:"TRANSACTION_SERVICE_URL"`
 	BalanceServiceUrl     string `mapstructure:"BALANCE_SERVICE_URL"`
+	OidcIssuer            string `mapstructure:"OIDC_ISSUER"`
+	OidcClientID          string `mapstructure:"OIDC_CLIENT_ID"`
+	OidcClientSecret      string `mapstructure:"OIDC_CLIENT_SECRET"`
+	OidcRedirectURL       string `mapstructure:"OIDC_REDIRECT_URL"`
+	OidcStateSecret       string `mapstructure:"OIDC_STATE_SECRET"`
+	JwtVerifyLocally      bool   `mapstructure:"JWT_VERIFY_LOCALLY"`
+	TrustedProxies        string `mapstructure:"TRUSTED_PROXIES"`
 }
 
 func LoadConfig(path string, fileName string) (c Config, err error) {
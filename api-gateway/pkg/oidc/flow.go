@@ -0,0 +1,102 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// FlowCookie keeps the flow of a login between the redirect to the provider
+// and the callback.
+const FlowCookie = "oidc_flow"
+
+// FlowTTL is how long the user has to sign in at the provider.
+const FlowTTL = 10 * time.Minute
+
+// Flow is the state of one login, the callback only accepts the state it was
+// started with and the ID token has to carry its nonce.
+type Flow struct {
+	State      string `json:"state"`
+	Nonce      string `json:"nonce"`
+	Verifier   string `json:"verifier"`
+	DeviceName string `json:"device_name,omitempty"`
+	Expires    int64  `json:"expires"`
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewFlow starts a login with a random state, nonce and PKCE verifier.
+func NewFlow(deviceName string) (Flow, error) {
+	var flow Flow
+	var err error
+
+	if flow.State, err = randomString(); err != nil {
+		return flow, err
+	}
+	if flow.Nonce, err = randomString(); err != nil {
+		return flow, err
+	}
+	if flow.Verifier, err = randomString(); err != nil {
+		return flow, err
+	}
+	flow.DeviceName = deviceName
+	flow.Expires = time.Now().Add(FlowTTL).Unix()
+
+	return flow, nil
+}
+
+// Challenge is the S256 PKCE challenge of a verifier.
+func Challenge(verifier string) string {
+	digest := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+func (p *Provider) sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(p.Config.StateSecret))
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Seal encodes a flow for the cookie, signed so the client can't change it.
+func (p *Provider) Seal(flow Flow) (string, error) {
+	b, err := json.Marshal(flow)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + p.sign(payload), nil
+}
+
+// Open decodes a sealed flow, it fails when the signature doesn't match or the
+// flow expired.
+func (p *Provider) Open(sealed string) (Flow, error) {
+	var flow Flow
+
+	parts := strings.Split(sealed, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(p.sign(parts[0]))) {
+		return flow, errors.New("oidc: invalid flow")
+	}
+	if err := decodeSegment(parts[0], &flow); err != nil {
+		return flow, err
+	}
+	if time.Unix(flow.Expires, 0).Before(time.Now()) {
+		return flow, errors.New("oidc: flow expired")
+	}
+
+	return flow, nil
+}
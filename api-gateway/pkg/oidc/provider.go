@@ -0,0 +1,352 @@
+// Package oidc signs users in with an OpenID Connect provider using the
+// authorization code flow with PKCE. Only what the gateway needs is
+// implemented: discovery, the code exchange and RS256 ID tokens.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config of the client registered at the provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// signs the cookie keeping the state of a login between the redirects
+	StateSecret string
+}
+
+// Claims of an ID token the gateway uses.
+type Claims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	Expiry        int64    `json:"exp"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified boolean  `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+// audience is a single string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+
+	return nil
+}
+
+// boolean also accepts "true" and "false", some providers send those.
+type boolean bool
+
+func (v *boolean) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	*v = boolean(s == "true")
+
+	return nil
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Provider is the client of one provider, its discovery document and signing
+// keys are fetched when first needed and kept.
+type Provider struct {
+	Config     Config
+	HTTPClient *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]*rsa.PublicKey
+}
+
+func NewProvider(c Config) *Provider {
+	return &Provider{
+		Config:     c,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: %s returned %d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d discovery
+	wellKnown := strings.TrimSuffix(p.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &d); err != nil {
+		return nil, err
+	}
+	if d.Issuer != p.Config.Issuer {
+		return nil, fmt.Errorf("oidc: issuer %q doesn't match the configured %q", d.Issuer, p.Config.Issuer)
+	}
+
+	p.discovery = &d
+
+	return p.discovery, nil
+}
+
+// AuthCodeURL is where the user is sent to sign in for a flow.
+func (p *Provider) AuthCodeURL(ctx context.Context, flow Flow) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.Config.ClientID},
+		"redirect_uri":          {p.Config.RedirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {flow.State},
+		"nonce":                 {flow.Nonce},
+		"code_challenge":        {Challenge(flow.Verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems the code of a flow at the token endpoint and returns the
+// ID token.
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.Config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+
+	res, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc: token endpoint returned %d %s", res.StatusCode, body.Error)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("oidc: no id_token in the token response")
+	}
+
+	return body.IDToken, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// key returns the signing key with the id, the keys are fetched again for an
+// unknown id since the provider may have rotated them.
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JwksURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.rsaKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[k.Kid] = key
+	}
+	p.keys = keys
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+// Verify checks the signature and claims of an ID token issued for a flow.
+func (p *Provider) Verify(ctx context.Context, rawIDToken string, nonce string) (*Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("oidc: malformed id token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("oidc: unsupported signing algorithm %q", header.Alg)
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, errors.New("oidc: invalid id token signature")
+	}
+
+	var claims Claims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if claims.Issuer != p.Config.Issuer {
+		return nil, fmt.Errorf("oidc: unexpected issuer %q", claims.Issuer)
+	}
+	if !claims.Audience.contains(p.Config.ClientID) {
+		return nil, errors.New("oidc: id token issued for another client")
+	}
+	// a minute of leeway for clocks that are a bit apart
+	if time.Unix(claims.Expiry, 0).Add(time.Minute).Before(time.Now()) {
+		return nil, errors.New("oidc: id token expired")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("oidc: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("oidc: id token without subject")
+	}
+
+	return &claims, nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
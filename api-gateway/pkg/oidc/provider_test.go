@@ -0,0 +1,158 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/maslow123/api-gateway/pkg/oidc"
+	"github.com/maslow123/api-gateway/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/require"
+)
+
+func newProvider(t *testing.T) (*oidc.Provider, *oidctest.Provider) {
+	mock := oidctest.NewProvider()
+	t.Cleanup(mock.Close)
+
+	p := oidc.NewProvider(oidc.Config{
+		Issuer:       mock.Issuer(),
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  "http://localhost:8000/users/oidc/callback",
+		StateSecret:  "state-secret",
+	})
+
+	return p, mock
+}
+
+// authorize follows the redirect to the provider and returns the code it
+// redirected back with.
+func authorize(t *testing.T, p *oidc.Provider, flow oidc.Flow) string {
+	authURL, err := p.AuthCodeURL(context.Background(), flow)
+	require.NoError(t, err)
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, flow.State, location.Query().Get("state"))
+
+	return location.Query().Get("code")
+}
+
+func TestLogin(t *testing.T) {
+	p, mock := newProvider(t)
+	ctx := context.Background()
+
+	flow, err := oidc.NewFlow("Laptop")
+	require.NoError(t, err)
+
+	code := authorize(t, p, flow)
+	rawIDToken, err := p.Exchange(ctx, code, flow.Verifier)
+	require.NoError(t, err)
+
+	claims, err := p.Verify(ctx, rawIDToken, flow.Nonce)
+	require.NoError(t, err)
+	require.Equal(t, mock.Issuer(), claims.Issuer)
+	require.Equal(t, mock.User.Subject, claims.Subject)
+	require.Equal(t, mock.User.Email, claims.Email)
+	require.True(t, bool(claims.EmailVerified))
+
+	// codes are redeemed once
+	_, err = p.Exchange(ctx, code, flow.Verifier)
+	require.Error(t, err)
+}
+
+func TestExchangeWrongVerifier(t *testing.T) {
+	p, _ := newProvider(t)
+
+	flow, err := oidc.NewFlow("")
+	require.NoError(t, err)
+
+	code := authorize(t, p, flow)
+	_, err = p.Exchange(context.Background(), code, "another-verifier")
+	require.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	p, mock := newProvider(t)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		audience string
+		nonce    string
+		token    func() string
+		ok       bool
+	}{
+		{"OK", "", "nonce", nil, true},
+		{"Wrong Nonce", "", "another-nonce", nil, false},
+		{"Other Client", "another-client", "nonce", nil, false},
+		{"Malformed", "", "nonce", func() string { return "not.a-token" }, false},
+		{"Tampered", "", "nonce", func() string { return mock.IDToken(mock.User, "nonce") + "x" }, false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			mock.Audience = tc.audience
+			token := mock.IDToken(mock.User, "nonce")
+			if tc.token != nil {
+				token = tc.token()
+			}
+
+			claims, err := p.Verify(ctx, token, tc.nonce)
+			if tc.ok {
+				require.NoError(t, err)
+				require.Equal(t, mock.User.Subject, claims.Subject)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestSealFlow(t *testing.T) {
+	p, _ := newProvider(t)
+
+	flow, err := oidc.NewFlow("Laptop")
+	require.NoError(t, err)
+
+	sealed, err := p.Seal(flow)
+	require.NoError(t, err)
+
+	opened, err := p.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, flow, opened)
+
+	_, err = p.Open(sealed[:len(sealed)-2])
+	require.Error(t, err)
+
+	other := oidc.NewProvider(oidc.Config{StateSecret: "another-secret"})
+	_, err = other.Open(sealed)
+	require.Error(t, err)
+
+	flow.Expires = 1
+	sealed, err = p.Seal(flow)
+	require.NoError(t, err)
+	_, err = p.Open(sealed)
+	require.Error(t, err)
+}
+
+func TestChallenge(t *testing.T) {
+	// the example of RFC 7636 appendix B
+	require.Equal(t,
+		"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		oidc.Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"),
+	)
+}
@@ -17,11 +17,18 @@ func RegisterRoutes(r *gin.Engine, c *config.Config, userSvc *users.ServiceClien
 	r.Use(a.CORSMiddleware)
 	routes := r.Group("/pos")
 	routes.Use(a.AuthRequired)
-	routes.POST("/create", svc.CreatePos)
+	write := a.WriteScope(users.ScopePosWrite)
+	routes.POST("/create", write, svc.CreatePos)
 	routes.GET("/list", svc.GetPosList)
+	routes.PUT("/reorder", write, svc.ReorderPos)
+	routes.GET("/templates", svc.GetPosTemplates)
+	routes.POST("/templates/:code/apply", write, svc.ApplyPosTemplate)
 	routes.GET("/:id", svc.PosDetail)
-	routes.PUT("/:id", svc.UpdatePosByUser)
-	routes.DELETE("/:id", svc.DeletePosByUser)
+	routes.PUT("/:id", write, svc.UpdatePosByUser)
+	routes.DELETE("/:id", write, svc.DeletePosByUser)
+	routes.PUT("/:id/move", write, svc.MovePos)
+	routes.POST("/:id/merge", write, svc.MergePos)
+	routes.PUT("/:id/archive", write, svc.ArchivePos)
 
 	return svc
 }
@@ -45,3 +52,27 @@ func (svc *ServiceClient) UpdatePosByUser(ctx *gin.Context) {
 func (svc *ServiceClient) DeletePosByUser(ctx *gin.Context) {
 	routes.DeletePosByUser(ctx, svc.Client)
 }
+
+func (svc *ServiceClient) MovePos(ctx *gin.Context) {
+	routes.MovePos(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) MergePos(ctx *gin.Context) {
+	routes.MergePos(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ArchivePos(ctx *gin.Context) {
+	routes.ArchivePos(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ReorderPos(ctx *gin.Context) {
+	routes.ReorderPos(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetPosTemplates(ctx *gin.Context) {
+	routes.GetPosTemplates(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ApplyPosTemplate(ctx *gin.Context) {
+	routes.ApplyPosTemplate(ctx, svc.Client)
+}
@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/transactions/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type ArchivePosRequest struct {
+	Archived bool `json:"archived"`
+}
+
+func ArchivePos(ctx *gin.Context, c pb.PosServiceClient) {
+	var req ArchivePosRequest
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.ArchivePos(context.Background(), &pb.ArchivePosRequest{
+		Id:          int32(id),
+		UserId:      userID,
+		HouseholdId: householdID,
+		Archived:    req.Archived,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	if res.Status != int32(http.StatusOK) {
+		ctx.JSON(int(res.Status), res.Error)
+		return
+	}
+	ctx.JSON(int(res.Status), &res)
+}
@@ -10,9 +10,11 @@ import (
 )
 
 type CreatePosRequest struct {
-	Name  string `json:"name"`
-	Type  int32  `json:"type"`
-	Color string `json:"color"`
+	Name     string `json:"name"`
+	Type     int32  `json:"type"`
+	Color    string `json:"color"`
+	ParentId int32  `json:"parent_id"`
+	Icon     string `json:"icon"`
 }
 
 func CreatePos(ctx *gin.Context, c pb.PosServiceClient) {
@@ -24,12 +26,20 @@ func CreatePos(ctx *gin.Context, c pb.PosServiceClient) {
 	}
 
 	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
 
 	res, err := c.CreatePos(context.Background(), &pb.CreatePosRequest{
-		UserId: userID,
-		Name:   req.Name,
-		Type:   req.Type,
-		Color:  req.Color,
+		UserId:      userID,
+		HouseholdId: householdID,
+		Name:        req.Name,
+		Type:        req.Type,
+		Color:       req.Color,
+		ParentId:    req.ParentId,
+		Icon:        req.Icon,
 	})
 
 	if err != nil {
@@ -18,8 +18,17 @@ func DeletePosByUser(ctx *gin.Context, c pb.PosServiceClient) {
 		return
 	}
 
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
 	res, err := c.DeletePosByUser(context.Background(), &pb.DeletePosRequest{
-		Id: int32(id),
+		Id:          int32(id),
+		UserId:      userID,
+		HouseholdId: householdID,
 	})
 
 	if err != nil {
@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/transactions/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type MergePosRequest struct {
+	TargetId      int32 `json:"target_id"`
+	ArchiveSource bool  `json:"archive_source"`
+}
+
+func MergePos(ctx *gin.Context, c pb.PosServiceClient) {
+	var req MergePosRequest
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.MergePos(context.Background(), &pb.MergePosRequest{
+		Id:            int32(id),
+		UserId:        userID,
+		HouseholdId:   householdID,
+		TargetId:      req.TargetId,
+		ArchiveSource: req.ArchiveSource,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	if res.Status != int32(http.StatusOK) {
+		ctx.JSON(int(res.Status), res.Error)
+		return
+	}
+	utils.SendProtoMessage(ctx, res, http.StatusOK)
+}
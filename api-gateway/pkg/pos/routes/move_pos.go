@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/transactions/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type MovePosRequest struct {
+	ParentId int32 `json:"parent_id"`
+}
+
+func MovePos(ctx *gin.Context, c pb.PosServiceClient) {
+	var req MovePosRequest
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.MovePos(context.Background(), &pb.MovePosRequest{
+		Id:          int32(id),
+		UserId:      userID,
+		HouseholdId: householdID,
+		ParentId:    req.ParentId,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	if res.Status != int32(http.StatusOK) {
+		ctx.JSON(int(res.Status), res.Error)
+		return
+	}
+	ctx.JSON(int(res.Status), &res)
+}
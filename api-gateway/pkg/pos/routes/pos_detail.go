@@ -17,8 +17,19 @@ func PosDetail(ctx *gin.Context, c pb.PosServiceClient) {
 		return
 	}
 
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
 	res, err := c.PosDetail(context.Background(), &pb.PosDetailRequest{
-		Id: int32(id),
+		Id:          int32(id),
+		UserId:      userID,
+		HouseholdId: householdID,
+		StartDate:   ctx.Query("start_date"),
+		EndDate:     ctx.Query("end_date"),
 	})
 
 	if err != nil {
@@ -26,10 +37,10 @@ func PosDetail(ctx *gin.Context, c pb.PosServiceClient) {
 		return
 	}
 
-	if res.Status != int32(http.StatusCreated) {
+	if res.Status != int32(http.StatusOK) {
 		ctx.JSON(int(res.Status), res.Error)
 		return
 	}
 
-	ctx.JSON(int(res.Status), &res)
+	utils.SendProtoMessage(ctx, res, http.StatusOK)
 }
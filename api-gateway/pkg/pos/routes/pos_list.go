@@ -15,7 +15,13 @@ func GetPosList(ctx *gin.Context, c pb.PosServiceClient) {
 	limitString := ctx.Query("limit")
 	pageString := ctx.Query("page")
 	typeString := ctx.Query("type")
+	includeArchived := ctx.Query("include_archived") == "true"
 	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
 
 	limit, err := strconv.Atoi(limitString)
 	if err != nil {
@@ -36,10 +42,14 @@ func GetPosList(ctx *gin.Context, c pb.PosServiceClient) {
 	}
 
 	res, err := c.GetPosByUser(context.Background(), &pb.GetPosListRequest{
-		UserId: userID,
-		Limit:  int32(limit),
-		Page:   int32(page),
-		Type:   int32(parsingType),
+		UserId:          userID,
+		HouseholdId:     householdID,
+		Limit:           int32(limit),
+		Page:            int32(page),
+		Type:            int32(parsingType),
+		IncludeArchived: includeArchived,
+		StartDate:       ctx.Query("start_date"),
+		EndDate:         ctx.Query("end_date"),
 	})
 
 	if err != nil {
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/transactions/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+func GetPosTemplates(ctx *gin.Context, c pb.PosServiceClient) {
+	res, err := c.GetPosTemplates(context.Background(), &pb.GetPosTemplatesRequest{})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	if res.Status != int32(http.StatusOK) {
+		ctx.JSON(int(res.Status), res)
+		return
+	}
+	utils.SendProtoMessage(ctx, res, http.StatusOK)
+}
+
+func ApplyPosTemplate(ctx *gin.Context, c pb.PosServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.ApplyPosTemplate(context.Background(), &pb.ApplyPosTemplateRequest{
+		UserId:      userID,
+		HouseholdId: householdID,
+		Code:        ctx.Param("code"),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	if res.Status != int32(http.StatusCreated) {
+		ctx.JSON(int(res.Status), res.Error)
+		return
+	}
+	ctx.JSON(int(res.Status), &res)
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/transactions/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type ReorderPosRequest struct {
+	Ids []int32 `json:"ids"`
+}
+
+func ReorderPos(ctx *gin.Context, c pb.PosServiceClient) {
+	var req ReorderPosRequest
+
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.ReorderPos(context.Background(), &pb.ReorderPosRequest{
+		UserId:      userID,
+		HouseholdId: householdID,
+		Ids:         req.Ids,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	if res.Status != int32(http.StatusOK) {
+		ctx.JSON(int(res.Status), res.Error)
+		return
+	}
+	ctx.JSON(int(res.Status), &res)
+}
@@ -13,6 +13,7 @@ import (
 type UpdatePosRequest struct {
 	Name  string `json:"name"`
 	Color string `json:"color"`
+	Icon  string `json:"icon"`
 }
 
 func UpdatePosByUser(ctx *gin.Context, c pb.PosServiceClient) {
@@ -29,10 +30,20 @@ func UpdatePosByUser(ctx *gin.Context, c pb.PosServiceClient) {
 		return
 	}
 
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
 	res, err := c.UpdatePosByUser(context.Background(), &pb.UpdatePosRequest{
-		Id:    int32(id),
-		Name:  req.Name,
-		Color: req.Color,
+		Id:          int32(id),
+		UserId:      userID,
+		HouseholdId: householdID,
+		Name:        req.Name,
+		Color:       req.Color,
+		Icon:        req.Icon,
 	})
 
 	if err != nil {
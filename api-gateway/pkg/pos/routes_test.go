@@ -128,6 +128,20 @@ func TestPostList(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name:  "OK With Period",
+			query: "page=1&limit=10&type=2&start_date=2022-05-01&end_date=2022-05-31",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "Invalid Start Date",
+			query: "page=1&limit=10&type=2&start_date=2022-05&end_date=2022-05-31",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name:  "Pos Not Found",
 			query: "page=100&limit=10&type=0",
@@ -358,6 +372,315 @@ func TestDeletePos(t *testing.T) {
 	}
 }
 
+func TestMovePos(t *testing.T) {
+	testCases := []struct {
+		name          string
+		posID         func(t *testing.T, server *ServiceClient, authorizationHeader string) int32
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			posID: func(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
+				return createRandomPOS(t, server, authorizationHeader)
+			},
+			body: gin.H{
+				"parent_id": 1,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Own Parent",
+			posID: func(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
+				return 1
+			},
+			body: gin.H{
+				"parent_id": 1,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Pos Not Found",
+			posID: func(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
+				return 9999
+			},
+			body: gin.H{
+				"parent_id": 0,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server = NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			id := tc.posID(t, server, authorizationHeader)
+			url := fmt.Sprintf("/pos/%d/move", id)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestMergePos(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          func(t *testing.T, server *ServiceClient, authorizationHeader string) gin.H
+		posID         func(t *testing.T, server *ServiceClient, authorizationHeader string) int32
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			posID: func(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
+				return createRandomPOS(t, server, authorizationHeader)
+			},
+			body: func(t *testing.T, server *ServiceClient, authorizationHeader string) gin.H {
+				return gin.H{
+					"target_id":      createRandomPOS(t, server, authorizationHeader),
+					"archive_source": true,
+				}
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Target ID",
+			posID: func(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
+				return 1
+			},
+			body: func(t *testing.T, server *ServiceClient, authorizationHeader string) gin.H {
+				return gin.H{
+					"target_id": 1,
+				}
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Target Not Found",
+			posID: func(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
+				return createRandomPOS(t, server, authorizationHeader)
+			},
+			body: func(t *testing.T, server *ServiceClient, authorizationHeader string) gin.H {
+				return gin.H{
+					"target_id": 99999,
+				}
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server = NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			id := tc.posID(t, server, authorizationHeader)
+			data, err := json.Marshal(tc.body(t, server, authorizationHeader))
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/pos/%d/merge", id)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestArchivePos(t *testing.T) {
+	testCases := []struct {
+		name          string
+		posID         func(t *testing.T, server *ServiceClient, authorizationHeader string) int32
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			posID: func(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
+				return createRandomPOS(t, server, authorizationHeader)
+			},
+			body: gin.H{
+				"archived": true,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Pos Not Found",
+			posID: func(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
+				return 9999
+			},
+			body: gin.H{
+				"archived": true,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server = NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			id := tc.posID(t, server, authorizationHeader)
+			url := fmt.Sprintf("/pos/%d/archive", id)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestReorderPos(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          func(t *testing.T, server *ServiceClient, authorizationHeader string) gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: func(t *testing.T, server *ServiceClient, authorizationHeader string) gin.H {
+				first := createRandomPOS(t, server, authorizationHeader)
+				second := createRandomPOS(t, server, authorizationHeader)
+				return gin.H{
+					"ids": []int32{second, first},
+				}
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid IDs",
+			body: func(t *testing.T, server *ServiceClient, authorizationHeader string) gin.H {
+				return gin.H{
+					"ids": []int32{},
+				}
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server = NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body(t, server, authorizationHeader))
+			require.NoError(t, err)
+
+			url := "/pos/reorder"
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestApplyPosTemplate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		code          string
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			code: "id-household",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "Template Not Found",
+			code: "not-a-template",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server = NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/pos/templates/%s/apply", tc.code)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
 func createRandomPOS(t *testing.T, server *ServiceClient, authorizationHeader string) int32 {
 	recorder := httptest.NewRecorder()
 
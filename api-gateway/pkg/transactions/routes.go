@@ -18,10 +18,11 @@ func RegisterRoutes(r *gin.Engine, c *config.Config, userSvc *users.ServiceClien
 	r.Use(a.CORSMiddleware)
 	routes := r.Group("/transactions")
 	routes.Use(a.AuthRequired)
-	routes.POST("/create", svc.CreateTransaction)
+	write := a.WriteScope(users.ScopeTransactionsWrite)
+	routes.POST("/create", write, svc.CreateTransaction)
 	routes.GET("/list", svc.GetUserTransaction)
 	routes.GET("/detail/:id", svc.DetailUserTransaction)
-	routes.DELETE("/:id", svc.DeleteTransactionByUser)
+	routes.DELETE("/:id", write, svc.DeleteTransactionByUser)
 
 	routes.GET("/expenditure", svc.GetPercentageExpenditure)
 
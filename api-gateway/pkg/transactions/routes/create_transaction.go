@@ -11,12 +11,13 @@ import (
 )
 
 type CreateTransactionRequest struct {
-	PosId      int32  `json:"pos_id"`
-	Total      int32  `json:"total"`
-	Details    string `json:"details"`
-	ActionType int32  `json:"action_type"`
-	Type       int32  `json:"type"`
-	Date       int32  `json:"date"`
+	PosId       int32  `json:"pos_id"`
+	Total       int32  `json:"total"`
+	Details     string `json:"details"`
+	ActionType  int32  `json:"action_type"`
+	Type        *int32 `json:"type"`
+	Date        int32  `json:"date"`
+	LiabilityId int32  `json:"liability_id"`
 }
 
 func CreateTransaction(ctx *gin.Context, c pb.TransactionServiceClient) {
@@ -27,15 +28,30 @@ func CreateTransaction(ctx *gin.Context, c pb.TransactionServiceClient) {
 		return
 	}
 
+	// without a type the user's default balance type is used
+	var balanceType int32
+	if req.Type != nil {
+		balanceType = *req.Type
+	}
+
 	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
 	request := &pb.CreateTransactionRequest{
-		UserId:     int32(userID),
-		PosId:      req.PosId,
-		Total:      req.Total,
-		Details:    req.Details,
-		ActionType: req.ActionType,
-		Type:       req.Type,
-		Date:       req.Date,
+		UserId:      int32(userID),
+		HouseholdId: householdID,
+		PosId:       req.PosId,
+		Total:       req.Total,
+		Details:     req.Details,
+		ActionType:  req.ActionType,
+		Type:        balanceType,
+		Date:        req.Date,
+		LiabilityId: req.LiabilityId,
+		DefaultType: req.Type == nil,
 	}
 	log.Println(request)
 	res, err := c.CreateTransaction(context.Background(), request)
@@ -20,10 +20,16 @@ func DeleteTransactionByUser(ctx *gin.Context, c pb.TransactionServiceClient) {
 	}
 
 	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
 
 	res, err := c.DeleteTransactionByUser(context.Background(), &pb.DeleteTransactionRequest{
-		Id:     int32(transactionId),
-		UserId: userID,
+		Id:          int32(transactionId),
+		UserId:      userID,
+		HouseholdId: householdID,
 	})
 
 	if err != nil {
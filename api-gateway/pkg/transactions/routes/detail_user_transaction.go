@@ -18,10 +18,16 @@ func DetailUserTransaction(ctx *gin.Context, c pb.TransactionServiceClient) {
 		return
 	}
 	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
 
 	res, err := c.DetailTransaction(context.Background(), &pb.DetailTransactionRequest{
-		Id:     int32(transactionId),
-		UserId: userID,
+		Id:          int32(transactionId),
+		UserId:      userID,
+		HouseholdId: householdID,
 	})
 
 	if err != nil {
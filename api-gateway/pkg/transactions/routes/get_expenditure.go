@@ -15,11 +15,17 @@ func GetPercentageExpenditure(ctx *gin.Context, c pb.TransactionServiceClient) {
 	endDateString := ctx.Query("end_date")
 
 	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
 
 	res, err := c.GetPercentageExpenditure(context.Background(), &pb.GetPercentageExpenditureRequest{
-		UserId:    userID,
-		StartDate: startDateString,
-		EndDate:   endDateString,
+		UserId:      userID,
+		HouseholdId: householdID,
+		StartDate:   startDateString,
+		EndDate:     endDateString,
 	})
 
 	if err != nil {
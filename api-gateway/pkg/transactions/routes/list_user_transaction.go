@@ -17,6 +17,7 @@ func GetUserTransaction(ctx *gin.Context, c pb.TransactionServiceClient) {
 	actionString := ctx.Query("action")
 	startDateString := ctx.Query("start_date")
 	endDateString := ctx.Query("end_date")
+	period := ctx.Query("period")
 
 	limit, err := strconv.Atoi(limitString)
 	if err != nil {
@@ -36,27 +37,38 @@ func GetUserTransaction(ctx *gin.Context, c pb.TransactionServiceClient) {
 		return
 	}
 
-	startDate, err := strconv.Atoi(startDateString)
-	if err != nil {
-		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
-		return
+	// a period replaces the start and end date
+	var startDate, endDate int
+	if period == "" {
+		startDate, err = strconv.Atoi(startDateString)
+		if err != nil {
+			ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+			return
+		}
+
+		endDate, err = strconv.Atoi(endDateString)
+		if err != nil {
+			ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+			return
+		}
 	}
 
-	endDate, err := strconv.Atoi(endDateString)
+	userID := ctx.Value("user_id").(int32)
+	householdID, err := utils.HouseholdID(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
 		return
 	}
 
-	userID := ctx.Value("user_id").(int32)
-
 	res, err := c.GetTransactionByUser(context.Background(), &pb.GetTransactionListRequest{
-		UserId:    userID,
-		Limit:     int32(limit),
-		Page:      int32(page),
-		Action:    int32(action),
-		StartDate: int32(startDate),
-		EndDate:   int32(endDate),
+		UserId:      userID,
+		HouseholdId: householdID,
+		Limit:       int32(limit),
+		Page:        int32(page),
+		Action:      int32(action),
+		StartDate:   int32(startDate),
+		EndDate:     int32(endDate),
+		Period:      period,
 	})
 
 	if err != nil {
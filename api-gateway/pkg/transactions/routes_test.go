@@ -76,6 +76,20 @@ func TestGetUserTransaction(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name:  "OK with period",
+			query: "page=1&limit=10&action=0&period=month",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "Invalid Period",
+			query: "page=1&limit=10&action=0&period=year",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name:  "Invalid Page",
 			query: "page=0&limit=10&action=0&start_date=0&end_date=0",
@@ -414,3 +428,76 @@ func deleteTransction(t *testing.T, server *ServiceClient, authorizationHeader s
 	err = json.Unmarshal(data, &tx)
 	return err
 }
+
+// createAccessToken creates a personal access token of the logged in user.
+func createAccessToken(t *testing.T, server *ServiceClient, authorizationHeader string, scopes ...string) string {
+	data, err := json.Marshal(gin.H{
+		"name":   "Import",
+		"scopes": scopes,
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/access-tokens", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	request.Header.Set("Authorization", authorizationHeader)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+
+	return fmt.Sprintf("Bearer %s", resp.Token)
+}
+
+func TestAccessTokenScopes(t *testing.T) {
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+	readToken := createAccessToken(t, server, authorizationHeader, "read")
+	writeToken := createAccessToken(t, server, authorizationHeader, "read", "transactions:write")
+
+	body := gin.H{
+		"pos_id":      1,
+		"total":       10000,
+		"details":     "Beli cireng",
+		"action_type": 0,
+		"type":        1,
+		"date":        time.Now().Unix(),
+	}
+
+	testCases := []struct {
+		name   string
+		token  string
+		method string
+		url    string
+		body   gin.H
+		code   int
+	}{
+		{"Read", readToken, http.MethodGet, "/transactions/list?page=1&limit=10&action=0&period=month", nil, http.StatusOK},
+		{"Write without Scope", readToken, http.MethodPost, "/transactions/create", body, http.StatusForbidden},
+		{"Write", writeToken, http.MethodPost, "/transactions/create", body, http.StatusCreated},
+		{"Account Routes", writeToken, http.MethodGet, "/users/profile", nil, http.StatusForbidden},
+		{"Unknown Token", "Bearer kpat_unknown", http.MethodGet, "/transactions/list?page=1&limit=10&action=0&period=month", nil, http.StatusUnauthorized},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tc.method, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", tc.token)
+			server.Router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.code, recorder.Code)
+		})
+	}
+}
@@ -1,17 +1,25 @@
 package users
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
 	"github.com/maslow123/api-gateway/pkg/config"
+	"github.com/maslow123/api-gateway/pkg/oidc"
 	pb "github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/users/routes"
+	"github.com/maslow123/jwks"
 	"google.golang.org/grpc"
 )
 
 type ServiceClient struct {
 	Client pb.UserServiceClient
 	Router *gin.Engine
+	// nil unless an OpenID Connect provider is configured
+	Oidc *oidc.Provider
+	// nil unless access tokens are verified without the users service
+	Jwks *jwks.Verifier
 }
 
 func InitServiceClient(c *config.Config) pb.UserServiceClient {
@@ -24,3 +32,29 @@ func InitServiceClient(c *config.Config) pb.UserServiceClient {
 
 	return pb.NewUserServiceClient(cc)
 }
+
+func InitOidcProvider(c *config.Config) *oidc.Provider {
+	if c.OidcIssuer == "" {
+		return nil
+	}
+
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       c.OidcIssuer,
+		ClientID:     c.OidcClientID,
+		ClientSecret: c.OidcClientSecret,
+		RedirectURL:  c.OidcRedirectURL,
+		StateSecret:  c.OidcStateSecret,
+	})
+}
+
+// InitJwksVerifier returns the verifier of JWT_VERIFY_LOCALLY, which trades the
+// revocation checks of the users service for not calling it on every request.
+func InitJwksVerifier(c *config.Config, client pb.UserServiceClient) *jwks.Verifier {
+	if !c.JwtVerifyLocally {
+		return nil
+	}
+
+	return jwks.NewVerifier("user-service", func(ctx context.Context) ([]jwks.JWK, error) {
+		return routes.FetchJwks(ctx, client)
+	})
+}
@@ -11,6 +11,24 @@ import (
 	"github.com/maslow123/api-gateway/pkg/utils"
 )
 
+// Roles carried by the access tokens.
+const (
+	RoleUser     = "user"
+	RoleAdmin    = "admin"
+	RoleReadOnly = "read-only"
+)
+
+// accessTokenPrefix starts personal access tokens, only the users service can
+// check them.
+const accessTokenPrefix = "kpat_"
+
+// Write scopes of personal access tokens, every token can read.
+const (
+	ScopePosWrite          = "pos:write"
+	ScopeTransactionsWrite = "transactions:write"
+	ScopeBalanceWrite      = "balance:write"
+)
+
 type AuthMiddlewareConfig struct {
 	svc *ServiceClient
 }
@@ -34,6 +52,23 @@ func (c *AuthMiddlewareConfig) AuthRequired(ctx *gin.Context) {
 		return
 	}
 
+	if c.svc.Jwks != nil && !strings.HasPrefix(token[1], accessTokenPrefix) {
+		claims, err := c.svc.Jwks.Verify(context.Background(), token[1])
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.ErrorResponse(err))
+			return
+		}
+
+		ctx.Set("user_id", claims.UserId)
+		ctx.Set("session_id", claims.SessionId)
+		ctx.Set("role", claims.Role)
+		ctx.Set("access_token_id", int32(0))
+		ctx.Set("scopes", []string{})
+
+		ctx.Next()
+		return
+	}
+
 	res, err := c.svc.Client.Validate(context.Background(), &pb.ValidateRequest{
 		Token: token[1],
 	})
@@ -49,10 +84,76 @@ func (c *AuthMiddlewareConfig) AuthRequired(ctx *gin.Context) {
 	}
 
 	ctx.Set("user_id", res.UserId)
+	ctx.Set("session_id", res.SessionId)
+	ctx.Set("role", res.Role)
+	ctx.Set("access_token_id", res.AccessTokenId)
+	ctx.Set("scopes", res.Scopes)
 
 	ctx.Next()
 }
 
+// RequireRole lets only users with one of the roles through a route, it runs
+// after AuthRequired.
+func (c *AuthMiddlewareConfig) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role := ctx.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusForbidden, utils.ErrorResponse(errors.New("insufficient-role")))
+	}
+}
+
+// WriteRequired guards the routes that change data, read-only users may only
+// look at them.
+func (c *AuthMiddlewareConfig) WriteRequired(ctx *gin.Context) {
+	c.RequireRole(RoleUser, RoleAdmin)(ctx)
+}
+
+// WriteScope is WriteRequired for the routes of a service that personal access
+// tokens may use, a token also needs the write scope of the service.
+func (c *AuthMiddlewareConfig) WriteScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if isAccessToken(ctx) && !hasScope(ctx.GetStringSlice("scopes"), scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, utils.ErrorResponse(errors.New("insufficient-scope")))
+			return
+		}
+
+		c.WriteRequired(ctx)
+	}
+}
+
+// SessionRequired keeps personal access tokens out of routes that manage the
+// account, like changing the password or creating more tokens.
+func (c *AuthMiddlewareConfig) SessionRequired(ctx *gin.Context) {
+	if isAccessToken(ctx) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, utils.ErrorResponse(errors.New("session-required")))
+		return
+	}
+
+	ctx.Next()
+}
+
+func isAccessToken(ctx *gin.Context) bool {
+	id, _ := ctx.Value("access_token_id").(int32)
+
+	return id != 0
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (c *AuthMiddlewareConfig) CORSMiddleware(ctx *gin.Context) {
 	ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 	ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
@@ -8,21 +8,75 @@ import (
 
 func RegisterRoutes(r *gin.Engine, c *config.Config) *ServiceClient {
 
+	client := InitServiceClient(c)
 	svc := &ServiceClient{
-		Client: InitServiceClient(c),
+		Client: client,
 		Router: r,
+		Oidc:   InitOidcProvider(c),
+		Jwks:   InitJwksVerifier(c, client),
 	}
 	a := InitAuthMiddleware(svc)
 
+	r.GET("/.well-known/jwks.json", svc.GetJwks)
+
 	routes := r.Group("/users")
 	routes.Use(a.CORSMiddleware)
 	routes.POST("/register", svc.Register)
 	routes.POST("/login", svc.Login)
+	routes.POST("/refresh", svc.Refresh)
+	routes.POST("/forgot-password", svc.ForgotPassword)
+	routes.POST("/reset-password", svc.ResetPassword)
+	routes.POST("/verify-email", svc.VerifyEmail)
+	routes.POST("/2fa/verify", svc.VerifyTwoFactor)
+	routes.POST("/household-invitations/decline", svc.DeclineHouseholdInvitation)
+	// avatars are public so <img> tags can load them without a token
+	routes.GET("/:id/avatar", svc.GetAvatar)
+	if svc.Oidc != nil {
+		routes.GET("/oidc/login", svc.OidcLogin)
+		routes.GET("/oidc/callback", svc.OidcCallback)
+	}
 
 	routes.Use(a.AuthRequired)
+	routes.Use(a.SessionRequired)
+	routes.GET("/profile", svc.GetProfile)
 	routes.PUT("/update", svc.UpdateProfile)
+	routes.DELETE("/account", svc.DeleteAccount)
+	routes.GET("/preferences", svc.GetPreferences)
+	routes.PUT("/preferences", svc.UpdatePreferences)
 	routes.PUT("/change-password", svc.ChangePassword)
 	routes.POST("/upload", svc.UploadImage)
+	routes.POST("/logout", svc.Logout)
+	routes.POST("/resend-verification", svc.ResendVerification)
+	routes.POST("/2fa/enroll", svc.EnrollTwoFactor)
+	routes.POST("/2fa/confirm", svc.ConfirmTwoFactor)
+	routes.POST("/2fa/disable", svc.DisableTwoFactor)
+	routes.GET("/sessions", svc.ListSessions)
+	routes.DELETE("/sessions/:id", svc.RevokeSession)
+	routes.POST("/access-tokens", svc.CreateAccessToken)
+	routes.GET("/access-tokens", svc.ListAccessTokens)
+	routes.DELETE("/access-tokens/:id", svc.RevokeAccessToken)
+	routes.POST("/exports", svc.RequestDataExport)
+	routes.GET("/exports/:id", svc.GetDataExport)
+	routes.GET("/exports/:id/download", svc.DownloadDataExport)
+	routes.POST("/households", a.WriteRequired, svc.CreateHousehold)
+	routes.GET("/households", svc.GetHouseholds)
+	routes.GET("/households/:id", svc.GetHousehold)
+	routes.DELETE("/households/:id", a.WriteRequired, svc.DeleteHousehold)
+	routes.POST("/households/:id/invitations", a.WriteRequired, svc.InviteHouseholdMember)
+	routes.DELETE("/households/:id/members/:member_id", a.WriteRequired, svc.RemoveHouseholdMember)
+	routes.PUT("/households/:id/owner", a.WriteRequired, svc.TransferHouseholdOwnership)
+	routes.POST("/household-invitations/accept", a.WriteRequired, svc.AcceptHouseholdInvitation)
+
+	admin := r.Group("/admin")
+	admin.Use(a.CORSMiddleware)
+	admin.Use(a.AuthRequired)
+	admin.Use(a.SessionRequired)
+	admin.Use(a.RequireRole(RoleAdmin))
+	admin.GET("/users", svc.ListUsers)
+	admin.POST("/users/:id/disable", svc.DisableUser)
+	admin.POST("/users/:id/enable", svc.EnableUser)
+	admin.PUT("/users/:id/role", svc.SetUserRole)
+	admin.GET("/stats", svc.GetSystemStats)
 
 	return svc
 }
@@ -35,10 +89,170 @@ func (svc *ServiceClient) Login(ctx *gin.Context) {
 	routes.Login(ctx, svc.Client)
 }
 
+func (svc *ServiceClient) OidcLogin(ctx *gin.Context) {
+	routes.OidcLogin(ctx, svc.Oidc)
+}
+
+func (svc *ServiceClient) OidcCallback(ctx *gin.Context) {
+	routes.OidcCallback(ctx, svc.Client, svc.Oidc)
+}
+
+func (svc *ServiceClient) GetJwks(ctx *gin.Context) {
+	routes.GetJwks(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) Refresh(ctx *gin.Context) {
+	routes.Refresh(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ForgotPassword(ctx *gin.Context) {
+	routes.ForgotPassword(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ResetPassword(ctx *gin.Context) {
+	routes.ResetPassword(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) VerifyEmail(ctx *gin.Context) {
+	routes.VerifyEmail(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ResendVerification(ctx *gin.Context) {
+	routes.ResendVerification(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) EnrollTwoFactor(ctx *gin.Context) {
+	routes.EnrollTwoFactor(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ConfirmTwoFactor(ctx *gin.Context) {
+	routes.ConfirmTwoFactor(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) DisableTwoFactor(ctx *gin.Context) {
+	routes.DisableTwoFactor(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) VerifyTwoFactor(ctx *gin.Context) {
+	routes.VerifyTwoFactor(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) Logout(ctx *gin.Context) {
+	routes.Logout(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ListSessions(ctx *gin.Context) {
+	routes.ListSessions(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) RevokeSession(ctx *gin.Context) {
+	routes.RevokeSession(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) CreateAccessToken(ctx *gin.Context) {
+	routes.CreateAccessToken(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ListAccessTokens(ctx *gin.Context) {
+	routes.ListAccessTokens(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) RevokeAccessToken(ctx *gin.Context) {
+	routes.RevokeAccessToken(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) RequestDataExport(ctx *gin.Context) {
+	routes.RequestDataExport(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetDataExport(ctx *gin.Context) {
+	routes.GetDataExport(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) DownloadDataExport(ctx *gin.Context) {
+	routes.DownloadDataExport(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) CreateHousehold(ctx *gin.Context) {
+	routes.CreateHousehold(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetHouseholds(ctx *gin.Context) {
+	routes.GetHouseholds(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetHousehold(ctx *gin.Context) {
+	routes.GetHousehold(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) DeleteHousehold(ctx *gin.Context) {
+	routes.DeleteHousehold(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) InviteHouseholdMember(ctx *gin.Context) {
+	routes.InviteHouseholdMember(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) RemoveHouseholdMember(ctx *gin.Context) {
+	routes.RemoveHouseholdMember(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) TransferHouseholdOwnership(ctx *gin.Context) {
+	routes.TransferHouseholdOwnership(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) AcceptHouseholdInvitation(ctx *gin.Context) {
+	routes.AcceptHouseholdInvitation(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) DeclineHouseholdInvitation(ctx *gin.Context) {
+	routes.DeclineHouseholdInvitation(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) ListUsers(ctx *gin.Context) {
+	routes.ListUsers(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) DisableUser(ctx *gin.Context) {
+	routes.SetUserDisabled(ctx, svc.Client, true)
+}
+
+func (svc *ServiceClient) EnableUser(ctx *gin.Context) {
+	routes.SetUserDisabled(ctx, svc.Client, false)
+}
+
+func (svc *ServiceClient) SetUserRole(ctx *gin.Context) {
+	routes.SetUserRole(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetSystemStats(ctx *gin.Context) {
+	routes.GetSystemStats(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetProfile(ctx *gin.Context) {
+	routes.GetProfile(ctx, svc.Client)
+}
+
 func (svc *ServiceClient) UpdateProfile(ctx *gin.Context) {
 	routes.UpdateProfile(ctx, svc.Client)
 }
 
+func (svc *ServiceClient) GetAvatar(ctx *gin.Context) {
+	routes.GetAvatar(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) DeleteAccount(ctx *gin.Context) {
+	routes.DeleteAccount(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) GetPreferences(ctx *gin.Context) {
+	routes.GetPreferences(ctx, svc.Client)
+}
+
+func (svc *ServiceClient) UpdatePreferences(ctx *gin.Context) {
+	routes.UpdatePreferences(ctx, svc.Client)
+}
+
 func (svc *ServiceClient) ChangePassword(ctx *gin.Context) {
 	routes.ChangePassword(ctx, svc.Client)
 }
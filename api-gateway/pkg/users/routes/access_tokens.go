@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type CreateAccessTokenBody struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int32    `json:"expires_in_days"`
+}
+
+func CreateAccessToken(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	req := CreateAccessTokenBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.CreateAccessToken(context.Background(), &pb.CreateAccessTokenRequest{
+		UserId:        userID,
+		Name:          req.Name,
+		Scopes:        req.Scopes,
+		ExpiresInDays: req.ExpiresInDays,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func ListAccessTokens(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.ListAccessTokens(context.Background(), &pb.ListAccessTokensRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func RevokeAccessToken(ctx *gin.Context, c pb.UserServiceClient) {
+	tokenID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.RevokeAccessToken(context.Background(), &pb.RevokeAccessTokenRequest{
+		UserId: userID,
+		Id:     int32(tokenID),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type DeleteAccountBody struct {
+	Password string `json:"password"`
+}
+
+func GetProfile(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.GetProfile(context.Background(), &pb.GetProfileRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func DeleteAccount(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	req := DeleteAccountBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.DeleteAccount(context.Background(), &pb.DeleteAccountRequest{
+		UserId:   userID,
+		Password: req.Password,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type SetUserRoleBody struct {
+	Role string `json:"role"`
+}
+
+func ListUsers(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.ListUsers(context.Background(), &pb.ListUsersRequest{
+		UserId: userID,
+		Page:   int32(page),
+		Limit:  int32(limit),
+		Search: ctx.Query("search"),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+// SetUserDisabled disables or enables the account in the path.
+func SetUserDisabled(ctx *gin.Context, c pb.UserServiceClient, disabled bool) {
+	accountID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.SetUserDisabled(context.Background(), &pb.SetUserDisabledRequest{
+		UserId:   userID,
+		Id:       int32(accountID),
+		Disabled: disabled,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func SetUserRole(ctx *gin.Context, c pb.UserServiceClient) {
+	accountID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	req := SetUserRoleBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.SetUserRole(context.Background(), &pb.SetUserRoleRequest{
+		UserId: userID,
+		Id:     int32(accountID),
+		Role:   req.Role,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func GetSystemStats(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.GetSystemStats(context.Background(), &pb.GetSystemStatsRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
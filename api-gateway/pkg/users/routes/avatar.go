@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+// avatarCacheControl lets clients and caches keep an avatar for a while, then
+// revalidate it with its ETag since the url stays the same after a new upload.
+const avatarCacheControl = "public, max-age=300"
+
+// GetAvatar streams the avatar of a user, ?size= picks a thumbnail.
+func GetAvatar(ctx *gin.Context, c pb.UserServiceClient) {
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	var size int64
+	if sizeString := ctx.Query("size"); sizeString != "" {
+		size, err = strconv.ParseInt(sizeString, 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			return
+		}
+	}
+
+	stream, err := c.GetAvatar(ctx.Request.Context(), &pb.GetAvatarRequest{
+		UserId:      int32(userID),
+		Size:        int32(size),
+		IfNoneMatch: ctx.GetHeader("If-None-Match"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := stream.Recv()
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	info := res.GetInfo()
+	switch info.GetStatus() {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		ctx.Header("ETag", info.Etag)
+		ctx.Header("Cache-Control", avatarCacheControl)
+		ctx.Status(http.StatusNotModified)
+		return
+	default:
+		utils.SendProtoMessage(ctx, info, int(info.GetStatus()))
+		return
+	}
+
+	ctx.Header("Content-Type", info.ContentType)
+	ctx.Header("ETag", info.Etag)
+	ctx.Header("Cache-Control", avatarCacheControl)
+	ctx.Status(http.StatusOK)
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			// the headers are sent already, all that's left is cutting the response short
+			log.Println("Cannot receive chunk data: ", err)
+			ctx.Abort()
+			return
+		}
+
+		if _, err = ctx.Writer.Write(res.GetChunkData()); err != nil {
+			log.Println(err)
+			return
+		}
+	}
+}
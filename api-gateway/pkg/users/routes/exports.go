@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+func RequestDataExport(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.RequestDataExport(context.Background(), &pb.RequestDataExportRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func GetDataExport(ctx *gin.Context, c pb.UserServiceClient) {
+	exportID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.GetDataExport(context.Background(), &pb.GetDataExportRequest{
+		UserId: userID,
+		Id:     int32(exportID),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+// DownloadDataExport streams the zip of an export as an attachment.
+func DownloadDataExport(ctx *gin.Context, c pb.UserServiceClient) {
+	exportID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+
+	stream, err := c.DownloadDataExport(ctx.Request.Context(), &pb.DownloadDataExportRequest{
+		UserId: userID,
+		Id:     int32(exportID),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := stream.Recv()
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	info := res.GetInfo()
+	if info.GetStatus() != http.StatusOK {
+		utils.SendProtoMessage(ctx, info, int(info.GetStatus()))
+		return
+	}
+
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, info.FileName))
+	ctx.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	ctx.Status(http.StatusOK)
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			// the headers are sent already, all that's left is cutting the response short
+			log.Println("Cannot receive chunk data: ", err)
+			ctx.Abort()
+			return
+		}
+
+		if _, err = ctx.Writer.Write(res.GetChunkData()); err != nil {
+			log.Println(err)
+			return
+		}
+	}
+}
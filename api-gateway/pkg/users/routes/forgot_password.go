@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type ForgotPasswordBody struct {
+	Email string `json:"email"`
+}
+
+func ForgotPassword(ctx *gin.Context, c pb.UserServiceClient) {
+	req := ForgotPasswordBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.ForgotPassword(context.Background(), &pb.ForgotPasswordRequest{
+		Email: req.Email,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
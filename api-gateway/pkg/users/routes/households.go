@@ -0,0 +1,234 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type CreateHouseholdBody struct {
+	Name string `json:"name"`
+}
+
+type InviteHouseholdMemberBody struct {
+	Email string `json:"email"`
+}
+
+type HouseholdInvitationBody struct {
+	Token string `json:"token"`
+}
+
+type TransferHouseholdOwnershipBody struct {
+	MemberId int32 `json:"member_id"`
+}
+
+func CreateHousehold(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	req := CreateHouseholdBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.CreateHousehold(context.Background(), &pb.CreateHouseholdRequest{
+		UserId: userID,
+		Name:   req.Name,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func GetHouseholds(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.GetHouseholds(context.Background(), &pb.GetHouseholdsRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func GetHousehold(ctx *gin.Context, c pb.UserServiceClient) {
+	householdID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.GetHousehold(context.Background(), &pb.GetHouseholdRequest{
+		UserId: userID,
+		Id:     int32(householdID),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func DeleteHousehold(ctx *gin.Context, c pb.UserServiceClient) {
+	householdID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.DeleteHousehold(context.Background(), &pb.DeleteHouseholdRequest{
+		UserId: userID,
+		Id:     int32(householdID),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func InviteHouseholdMember(ctx *gin.Context, c pb.UserServiceClient) {
+	householdID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	req := InviteHouseholdMemberBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.InviteHouseholdMember(context.Background(), &pb.InviteHouseholdMemberRequest{
+		UserId:      userID,
+		HouseholdId: int32(householdID),
+		Email:       req.Email,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func RemoveHouseholdMember(ctx *gin.Context, c pb.UserServiceClient) {
+	householdID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	memberID, err := strconv.ParseInt(ctx.Param("member_id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.RemoveHouseholdMember(context.Background(), &pb.RemoveHouseholdMemberRequest{
+		UserId:      userID,
+		HouseholdId: int32(householdID),
+		MemberId:    int32(memberID),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func TransferHouseholdOwnership(ctx *gin.Context, c pb.UserServiceClient) {
+	householdID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+	req := TransferHouseholdOwnershipBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.TransferHouseholdOwnership(context.Background(), &pb.TransferHouseholdOwnershipRequest{
+		UserId:      userID,
+		HouseholdId: int32(householdID),
+		MemberId:    req.MemberId,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func AcceptHouseholdInvitation(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	req := HouseholdInvitationBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.AcceptHouseholdInvitation(context.Background(), &pb.AcceptHouseholdInvitationRequest{
+		UserId: userID,
+		Token:  req.Token,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+// DeclineHouseholdInvitation needs no session, the mailed token is enough to
+// turn an invitation down.
+func DeclineHouseholdInvitation(ctx *gin.Context, c pb.UserServiceClient) {
+	req := HouseholdInvitationBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.DeclineHouseholdInvitation(context.Background(), &pb.DeclineHouseholdInvitationRequest{
+		Token: req.Token,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
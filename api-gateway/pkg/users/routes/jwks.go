@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+	"github.com/maslow123/jwks"
+)
+
+// FetchJwks gets the public keys of the access tokens from the users service.
+func FetchJwks(ctx context.Context, c pb.UserServiceClient) ([]jwks.JWK, error) {
+	res, err := c.GetJwks(ctx, &pb.GetJwksRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status != http.StatusOK {
+		return nil, errors.New(res.Error)
+	}
+
+	keys := []jwks.JWK{}
+	for _, key := range res.Keys {
+		keys = append(keys, jwks.JWK{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Alg: key.Alg,
+			Use: key.Use,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Crv,
+			X:   key.X,
+		})
+	}
+
+	return keys, nil
+}
+
+// GetJwks publishes the keys as a JSON Web Key Set. New keys are published
+// well before they sign, so caching them for a few minutes is fine.
+func GetJwks(ctx *gin.Context, c pb.UserServiceClient) {
+	keys, err := FetchJwks(context.Background(), c)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{"keys": keys})
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/maslow123/api-gateway/pkg/users/pb"
@@ -10,8 +11,9 @@ import (
 )
 
 type LoginRequestBody struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name"`
 }
 
 func Login(ctx *gin.Context, c pb.UserServiceClient) {
@@ -22,8 +24,11 @@ func Login(ctx *gin.Context, c pb.UserServiceClient) {
 	}
 
 	res, err := c.Login(context.Background(), &pb.LoginRequest{
-		Email:    req.Email,
-		Password: req.Password,
+		Email:      req.Email,
+		Password:   req.Password,
+		DeviceName: req.DeviceName,
+		UserAgent:  ctx.Request.UserAgent(),
+		Ip:         ctx.ClientIP(),
 	})
 
 	if err != nil {
@@ -31,5 +36,9 @@ func Login(ctx *gin.Context, c pb.UserServiceClient) {
 		return
 	}
 
+	if res.Status == http.StatusTooManyRequests {
+		ctx.Header("Retry-After", strconv.Itoa(int(res.RetryAfter)))
+	}
+
 	utils.SendProtoMessage(ctx, res, int(res.Status))
 }
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type LogoutRequestBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Logout revokes the access token of the request, the refresh token in the
+// body is optional and revokes the whole session when given.
+func Logout(ctx *gin.Context, c pb.UserServiceClient) {
+	req := LogoutRequestBody{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+			return
+		}
+	}
+
+	token := strings.TrimPrefix(ctx.Request.Header.Get("authorization"), "Bearer ")
+
+	res, err := c.Logout(context.Background(), &pb.LogoutRequest{
+		Token:        token,
+		RefreshToken: req.RefreshToken,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
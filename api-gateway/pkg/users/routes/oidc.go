@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/oidc"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+const oidcCookiePath = "/users/oidc"
+
+// OidcLogin redirects to the provider, the state of the login is kept in a
+// signed cookie until the callback.
+func OidcLogin(ctx *gin.Context, p *oidc.Provider) {
+	flow, err := oidc.NewFlow(ctx.Query("device_name"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+		return
+	}
+
+	authURL, err := p.AuthCodeURL(context.Background(), flow)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	sealed, err := p.Seal(flow)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.ErrorResponse(err))
+		return
+	}
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidc.FlowCookie, sealed, int(oidc.FlowTTL.Seconds()), oidcCookiePath, "", ctx.Request.TLS != nil, true)
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// OidcCallback finishes the login the provider redirected back from and
+// responds like Login.
+func OidcCallback(ctx *gin.Context, c pb.UserServiceClient, p *oidc.Provider) {
+	sealed, err := ctx.Cookie(oidc.FlowCookie)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("invalid-state")))
+		return
+	}
+
+	// a flow is used once, whatever the outcome
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidc.FlowCookie, "", -1, oidcCookiePath, "", ctx.Request.TLS != nil, true)
+
+	flow, err := p.Open(sealed)
+	if err != nil || flow.State != ctx.Query("state") {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(errors.New("invalid-state")))
+		return
+	}
+
+	if ctx.Query("error") != "" {
+		ctx.JSON(http.StatusUnauthorized, utils.ErrorResponse(errors.New(ctx.Query("error"))))
+		return
+	}
+
+	rawIDToken, err := p.Exchange(context.Background(), ctx.Query("code"), flow.Verifier)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, utils.ErrorResponse(err))
+		return
+	}
+
+	claims, err := p.Verify(context.Background(), rawIDToken, flow.Nonce)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.OidcLogin(context.Background(), &pb.OidcLoginRequest{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		DeviceName:    flow.DeviceName,
+		UserAgent:     ctx.Request.UserAgent(),
+		Ip:            ctx.ClientIP(),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type UpdatePreferencesBody struct {
+	Currency           string `json:"currency"`
+	Locale             string `json:"locale"`
+	Timezone           string `json:"timezone"`
+	WeekStart          int32  `json:"week_start"`
+	DefaultBalanceType int32  `json:"default_balance_type"`
+}
+
+func GetPreferences(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.GetPreferences(context.Background(), &pb.GetPreferencesRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func UpdatePreferences(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	req := UpdatePreferencesBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.UpdatePreferences(context.Background(), &pb.UpdatePreferencesRequest{
+		UserId: userID,
+		Preferences: &pb.Preferences{
+			Currency:           req.Currency,
+			Locale:             req.Locale,
+			Timezone:           req.Timezone,
+			WeekStart:          req.WeekStart,
+			DefaultBalanceType: req.DefaultBalanceType,
+		},
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
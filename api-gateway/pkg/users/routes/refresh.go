@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type RefreshTokenRequestBody struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func Refresh(ctx *gin.Context, c pb.UserServiceClient) {
+	req := RefreshTokenRequestBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.Refresh(context.Background(), &pb.RefreshTokenRequest{
+		RefreshToken: req.RefreshToken,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
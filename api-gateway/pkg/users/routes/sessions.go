@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+func ListSessions(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	sessionID := ctx.Value("session_id").(int32)
+
+	res, err := c.ListSessions(context.Background(), &pb.ListSessionsRequest{
+		UserId:           userID,
+		CurrentSessionId: sessionID,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func RevokeSession(ctx *gin.Context, c pb.UserServiceClient) {
+	sessionID, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.RevokeSession(context.Background(), &pb.RevokeSessionRequest{
+		UserId: userID,
+		Id:     int32(sessionID),
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/users/pb"
+	"github.com/maslow123/api-gateway/pkg/utils"
+)
+
+type ConfirmTwoFactorBody struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorBody struct {
+	Password string `json:"password"`
+}
+
+type VerifyTwoFactorBody struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+func EnrollTwoFactor(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+
+	res, err := c.EnrollTwoFactor(context.Background(), &pb.EnrollTwoFactorRequest{
+		UserId: userID,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func ConfirmTwoFactor(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	req := ConfirmTwoFactorBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.ConfirmTwoFactor(context.Background(), &pb.ConfirmTwoFactorRequest{
+		UserId: userID,
+		Code:   req.Code,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+func DisableTwoFactor(ctx *gin.Context, c pb.UserServiceClient) {
+	userID := ctx.Value("user_id").(int32)
+	req := DisableTwoFactorBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.DisableTwoFactor(context.Background(), &pb.DisableTwoFactorRequest{
+		UserId:   userID,
+		Password: req.Password,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
+
+// VerifyTwoFactor is the second step of a login answered with
+// two_factor_required, it returns the same response as Login.
+func VerifyTwoFactor(ctx *gin.Context, c pb.UserServiceClient) {
+	req := VerifyTwoFactorBody{}
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+
+	res, err := c.VerifyTwoFactor(context.Background(), &pb.VerifyTwoFactorRequest{
+		TwoFactorToken: req.TwoFactorToken,
+		Code:           req.Code,
+		RecoveryCode:   req.RecoveryCode,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
+	}
+
+	utils.SendProtoMessage(ctx, res, int(res.Status))
+}
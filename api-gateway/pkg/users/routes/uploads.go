@@ -15,13 +15,21 @@ import (
 
 func UploadImage(ctx *gin.Context, c pb.UserServiceClient) {
 	userID := ctx.Value("user_id").(int32)
-	stream, err := c.UploadImage(context.Background())
 
 	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.ErrorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	stream, err := c.UploadImage(context.Background())
 	if err != nil {
 		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
 	}
 
+	// only informative, the users service sniffs the type from the content
 	imageType := filepath.Ext(header.Filename)
 	req := &pb.UploadImageRequest{
 		Data: &pb.UploadImageRequest_Info{
@@ -36,11 +44,11 @@ func UploadImage(ctx *gin.Context, c pb.UserServiceClient) {
 	if err != nil {
 		log.Println(err)
 		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		return
 	}
 
 	reader := bufio.NewReader(file)
 	buffer := make([]byte, 1024)
-	size := 0
 
 	for {
 		n, err := reader.Read(buffer)
@@ -50,25 +58,24 @@ func UploadImage(ctx *gin.Context, c pb.UserServiceClient) {
 
 		if err != nil {
 			ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
-			break
+			return
 		}
-		size += n
 		req := &pb.UploadImageRequest{
 			Data: &pb.UploadImageRequest_ChunkData{
 				ChunkData: buffer[:n],
 			},
 		}
 
-		err = stream.Send(req)
-		if err != nil {
-			ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		// the service stopped reading, its status comes with CloseAndRecv
+		if err = stream.Send(req); err != nil {
 			break
 		}
 	}
 
 	res, err := stream.CloseAndRecv()
 	if err != nil {
-		ctx.JSON(http.StatusBadGateway, utils.ErrorResponse(err))
+		ctx.JSON(utils.GRPCErrorStatus(err), utils.GRPCErrorResponse(err))
+		return
 	}
 
 	utils.SendProtoMessage(ctx, res, http.StatusOK)
@@ -1,17 +1,23 @@
 package users
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maslow123/api-gateway/pkg/config"
+	"github.com/maslow123/api-gateway/pkg/oidc/oidctest"
 	"github.com/maslow123/api-gateway/pkg/utils"
 	"github.com/stretchr/testify/require"
 )
@@ -65,11 +71,11 @@ func TestLogin(t *testing.T) {
 		{
 			name: "User not found",
 			body: gin.H{
-				"email":    "user9999",
+				"email":    utils.RandomEmail(),
 				"password": "wrong password",
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 	}
@@ -88,6 +94,9 @@ func TestLogin(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			require.NoError(t, err)
 
+			// failed logins count against the client ip too
+			request.RemoteAddr = randomRemoteAddr()
+
 			server.Router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
 		})
@@ -95,6 +104,8 @@ func TestLogin(t *testing.T) {
 }
 
 func TestRegister(t *testing.T) {
+	password := utils.RandomString(12)
+
 	testCases := []struct {
 		name          string
 		body          gin.H
@@ -104,9 +115,9 @@ func TestRegister(t *testing.T) {
 			name: "OK",
 			body: gin.H{
 				"name":             utils.RandomString(10),
-				"email":            utils.RandomString(10),
-				"password":         "111111",
-				"confirm_password": "111111",
+				"email":            utils.RandomEmail(),
+				"password":         password,
+				"confirm_password": password,
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusCreated, recorder.Code)
@@ -116,7 +127,7 @@ func TestRegister(t *testing.T) {
 			name: "Invalid Name",
 			body: gin.H{
 				"name":             "",
-				"email":            utils.RandomString(10),
+				"email":            utils.RandomEmail(),
 				"password":         "111111",
 				"confirm_password": "111111",
 			},
@@ -137,10 +148,22 @@ func TestRegister(t *testing.T) {
 			},
 		},
 		{
-			name: "Invalid Password",
+			name: "Invalid Email Format",
 			body: gin.H{
 				"name":             utils.RandomString(10),
 				"email":            utils.RandomString(10),
+				"password":         "111111",
+				"confirm_password": "111111",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Password",
+			body: gin.H{
+				"name":             utils.RandomString(10),
+				"email":            utils.RandomEmail(),
 				"password":         "",
 				"confirm_password": "111111",
 			},
@@ -152,7 +175,7 @@ func TestRegister(t *testing.T) {
 			name: "Invalid Confirm Password",
 			body: gin.H{
 				"name":             utils.RandomString(10),
-				"email":            utils.RandomString(10),
+				"email":            utils.RandomEmail(),
 				"password":         "111111",
 				"confirm_password": "",
 			},
@@ -164,7 +187,7 @@ func TestRegister(t *testing.T) {
 			name: "Confirm Password not match",
 			body: gin.H{
 				"name":             utils.RandomString(10),
-				"email":            utils.RandomString(10),
+				"email":            utils.RandomEmail(),
 				"password":         "111111",
 				"confirm_password": "not match",
 			},
@@ -173,10 +196,10 @@ func TestRegister(t *testing.T) {
 			},
 		},
 		{
-			name: "Existing User",
+			name: "Password Too Common",
 			body: gin.H{
 				"name":             utils.RandomString(10),
-				"email":            "user1@gmail.com",
+				"email":            utils.RandomEmail(),
 				"password":         "111111",
 				"confirm_password": "111111",
 			},
@@ -184,6 +207,18 @@ func TestRegister(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "Existing User",
+			body: gin.H{
+				"name":             utils.RandomString(10),
+				"email":            "user1@gmail.com",
+				"password":         password,
+				"confirm_password": password,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -251,18 +286,34 @@ func TestUpdateProfile(t *testing.T) {
 }
 
 func TestChangePassword(t *testing.T) {
+	// changing the password logs the user out everywhere, so don't use a seeded user
+	server := NewServer(t)
+	email, password := registerUser(t, server)
+	newPassword := utils.RandomString(12)
+
 	testCases := []struct {
 		name          string
 		body          gin.H
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name: "OK",
+			name: "Password Too Common",
 			body: gin.H{
-				"old_password":     "111111",
+				"old_password":     password,
 				"password":         "111111",
 				"confirm_password": "111111",
 			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "OK",
+			body: gin.H{
+				"old_password":     password,
+				"password":         newPassword,
+				"confirm_password": newPassword,
+			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				log.Println(recorder)
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -271,8 +322,8 @@ func TestChangePassword(t *testing.T) {
 	}
 
 	// set authorizationHeader
-	server := NewServer(t)
-	authorizationHeader := addAuthorization(t, server)
+	token, _ := loginAs(t, server, email, password)
+	authorizationHeader := fmt.Sprintf("Bearer %s", token)
 
 	for i := range testCases {
 		tc := testCases[i]
@@ -327,3 +378,1220 @@ func TestUploadImage(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, res.Code)
 }
+
+func TestUploadImageInvalid(t *testing.T) {
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	// the extension doesn't make it an image
+	w, err := mw.CreateFormFile("file", "avatar.jpg")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("GIF89a\x01\x00\x01\x00"))
+	require.NoError(t, err)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/users/upload", body)
+	req.Header.Add("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", authorizationHeader)
+
+	res := httptest.NewRecorder()
+	server.Router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Contains(t, res.Body.String(), "unsupported-image-type")
+}
+
+func login(t *testing.T, server *ServiceClient) (token string, refreshToken string) {
+	return loginAs(t, server, "user2@gmail.com", "111111")
+}
+
+func loginAs(t *testing.T, server *ServiceClient, email string, password string) (token string, refreshToken string) {
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"email":    email,
+		"password": password,
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+
+	return resp.Token, resp.RefreshToken
+}
+
+func TestRefresh(t *testing.T) {
+	server := NewServer(t)
+	_, refreshToken := login(t, server)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Reused Refresh Token",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Refresh Token",
+			body: gin.H{
+				"refresh_token": "",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/refresh"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	server := NewServer(t)
+	token, refreshToken := login(t, server)
+	authorizationHeader := fmt.Sprintf("Bearer %s", token)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Revoked Token",
+			body: gin.H{},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/logout"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	server := NewServer(t)
+	token, _ := login(t, server)
+	authorizationHeader := fmt.Sprintf("Bearer %s", token)
+
+	// list the sessions, the one of the token is the current one
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/users/sessions", nil)
+	require.NoError(t, err)
+
+	request.Header.Set("Authorization", authorizationHeader)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp struct {
+		Sessions []struct {
+			Id      int32 `json:"id"`
+			Current bool  `json:"current"`
+		} `json:"sessions"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+
+	var current int32
+	for _, session := range resp.Sessions {
+		if session.Current {
+			current = session.Id
+		}
+	}
+	require.NotZero(t, current)
+
+	testCases := []struct {
+		name          string
+		sessionID     string
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Invalid Session ID",
+			sessionID: "abc",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "Session Not Found",
+			sessionID: "2147483647",
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "OK",
+			sessionID: fmt.Sprint(current),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Revoked Session",
+			sessionID: fmt.Sprint(current),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/users/sessions/%s", tc.sessionID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"email": "user2@gmail.com",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Unknown Email",
+			body: gin.H{
+				"email": utils.RandomEmail(),
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Email",
+			body: gin.H{
+				"email": "",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/forgot-password"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Invalid Token",
+			body: gin.H{
+				"token":            utils.RandomString(43),
+				"password":         "new password",
+				"confirm_password": "new password",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Confirm Password not match",
+			body: gin.H{
+				"token":            utils.RandomString(43),
+				"password":         "111111",
+				"confirm_password": "not match",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/reset-password"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Invalid Token",
+			body: gin.H{
+				"token": utils.RandomString(43),
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/verify-email"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/resend-verification", nil)
+	require.NoError(t, err)
+
+	request.Header.Set("Authorization", authorizationHeader)
+	server.Router.ServeHTTP(recorder, request)
+
+	// user2 is either still unverified or has verified the address already
+	require.Contains(t, []int{http.StatusOK, http.StatusBadRequest}, recorder.Code)
+}
+
+func TestVerifyTwoFactor(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Invalid Two Factor Token",
+			body: gin.H{
+				"two_factor_token": utils.RandomString(43),
+				"code":             "123456",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Code",
+			body: gin.H{
+				"two_factor_token": utils.RandomString(43),
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer(t)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/2fa/verify"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestTwoFactorSettings(t *testing.T) {
+	testCases := []struct {
+		name          string
+		url           string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Enroll",
+			url:  "/users/2fa/enroll",
+			body: gin.H{},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Confirm Invalid Code",
+			url:  "/users/2fa/confirm",
+			body: gin.H{
+				"code": "",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Disable Password not match",
+			url:  "/users/2fa/disable",
+			body: gin.H{
+				"password": "wrong password",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+// registerUser registers a new account and returns its credentials.
+func registerUser(t *testing.T, server *ServiceClient) (email string, password string) {
+	email = utils.RandomEmail()
+	password = utils.RandomString(12)
+
+	data, err := json.Marshal(gin.H{
+		"name":             utils.RandomString(10),
+		"email":            email,
+		"password":         password,
+		"confirm_password": password,
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/register", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	return email, password
+}
+
+func randomRemoteAddr() string {
+	return fmt.Sprintf("10.%d.%d.%d:1234", utils.RandomInt(0, 255), utils.RandomInt(0, 255), utils.RandomInt(0, 255))
+}
+
+func TestLoginLockout(t *testing.T) {
+	server := NewServer(t)
+
+	// register an account of our own, locking a seeded user would break other tests
+	email, _ := registerUser(t, server)
+
+	data, err := json.Marshal(gin.H{
+		"email":    email,
+		"password": "wrong password",
+	})
+	require.NoError(t, err)
+
+	// keep failing until the account is locked
+	recorder := httptest.NewRecorder()
+	remoteAddr := randomRemoteAddr()
+	for i := 0; i < 20 && recorder.Code != http.StatusTooManyRequests; i++ {
+		recorder = httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+		require.NoError(t, err)
+
+		request.RemoteAddr = remoteAddr
+		server.Router.ServeHTTP(recorder, request)
+	}
+
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+}
+
+func TestGetProfile(t *testing.T) {
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/users/profile", nil)
+	require.NoError(t, err)
+
+	request.Header.Set("Authorization", authorizationHeader)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "user2@gmail.com", resp.User.Email)
+}
+
+func TestGetAvatar(t *testing.T) {
+	server := NewServer(t)
+	authorizationHeader := addAuthorization(t, server)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/users/profile", nil)
+	require.NoError(t, err)
+
+	request.Header.Set("Authorization", authorizationHeader)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var profile struct {
+		User struct {
+			Id int32 `json:"id"`
+		} `json:"user"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &profile)
+	require.NoError(t, err)
+
+	avatarUrl := fmt.Sprintf("/users/%d/avatar", profile.User.Id)
+	var etag string
+
+	testCases := []struct {
+		name          string
+		url           string
+		setupAuth     func(request *http.Request)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			url:  avatarUrl + "?size=64",
+			setupAuth: func(request *http.Request) {
+				request.Header.Set("Authorization", authorizationHeader)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "image/")
+				require.NotEmpty(t, recorder.Header().Get("Cache-Control"))
+				require.NotEmpty(t, recorder.Body.Bytes())
+
+				etag = recorder.Header().Get("ETag")
+				require.NotEmpty(t, etag)
+			},
+		},
+		{
+			name: "Not Modified",
+			url:  avatarUrl + "?size=64",
+			setupAuth: func(request *http.Request) {
+				request.Header.Set("Authorization", authorizationHeader)
+				request.Header.Set("If-None-Match", etag)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotModified, recorder.Code)
+				require.Equal(t, etag, recorder.Header().Get("ETag"))
+				require.Empty(t, recorder.Body.Bytes())
+			},
+		},
+		{
+			name: "Invalid Size",
+			url:  avatarUrl + "?size=100",
+			setupAuth: func(request *http.Request) {
+				request.Header.Set("Authorization", authorizationHeader)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid ID",
+			url:  "/users/abc/avatar",
+			setupAuth: func(request *http.Request) {
+				request.Header.Set("Authorization", authorizationHeader)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "Without Authorization",
+			url:       avatarUrl + "?size=64",
+			setupAuth: func(request *http.Request) {},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, etag, recorder.Header().Get("ETag"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(request)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	server := NewServer(t)
+	email, password := registerUser(t, server)
+	token, _ := loginAs(t, server, email, password)
+	authorizationHeader := fmt.Sprintf("Bearer %s", token)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Password Not Match",
+			body: gin.H{
+				"password": "wrong password",
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "OK",
+			body: gin.H{
+				"password": password,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			// the token went with the account
+			name: "Deleted",
+			body: gin.H{
+				"password": password,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodDelete, "/users/account", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	server := NewServer(t)
+	email, password := registerUser(t, server)
+	token, _ := loginAs(t, server, email, password)
+	authorizationHeader := fmt.Sprintf("Bearer %s", token)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"currency":             "USD",
+				"locale":               "en-US",
+				"timezone":             "America/New_York",
+				"week_start":           0,
+				"default_balance_type": 1,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Timezone",
+			body: gin.H{
+				"currency":             "USD",
+				"locale":               "en-US",
+				"timezone":             "Mars/Olympus",
+				"week_start":           0,
+				"default_balance_type": 1,
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, "/users/preferences", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", authorizationHeader)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/users/preferences", nil)
+	require.NoError(t, err)
+
+	request.Header.Set("Authorization", authorizationHeader)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp struct {
+		Preferences struct {
+			Currency string `json:"currency"`
+			Timezone string `json:"timezone"`
+		} `json:"preferences"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, "USD", resp.Preferences.Currency)
+	require.Equal(t, "America/New_York", resp.Preferences.Timezone)
+}
+
+func TestDataExport(t *testing.T) {
+	server := NewServer(t)
+	email, password := registerUser(t, server)
+	token, _ := loginAs(t, server, email, password)
+	authorizationHeader := fmt.Sprintf("Bearer %s", token)
+
+	send := func(method string, url string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, nil)
+		require.NoError(t, err)
+
+		request.Header.Set("Authorization", authorizationHeader)
+		server.Router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send(http.MethodPost, "/users/exports")
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	var resp struct {
+		Export struct {
+			Id     int32  `json:"id"`
+			Status string `json:"status"`
+		} `json:"export"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.NotZero(t, resp.Export.Id)
+
+	url := fmt.Sprintf("/users/exports/%d", resp.Export.Id)
+	require.Eventually(t, func() bool {
+		recorder := send(http.MethodGet, url)
+		if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &resp) != nil {
+			return false
+		}
+		return resp.Export.Status != "pending"
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, "done", resp.Export.Status)
+
+	recorder = send(http.MethodGet, url+"/download")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/zip", recorder.Header().Get("Content-Type"))
+	require.Contains(t, recorder.Header().Get("Content-Disposition"), "attachment")
+
+	body := recorder.Body.Bytes()
+	_, err = zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	recorder = send(http.MethodGet, "/users/exports/abc")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = send(http.MethodGet, "/users/exports/2147483647/download")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestHouseholds(t *testing.T) {
+	server := NewServer(t)
+	email, password := registerUser(t, server)
+	token, _ := loginAs(t, server, email, password)
+	authorizationHeader := fmt.Sprintf("Bearer %s", token)
+
+	send := func(method string, url string, body gin.H) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+
+		request.Header.Set("Authorization", authorizationHeader)
+		server.Router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send(http.MethodPost, "/users/households", gin.H{"name": "Keluarga"})
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	var resp struct {
+		Household struct {
+			Id   int32  `json:"id"`
+			Role string `json:"role"`
+		} `json:"household"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.NotZero(t, resp.Household.Id)
+	require.Equal(t, "owner", resp.Household.Role)
+
+	url := fmt.Sprintf("/users/households/%d", resp.Household.Id)
+
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		body   gin.H
+		code   int
+	}{
+		{"List", http.MethodGet, "/users/households", nil, http.StatusOK},
+		{"Detail", http.MethodGet, url, nil, http.StatusOK},
+		{"Invalid ID", http.MethodGet, "/users/households/abc", nil, http.StatusBadRequest},
+		{"Invalid Email", http.MethodPost, url + "/invitations", gin.H{"email": "invalid"}, http.StatusBadRequest},
+		{"Invite", http.MethodPost, url + "/invitations", gin.H{"email": utils.RandomEmail()}, http.StatusCreated},
+		{"Invalid Member ID", http.MethodDelete, url + "/members/0", nil, http.StatusBadRequest},
+		{"Transfer Invalid Member ID", http.MethodPut, url + "/owner", gin.H{"member_id": 0}, http.StatusBadRequest},
+		{"Transfer to Stranger", http.MethodPut, url + "/owner", gin.H{"member_id": 2147483647}, http.StatusNotFound},
+		{"Invalid Token", http.MethodPost, "/users/household-invitations/accept", gin.H{"token": "invalid"}, http.StatusBadRequest},
+		{"Delete", http.MethodDelete, url, nil, http.StatusOK},
+		{"Deleted", http.MethodGet, url, nil, http.StatusNotFound},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := send(tc.method, tc.url, tc.body)
+			require.Equal(t, tc.code, recorder.Code)
+		})
+	}
+
+	// declining goes without a session
+	data, err := json.Marshal(gin.H{"token": "invalid"})
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/household-invitations/decline", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestAdmin(t *testing.T) {
+	server := NewServer(t)
+	adminToken, _ := loginAs(t, server, "user1@gmail.com", "111111")
+	email, password := registerUser(t, server)
+	userToken, _ := loginAs(t, server, email, password)
+
+	send := func(token string, method string, url string, body gin.H) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		server.Router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send(adminToken, http.MethodGet, "/admin/users?search="+email, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp struct {
+		Users []struct {
+			Id   int32  `json:"id"`
+			Role string `json:"role"`
+		} `json:"users"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Users, 1)
+	require.Equal(t, "user", resp.Users[0].Role)
+
+	url := fmt.Sprintf("/admin/users/%d", resp.Users[0].Id)
+
+	testCases := []struct {
+		name   string
+		token  string
+		method string
+		url    string
+		body   gin.H
+		code   int
+	}{
+		{"Stats", adminToken, http.MethodGet, "/admin/stats", nil, http.StatusOK},
+		{"Not an Admin", userToken, http.MethodGet, "/admin/stats", nil, http.StatusForbidden},
+		{"Invalid ID", adminToken, http.MethodPost, "/admin/users/abc/disable", nil, http.StatusBadRequest},
+		{"Invalid Role", adminToken, http.MethodPut, url + "/role", gin.H{"role": "owner"}, http.StatusBadRequest},
+		{"Read-only", adminToken, http.MethodPut, url + "/role", gin.H{"role": "read-only"}, http.StatusOK},
+		{"Revoked Token", userToken, http.MethodGet, "/users/profile", nil, http.StatusUnauthorized},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := send(tc.token, tc.method, tc.url, tc.body)
+			require.Equal(t, tc.code, recorder.Code)
+		})
+	}
+
+	// a read-only user reads but can't change data
+	userToken, _ = loginAs(t, server, email, password)
+	recorder = send(userToken, http.MethodGet, "/users/households", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	for _, route := range []struct{ method, url string }{
+		{http.MethodPost, "/users/households"},
+		{http.MethodDelete, "/users/households/1/members/1"},
+		{http.MethodPost, "/users/household-invitations/accept"},
+	} {
+		recorder = send(userToken, route.method, route.url, gin.H{"name": "Keluarga", "token": "invalid"})
+		require.Equal(t, http.StatusForbidden, recorder.Code, route.url)
+	}
+
+	recorder = send(adminToken, http.MethodPost, url+"/disable", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	data, err := json.Marshal(gin.H{"email": email, "password": password})
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(adminToken, http.MethodPost, url+"/enable", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestOidcLogin(t *testing.T) {
+	mock := oidctest.NewProvider()
+	defer mock.Close()
+	mock.User.Subject = utils.RandomString(20)
+	mock.User.Email = utils.RandomEmail()
+
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	c.OidcIssuer = mock.Issuer()
+	c.OidcClientID = oidctest.ClientID
+	c.OidcClientSecret = oidctest.ClientSecret
+	c.OidcStateSecret = "state-secret"
+	server := RegisterRoutes(gin.Default(), &c)
+
+	// the gateway redirects to the provider, which redirects back right away
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/users/oidc/login?device_name=Laptop", nil)
+	require.NoError(t, err)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusFound, recorder.Code)
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.True(t, cookies[0].HttpOnly)
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := client.Get(recorder.Header().Get("Location"))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+
+	callbackWith := func(query string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/users/oidc/callback?"+query, nil)
+		require.NoError(t, err)
+		if cookie != nil {
+			request.AddCookie(cookie)
+		}
+		server.Router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	query := callback.Query()
+	query.Set("state", "another-state")
+
+	testCases := []struct {
+		name   string
+		query  string
+		cookie *http.Cookie
+		code   int
+	}{
+		{"Missing Cookie", callback.RawQuery, nil, http.StatusBadRequest},
+		{"Wrong State", query.Encode(), cookies[0], http.StatusBadRequest},
+		{"OK", callback.RawQuery, cookies[0], http.StatusOK},
+		{"Code already used", callback.RawQuery, cookies[0], http.StatusUnauthorized},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := callbackWith(tc.query, tc.cookie)
+			require.Equal(t, tc.code, recorder.Code)
+
+			if recorder.Code == http.StatusOK {
+				var resp struct {
+					Token string
+					User  struct {
+						Email string
+					}
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.Token)
+				require.Equal(t, mock.User.Email, resp.User.Email)
+			}
+		})
+	}
+}
+
+func TestJwks(t *testing.T) {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	c.JwtVerifyLocally = true
+	server := RegisterRoutes(gin.Default(), &c)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+		} `json:"keys"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Keys)
+
+	email, password := registerUser(t, server)
+	token, _ := loginAs(t, server, email, password)
+
+	testCases := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{"Verified Locally", token, http.StatusOK},
+		{"Tampered", token + "x", http.StatusUnauthorized},
+		{"Access Token", "kpat_unknown", http.StatusUnauthorized},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/users/profile", nil)
+			require.NoError(t, err)
+
+			request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tc.token))
+			server.Router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.code, recorder.Code)
+		})
+	}
+}
+
+func TestJwksSkipsRevocation(t *testing.T) {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	c.JwtVerifyLocally = true
+	local := RegisterRoutes(gin.Default(), &c)
+	remote := NewServer(t)
+
+	email, password := registerUser(t, remote)
+	token, refreshToken := loginAs(t, remote, email, password)
+
+	send := func(server *ServiceClient, method string, url string, body gin.H) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		server.Router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send(remote, http.MethodPost, "/users/logout", gin.H{"refresh_token": refreshToken})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// the users service rejects the token of the ended session, verified
+	// locally it still works until it expires
+	recorder = send(remote, http.MethodGet, "/users/profile", nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = send(local, http.MethodGet, "/users/profile", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCErrorStatus is the http status of an error a service returned as a gRPC
+// status, any other failure is a bad gateway.
+func GRPCErrorStatus(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.ResourceExhausted:
+		return http.StatusRequestEntityTooLarge
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// GRPCErrorResponse is the body of an error a service returned as a gRPC
+// status, its message without the rpc error prefix.
+func GRPCErrorResponse(err error) gin.H {
+	return gin.H{
+		"error": status.Convert(err).Message(),
+	}
+}
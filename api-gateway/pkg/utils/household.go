@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HouseholdID is the household_id query parameter of a request on pos,
+// balances or transactions, 0 (the user's own data) when it's missing.
+func HouseholdID(ctx *gin.Context) (int32, error) {
+	value := ctx.Query("household_id")
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid-household-id")
+	}
+
+	return int32(id), nil
+}
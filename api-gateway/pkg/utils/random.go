@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"math/rand"
 	"strings"
 	"time"
@@ -27,3 +28,7 @@ func RandomString(n int) string {
 
 	return sb.String()
 }
+
+func RandomEmail() string {
+	return fmt.Sprintf("%s@%s.com", RandomString(10), RandomString(6))
+}
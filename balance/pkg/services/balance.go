@@ -19,13 +19,22 @@ func (s *Server) UpsertBalance(ctx context.Context, req *pb.UpsertBalanceRequest
 	if req.Action != 0 && req.Action != 1 {
 		return genericUpsertBalanceResponse(http.StatusBadRequest, "invalid-action")
 	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericUpsertBalanceResponse(status, errMessage)
+	}
 
-	q := `
-		INSERT INTO balance (user_id, type, total)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (user_id, type)
+	// personal balances are unique per user and type, shared ones per household
+	conflict := "(user_id, type) WHERE household_id IS NULL"
+	if req.HouseholdId != 0 {
+		conflict = "(household_id, type) WHERE household_id IS NOT NULL"
+	}
+
+	q := fmt.Sprintf(`
+		INSERT INTO balance (user_id, household_id, type, total)
+		VALUES ($1, NULLIF($4, 0), $2, $3)
+		ON CONFLICT %s
 		DO UPDATE SET 
-	`
+	`, conflict)
 	if req.Action == 1 {
 		q = fmt.Sprintf("%s total = balance.total - EXCLUDED.total RETURNING id, total", q)
 	} else {
@@ -36,6 +45,7 @@ func (s *Server) UpsertBalance(ctx context.Context, req *pb.UpsertBalanceRequest
 		&req.UserId,
 		&req.Type,
 		&req.Total,
+		&req.HouseholdId,
 	)
 
 	var lastInsertedId, currentBalance int32
@@ -60,12 +70,17 @@ func (s *Server) GetUserBalance(ctx context.Context, req *pb.GetUserBalanceReque
 	if req.UserId == 0 {
 		return genericGetUserBalanceResponse(http.StatusBadRequest, "invalid-user-id")
 	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericGetUserBalanceResponse(status, errMessage)
+	}
 
 	q := `
-		SELECT type, total FROM balance WHERE user_id = $1
+		SELECT type, total FROM balance
+		WHERE household_id = $2 OR ($2 = 0 AND household_id IS NULL AND user_id = $1)
+		ORDER BY type
 	`
 
-	rows, err := s.DB.QueryContext(ctx, q, req.UserId)
+	rows, err := s.DB.QueryContext(ctx, q, req.UserId, req.HouseholdId)
 	if err != nil {
 		log.Println(err)
 		return genericGetUserBalanceResponse(http.StatusInternalServerError, err.Error())
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+)
+
+// checkMember is a copy of the one documented in the pos service.
+func (s *Server) checkMember(ctx context.Context, userId, householdId int32) (int, string) {
+	if householdId == 0 {
+		return http.StatusOK, ""
+	}
+	if householdId < 0 {
+		return http.StatusBadRequest, "invalid-household-id"
+	}
+
+	q := `SELECT 1 FROM household_members WHERE household_id = $1 AND user_id = $2`
+
+	var member int
+	err := s.DB.QueryRowContext(ctx, q, householdId, userId).Scan(&member)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusForbidden, "not-a-household-member"
+		}
+		return http.StatusInternalServerError, err.Error()
+	}
+
+	return http.StatusOK, ""
+}
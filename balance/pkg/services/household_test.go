@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/balance/pkg/config"
+	"github.com/maslow123/balance/pkg/pb"
+	"github.com/maslow123/balance/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// createHousehold is a copy of the one documented in the pos tests.
+func createHousehold(t *testing.T, ownerId int32, memberIds ...int32) int32 {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var householdId int32
+	q := `INSERT INTO households (name, owner_id) VALUES ($1, $2) RETURNING id`
+	require.NoError(t, db.QueryRow(q, utils.RandomString(10), ownerId).Scan(&householdId))
+
+	q = `INSERT INTO household_members (household_id, user_id, role) VALUES ($1, $2, $3)`
+	_, err = db.Exec(q, householdId, ownerId, "owner")
+	require.NoError(t, err)
+	for _, memberId := range memberIds {
+		_, err = db.Exec(q, householdId, memberId, "member")
+		require.NoError(t, err)
+	}
+
+	return householdId
+}
+
+func TestHouseholdBalance(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewBalanceServiceClient(conn)
+	householdId := createHousehold(t, 1, 2)
+	otherHouseholdId := createHousehold(t, 1)
+
+	testCases := []struct {
+		name string
+		req  *pb.UpsertBalanceRequest
+		resp *pb.UpsertBalanceResponse
+	}{
+		{
+			"OK Owner",
+			&pb.UpsertBalanceRequest{UserId: 1, HouseholdId: householdId, Type: 1, Total: 5000},
+			&pb.UpsertBalanceResponse{
+				Status:         http.StatusCreated,
+				Error:          "",
+				CurrentBalance: 5000,
+			},
+		},
+		{
+			"OK Member",
+			&pb.UpsertBalanceRequest{UserId: 2, HouseholdId: householdId, Type: 1, Total: 2000, Action: pb.UpsertBalanceRequest_DECREASE},
+			&pb.UpsertBalanceResponse{
+				Status:         http.StatusCreated,
+				Error:          "",
+				CurrentBalance: 3000,
+			},
+		},
+		{
+			"Not a member",
+			&pb.UpsertBalanceRequest{UserId: 2, HouseholdId: otherHouseholdId, Type: 1, Total: 2000},
+			&pb.UpsertBalanceResponse{
+				Status: http.StatusForbidden,
+				Error:  "not-a-household-member",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.UpsertBalance(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusCreated {
+				require.Equal(t, tc.resp.CurrentBalance, response.CurrentBalance)
+			}
+		})
+	}
+
+	// the household balance is apart from the personal ones of its members
+	balance, err := client.GetUserBalance(ctx, &pb.GetUserBalanceRequest{UserId: 2, HouseholdId: householdId})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), balance.Status)
+	require.Len(t, balance.Balances, 1)
+	require.Equal(t, int32(3000), balance.Balances[0].Total)
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/maslow123/balance/pkg/pb"
+)
+
+const (
+	liabilityCreditCard = 0
+	liabilityLoan       = 1
+
+	liabilityEntryCharge  = 0
+	liabilityEntryPayment = 1
+)
+
+func (s *Server) CreateLiability(ctx context.Context, req *pb.CreateLiabilityRequest) (*pb.CreateLiabilityResponse, error) {
+	if req.UserId == 0 {
+		return genericCreateLiabilityResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Name == "" {
+		return genericCreateLiabilityResponse(http.StatusBadRequest, "invalid-name")
+	}
+	if req.Type != liabilityCreditCard && req.Type != liabilityLoan {
+		return genericCreateLiabilityResponse(http.StatusBadRequest, "invalid-type")
+	}
+	if req.CreditLimit < 0 {
+		return genericCreateLiabilityResponse(http.StatusBadRequest, "invalid-credit-limit")
+	}
+	if req.StatementDay < 1 || req.StatementDay > 31 {
+		return genericCreateLiabilityResponse(http.StatusBadRequest, "invalid-statement-day")
+	}
+	if req.DueDay < 1 || req.DueDay > 31 {
+		return genericCreateLiabilityResponse(http.StatusBadRequest, "invalid-due-day")
+	}
+	if req.MinimumPayment < 0 {
+		return genericCreateLiabilityResponse(http.StatusBadRequest, "invalid-minimum-payment")
+	}
+
+	q := `
+		INSERT INTO liabilities
+		(user_id, name, type, credit_limit, statement_day, due_day, minimum_payment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	row := s.DB.QueryRowContext(ctx, q,
+		&req.UserId,
+		&req.Name,
+		&req.Type,
+		&req.CreditLimit,
+		&req.StatementDay,
+		&req.DueDay,
+		&req.MinimumPayment,
+	)
+
+	var lastInsertedId int32
+	err := row.Scan(&lastInsertedId)
+	if err != nil {
+		log.Println(err)
+		return genericCreateLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.CreateLiabilityResponse{
+		Status: http.StatusCreated,
+		Error:  "",
+		Id:     lastInsertedId,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetUserLiabilities(ctx context.Context, req *pb.GetUserLiabilitiesRequest) (*pb.GetUserLiabilitiesResponse, error) {
+	if req.UserId == 0 {
+		return genericGetUserLiabilitiesResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	q := `
+		SELECT
+			id, user_id, name, type, credit_limit, statement_day, due_day,
+			minimum_payment, total, created_at, updated_at
+		FROM liabilities
+		WHERE user_id = $1
+		ORDER BY id
+	`
+
+	rows, err := s.DB.QueryContext(ctx, q, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericGetUserLiabilitiesResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer rows.Close()
+
+	var liabilities []*pb.Liability
+
+	for rows.Next() {
+		liability, err := scanLiability(rows)
+		if err != nil {
+			log.Println(err)
+			return genericGetUserLiabilitiesResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		liabilities = append(liabilities, liability)
+	}
+
+	if err := rows.Close(); err != nil {
+		log.Println(err)
+		return genericGetUserLiabilitiesResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return genericGetUserLiabilitiesResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if len(liabilities) == 0 {
+		return genericGetUserLiabilitiesResponse(http.StatusNotFound, "liability-not-found")
+	}
+
+	resp := &pb.GetUserLiabilitiesResponse{
+		Status:      http.StatusOK,
+		Error:       "",
+		Liabilities: liabilities,
+	}
+
+	return resp, nil
+}
+
+// UpsertLiability charges (INCREASE) or reverses a charge (DECREASE) on the
+// owed amount of a liability. It is called by the transactions service when an
+// expense is booked against a credit card or loan.
+func (s *Server) UpsertLiability(ctx context.Context, req *pb.UpsertLiabilityRequest) (*pb.UpsertLiabilityResponse, error) {
+	if req.Id == 0 {
+		return genericUpsertLiabilityResponse(http.StatusBadRequest, "invalid-id")
+	}
+	if req.UserId == 0 {
+		return genericUpsertLiabilityResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Total <= 0 {
+		return genericUpsertLiabilityResponse(http.StatusBadRequest, "invalid-total")
+	}
+	if req.Action != pb.UpsertLiabilityRequest_INCREASE && req.Action != pb.UpsertLiabilityRequest_DECREASE {
+		return genericUpsertLiabilityResponse(http.StatusBadRequest, "invalid-action")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericUpsertLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	// NO KEY UPDATE is enough for changing the total and doesn't wait for the
+	// key share locks of transactions being inserted with this liability
+	q := `
+		SELECT type, credit_limit, total
+		FROM liabilities
+		WHERE id = $1 AND user_id = $2
+		FOR NO KEY UPDATE
+	`
+
+	var liabilityType, creditLimit, owed int32
+	row := tx.QueryRowContext(ctx, q, req.Id, req.UserId)
+	err = row.Scan(&liabilityType, &creditLimit, &owed)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericUpsertLiabilityResponse(http.StatusNotFound, "liability-not-found")
+		}
+		return genericUpsertLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if req.Action == pb.UpsertLiabilityRequest_INCREASE &&
+		liabilityType == liabilityCreditCard &&
+		creditLimit > 0 &&
+		owed+req.Total > creditLimit {
+		return genericUpsertLiabilityResponse(http.StatusBadRequest, "credit-limit-exceeded")
+	}
+
+	q = `UPDATE liabilities SET updated_at = now(), total = `
+	if req.Action == pb.UpsertLiabilityRequest_DECREASE {
+		q = fmt.Sprintf("%s total - $2 WHERE id = $1 RETURNING total", q)
+	} else {
+		q = fmt.Sprintf("%s total + $2 WHERE id = $1 RETURNING total", q)
+	}
+
+	var currentOwed int32
+	row = tx.QueryRowContext(ctx, q, req.Id, req.Total)
+	if err = row.Scan(&currentOwed); err != nil {
+		log.Println(err)
+		return genericUpsertLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// a reversed charge is recorded as a negative charge so it drops out of the statement
+	entryTotal := req.Total
+	if req.Action == pb.UpsertLiabilityRequest_DECREASE {
+		entryTotal = -req.Total
+	}
+
+	q = `INSERT INTO liability_entries (liability_id, action, total) VALUES ($1, $2, $3)`
+	_, err = tx.ExecContext(ctx, q, req.Id, liabilityEntryCharge, entryTotal)
+	if err != nil {
+		log.Println(err)
+		return genericUpsertLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericUpsertLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.UpsertLiabilityResponse{
+		Status:      http.StatusCreated,
+		Error:       "",
+		Id:          req.Id,
+		CurrentOwed: currentOwed,
+	}
+
+	return resp, nil
+}
+
+// PayLiability moves money from one of the user's asset balances (cash or
+// transfer) to a liability, lowering both in a single database transaction.
+func (s *Server) PayLiability(ctx context.Context, req *pb.PayLiabilityRequest) (*pb.PayLiabilityResponse, error) {
+	if req.Id == 0 {
+		return genericPayLiabilityResponse(http.StatusBadRequest, "invalid-id")
+	}
+	if req.UserId == 0 {
+		return genericPayLiabilityResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.BalanceType != 0 && req.BalanceType != 1 {
+		return genericPayLiabilityResponse(http.StatusBadRequest, "invalid-balance-type")
+	}
+	if req.Total <= 0 {
+		return genericPayLiabilityResponse(http.StatusBadRequest, "invalid-total")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericPayLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `SELECT total FROM liabilities WHERE id = $1 AND user_id = $2 FOR NO KEY UPDATE`
+
+	var owed int32
+	row := tx.QueryRowContext(ctx, q, req.Id, req.UserId)
+	err = row.Scan(&owed)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericPayLiabilityResponse(http.StatusNotFound, "liability-not-found")
+		}
+		return genericPayLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if req.Total > owed {
+		return genericPayLiabilityResponse(http.StatusBadRequest, "payment-exceeds-owed")
+	}
+
+	q = `
+		UPDATE balance SET total = total - $3, updated_at = now()
+		WHERE user_id = $1 AND type = $2 AND household_id IS NULL
+		RETURNING total
+	`
+
+	var currentBalance int32
+	row = tx.QueryRowContext(ctx, q, req.UserId, req.BalanceType, req.Total)
+	err = row.Scan(&currentBalance)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericPayLiabilityResponse(http.StatusNotFound, "user-balance-not-found")
+		}
+		return genericPayLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `
+		UPDATE liabilities SET total = total - $2, updated_at = now()
+		WHERE id = $1
+		RETURNING total
+	`
+
+	var currentOwed int32
+	row = tx.QueryRowContext(ctx, q, req.Id, req.Total)
+	if err = row.Scan(&currentOwed); err != nil {
+		log.Println(err)
+		return genericPayLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `INSERT INTO liability_entries (liability_id, action, total) VALUES ($1, $2, $3)`
+	_, err = tx.ExecContext(ctx, q, req.Id, liabilityEntryPayment, req.Total)
+	if err != nil {
+		log.Println(err)
+		return genericPayLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericPayLiabilityResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.PayLiabilityResponse{
+		Status:         http.StatusOK,
+		Error:          "",
+		CurrentOwed:    currentOwed,
+		CurrentBalance: currentBalance,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetLiabilityStatement(ctx context.Context, req *pb.GetLiabilityStatementRequest) (*pb.GetLiabilityStatementResponse, error) {
+	if req.Id == 0 {
+		return genericGetLiabilityStatementResponse(http.StatusBadRequest, "invalid-id")
+	}
+	if req.UserId == 0 {
+		return genericGetLiabilityStatementResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	q := `
+		SELECT
+			id, user_id, name, type, credit_limit, statement_day, due_day,
+			minimum_payment, total, created_at, updated_at
+		FROM liabilities
+		WHERE id = $1 AND user_id = $2
+	`
+
+	row := s.DB.QueryRowContext(ctx, q, req.Id, req.UserId)
+	liability, err := scanLiability(row)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericGetLiabilityStatementResponse(http.StatusNotFound, "liability-not-found")
+		}
+		return genericGetLiabilityStatementResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	statementDate, dueDate := statementPeriod(time.Now(), int(liability.StatementDay), int(liability.DueDay))
+
+	// Everything booked after the closing date belongs to the next statement.
+	q = `
+		SELECT
+			COALESCE(SUM(CASE WHEN action = $2 THEN total ELSE 0 END), 0) charged,
+			COALESCE(SUM(CASE WHEN action = $3 THEN total ELSE 0 END), 0) paid
+		FROM liability_entries
+		WHERE liability_id = $1 AND created_at >= $4
+	`
+
+	var chargedSince, paidSince int32
+	row = s.DB.QueryRowContext(ctx, q, req.Id, liabilityEntryCharge, liabilityEntryPayment, statementDate)
+	if err = row.Scan(&chargedSince, &paidSince); err != nil {
+		log.Println(err)
+		return genericGetLiabilityStatementResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	statementBalance := liability.Total - chargedSince + paidSince
+	if statementBalance < 0 {
+		statementBalance = 0
+	}
+
+	remaining := statementBalance - paidSince
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	minimumPaymentDue := liability.MinimumPayment - paidSince
+	if minimumPaymentDue > remaining {
+		minimumPaymentDue = remaining
+	}
+	if minimumPaymentDue < 0 {
+		minimumPaymentDue = 0
+	}
+
+	resp := &pb.GetLiabilityStatementResponse{
+		Status:             http.StatusOK,
+		Error:              "",
+		Liability:          liability,
+		StatementBalance:   statementBalance,
+		PaidSinceStatement: paidSince,
+		MinimumPaymentDue:  minimumPaymentDue,
+		StatementDate:      int32(statementDate.Unix()),
+		DueDate:            int32(dueDate.Unix()),
+	}
+
+	return resp, nil
+}
+
+type liabilityScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLiability(row liabilityScanner) (*pb.Liability, error) {
+	var liability pb.Liability
+	var createdAt, updatedAt time.Time
+
+	err := row.Scan(
+		&liability.Id,
+		&liability.UserId,
+		&liability.Name,
+		&liability.Type,
+		&liability.CreditLimit,
+		&liability.StatementDay,
+		&liability.DueDay,
+		&liability.MinimumPayment,
+		&liability.Total,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	liability.CreatedAt = int32(createdAt.Unix())
+	liability.UpdatedAt = int32(updatedAt.Unix())
+
+	return &liability, nil
+}
+
+// statementPeriod returns the most recent statement closing date on or before
+// now, and the due date of that statement. Days past the end of a month are
+// clamped, so a statement day of 31 closes on the 30th in April. A due day on or
+// before the statement day falls in the month after the closing date.
+func statementPeriod(now time.Time, statementDay, dueDay int) (time.Time, time.Time) {
+	year, month, _ := now.Date()
+	statementDate := dayInMonth(year, month, statementDay, now.Location())
+	if statementDate.After(now) {
+		statementDate = dayInMonth(year, month-1, statementDay, now.Location())
+	}
+
+	year, month, _ = statementDate.Date()
+	if dueDay <= statementDay {
+		month++
+	}
+	dueDate := dayInMonth(year, month, dueDay, now.Location())
+
+	return statementDate, dueDate
+}
+
+func dayInMonth(year int, month time.Month, day int, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maslow123/balance/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func createLiability(t *testing.T, ctx context.Context, client pb.BalanceServiceClient) int32 {
+	arg := &pb.CreateLiabilityRequest{
+		UserId:         1,
+		Name:           "Kartu kredit",
+		Type:           0,
+		CreditLimit:    100000,
+		StatementDay:   25,
+		DueDay:         10,
+		MinimumPayment: 5000,
+	}
+	liability, err := client.CreateLiability(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), liability.Status)
+
+	return liability.Id
+}
+
+func TestCreateLiability(t *testing.T) {
+	testCases := []struct {
+		name string
+		req  *pb.CreateLiabilityRequest
+		resp *pb.CreateLiabilityResponse
+	}{
+		{
+			"OK",
+			&pb.CreateLiabilityRequest{
+				UserId:         1,
+				Name:           "Kartu kredit",
+				Type:           0,
+				CreditLimit:    100000,
+				StatementDay:   25,
+				DueDay:         10,
+				MinimumPayment: 5000,
+			},
+			&pb.CreateLiabilityResponse{
+				Status: http.StatusCreated,
+				Error:  "",
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.CreateLiabilityRequest{
+				UserId:       0,
+				Name:         "Kartu kredit",
+				StatementDay: 25,
+				DueDay:       10,
+			},
+			&pb.CreateLiabilityResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Invalid Type",
+			&pb.CreateLiabilityRequest{
+				UserId:       1,
+				Name:         "Kartu kredit",
+				Type:         2,
+				StatementDay: 25,
+				DueDay:       10,
+			},
+			&pb.CreateLiabilityResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-type",
+			},
+		},
+		{
+			"Invalid Statement Day",
+			&pb.CreateLiabilityRequest{
+				UserId:       1,
+				Name:         "Kartu kredit",
+				StatementDay: 32,
+				DueDay:       10,
+			},
+			&pb.CreateLiabilityResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-statement-day",
+			},
+		},
+		{
+			"Invalid Due Day",
+			&pb.CreateLiabilityRequest{
+				UserId:       1,
+				Name:         "Kartu kredit",
+				StatementDay: 25,
+				DueDay:       0,
+			},
+			&pb.CreateLiabilityResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-due-day",
+			},
+		},
+	}
+
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewBalanceServiceClient(conn)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.CreateLiability(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+}
+
+func TestUpsertLiability(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewBalanceServiceClient(conn)
+	liabilityId := createLiability(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.UpsertLiabilityRequest
+		resp *pb.UpsertLiabilityResponse
+	}{
+		{
+			"OK Charge",
+			&pb.UpsertLiabilityRequest{
+				Id:     liabilityId,
+				UserId: 1,
+				Total:  30000,
+				Action: pb.UpsertLiabilityRequest_INCREASE,
+			},
+			&pb.UpsertLiabilityResponse{
+				Status:      http.StatusCreated,
+				Error:       "",
+				CurrentOwed: 30000,
+			},
+		},
+		{
+			"OK Reverse",
+			&pb.UpsertLiabilityRequest{
+				Id:     liabilityId,
+				UserId: 1,
+				Total:  10000,
+				Action: pb.UpsertLiabilityRequest_DECREASE,
+			},
+			&pb.UpsertLiabilityResponse{
+				Status:      http.StatusCreated,
+				Error:       "",
+				CurrentOwed: 20000,
+			},
+		},
+		{
+			"Credit Limit Exceeded",
+			&pb.UpsertLiabilityRequest{
+				Id:     liabilityId,
+				UserId: 1,
+				Total:  90000,
+				Action: pb.UpsertLiabilityRequest_INCREASE,
+			},
+			&pb.UpsertLiabilityResponse{
+				Status: http.StatusBadRequest,
+				Error:  "credit-limit-exceeded",
+			},
+		},
+		{
+			"Invalid Total",
+			&pb.UpsertLiabilityRequest{
+				Id:     liabilityId,
+				UserId: 1,
+				Total:  0,
+				Action: pb.UpsertLiabilityRequest_INCREASE,
+			},
+			&pb.UpsertLiabilityResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-total",
+			},
+		},
+		{
+			"Other User",
+			&pb.UpsertLiabilityRequest{
+				Id:     liabilityId,
+				UserId: 2,
+				Total:  1000,
+				Action: pb.UpsertLiabilityRequest_INCREASE,
+			},
+			&pb.UpsertLiabilityResponse{
+				Status: http.StatusNotFound,
+				Error:  "liability-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.UpsertLiability(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == int32(http.StatusCreated) {
+				require.Equal(t, tc.resp.CurrentOwed, response.CurrentOwed)
+			}
+		})
+	}
+}
+
+func TestPayLiability(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewBalanceServiceClient(conn)
+	liabilityId := createLiability(t, ctx, client)
+
+	_, err := client.UpsertLiability(ctx, &pb.UpsertLiabilityRequest{
+		Id:     liabilityId,
+		UserId: 1,
+		Total:  20000,
+		Action: pb.UpsertLiabilityRequest_INCREASE,
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		req  *pb.PayLiabilityRequest
+		resp *pb.PayLiabilityResponse
+	}{
+		{
+			"OK",
+			&pb.PayLiabilityRequest{
+				Id:          liabilityId,
+				UserId:      1,
+				BalanceType: 1,
+				Total:       15000,
+			},
+			&pb.PayLiabilityResponse{
+				Status:      http.StatusOK,
+				Error:       "",
+				CurrentOwed: 5000,
+			},
+		},
+		{
+			"Payment Exceeds Owed",
+			&pb.PayLiabilityRequest{
+				Id:          liabilityId,
+				UserId:      1,
+				BalanceType: 1,
+				Total:       15000,
+			},
+			&pb.PayLiabilityResponse{
+				Status: http.StatusBadRequest,
+				Error:  "payment-exceeds-owed",
+			},
+		},
+		{
+			"Invalid Balance Type",
+			&pb.PayLiabilityRequest{
+				Id:          liabilityId,
+				UserId:      1,
+				BalanceType: 3,
+				Total:       1000,
+			},
+			&pb.PayLiabilityResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-balance-type",
+			},
+		},
+		{
+			"Liability Not Found",
+			&pb.PayLiabilityRequest{
+				Id:          99999,
+				UserId:      1,
+				BalanceType: 1,
+				Total:       1000,
+			},
+			&pb.PayLiabilityResponse{
+				Status: http.StatusNotFound,
+				Error:  "liability-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.PayLiability(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == int32(http.StatusOK) {
+				require.Equal(t, tc.resp.CurrentOwed, response.CurrentOwed)
+			}
+		})
+	}
+}
+
+func TestGetLiabilityStatement(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewBalanceServiceClient(conn)
+	liabilityId := createLiability(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.GetLiabilityStatementRequest
+		resp *pb.GetLiabilityStatementResponse
+	}{
+		{
+			"OK",
+			&pb.GetLiabilityStatementRequest{
+				Id:     liabilityId,
+				UserId: 1,
+			},
+			&pb.GetLiabilityStatementResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Invalid ID",
+			&pb.GetLiabilityStatementRequest{
+				Id:     0,
+				UserId: 1,
+			},
+			&pb.GetLiabilityStatementResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-id",
+			},
+		},
+		{
+			"Liability Not Found",
+			&pb.GetLiabilityStatementRequest{
+				Id:     liabilityId,
+				UserId: 2,
+			},
+			&pb.GetLiabilityStatementResponse{
+				Status: http.StatusNotFound,
+				Error:  "liability-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.GetLiabilityStatement(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == int32(http.StatusOK) {
+				require.NotNil(t, response.Liability)
+				require.Greater(t, response.DueDate, response.StatementDate)
+			}
+		})
+	}
+}
+
+func TestStatementPeriod(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name          string
+		now           time.Time
+		statementDay  int
+		dueDay        int
+		statementDate time.Time
+		dueDate       time.Time
+	}{
+		{
+			"After closing, due next month",
+			date(2022, time.May, 28),
+			25, 10,
+			date(2022, time.May, 25),
+			date(2022, time.June, 10),
+		},
+		{
+			"Before closing uses previous statement",
+			date(2022, time.May, 20),
+			25, 10,
+			date(2022, time.April, 25),
+			date(2022, time.May, 10),
+		},
+		{
+			"Due later in the same month",
+			date(2022, time.May, 5),
+			1, 20,
+			date(2022, time.May, 1),
+			date(2022, time.May, 20),
+		},
+		{
+			"Clamped to end of month",
+			date(2022, time.March, 15),
+			31, 15,
+			date(2022, time.February, 28),
+			date(2022, time.March, 15),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			statementDate, dueDate := statementPeriod(tc.now, tc.statementDay, tc.dueDay)
+
+			require.Equal(t, tc.statementDate, statementDate)
+			require.Equal(t, tc.dueDate, dueDate)
+		})
+	}
+}
@@ -15,3 +15,38 @@ func genericGetUserBalanceResponse(statusCode int, errorMessage string) (*pb.Get
 		Error:  errorMessage,
 	}, nil
 }
+
+func genericCreateLiabilityResponse(statusCode int, errorMessage string) (*pb.CreateLiabilityResponse, error) {
+	return &pb.CreateLiabilityResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericGetUserLiabilitiesResponse(statusCode int, errorMessage string) (*pb.GetUserLiabilitiesResponse, error) {
+	return &pb.GetUserLiabilitiesResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericUpsertLiabilityResponse(statusCode int, errorMessage string) (*pb.UpsertLiabilityResponse, error) {
+	return &pb.UpsertLiabilityResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericPayLiabilityResponse(statusCode int, errorMessage string) (*pb.PayLiabilityResponse, error) {
+	return &pb.PayLiabilityResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericGetLiabilityStatementResponse(statusCode int, errorMessage string) (*pb.GetLiabilityStatementResponse, error) {
+	return &pb.GetLiabilityStatementResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
@@ -1,67 +0,0 @@
-package utils
-
-import (
-	"errors"
-	"time"
-
-	"github.com/golang-jwt/jwt"
-)
-
-type JwtWrapper struct {
-	SecretKey       string
-	Issuer          string
-	ExpirationHours int32
-}
-
-type jwtClaims struct {
-	jwt.StandardClaims
-	Id    int32
-	Email string
-}
-
-func (w *JwtWrapper) GenerateToken(user string) (signedToken string, err error) {
-	claims := &jwtClaims{
-		Email: user,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(w.ExpirationHours)).Unix(),
-			Issuer:    w.Issuer,
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	signedToken, err = token.SignedString([]byte(w.SecretKey))
-
-	if err != nil {
-		return "", err
-	}
-
-	return signedToken, nil
-}
-
-func (w *JwtWrapper) ValidateToken(signedToken string) (claims *jwtClaims, err error) {
-	token, err := jwt.ParseWithClaims(
-		signedToken,
-		&jwtClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(w.SecretKey), nil
-		},
-	)
-
-	if err != nil {
-		return
-	}
-
-	claims, ok := token.Claims.(*jwtClaims)
-
-	if !ok {
-		return nil, errors.New("Couldn't parse claims")
-	}
-
-	if claims.ExpiresAt < time.Now().Local().Unix() {
-		return nil, errors.New("JWT is expired")
-	}
-
-	return claims, nil
-
-}
@@ -0,0 +1,215 @@
+// Package jwks verifies the access tokens of the users service with the public
+// keys it publishes, so a token can be checked without a Validate call. It is a
+// module of its own so every service can use it, not only the gateway.
+//
+// Verify only checks the signature, issuer and expiry. Revocation lives in the
+// users service, so a token verified here is still accepted after a logout,
+// revoking its session, a password change, disabling the user or changing
+// their role, and carries the role it was issued with, until it expires. Use
+// it where that is acceptable, and keep the access tokens short lived.
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWK is a public key as the users service publishes it.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// Claims of the access tokens.
+type Claims struct {
+	UserId       int32  `json:"UserId"`
+	SessionId    int32  `json:"SessionId"`
+	TokenVersion int32  `json:"TokenVersion"`
+	Role         string `json:"Role"`
+	Jti          string `json:"jti"`
+	Issuer       string `json:"iss"`
+	ExpiresAt    int64  `json:"exp"`
+}
+
+var (
+	ErrInvalidToken = errors.New("invalid-token")
+	ErrExpiredToken = errors.New("token-expired")
+)
+
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// Verifier caches the keys for MaxAge, and fetches them again sooner for a kid
+// it doesn't know since the users service publishes new keys before using them.
+type Verifier struct {
+	Issuer string
+	Fetch  func(ctx context.Context) ([]JWK, error)
+	MaxAge time.Duration
+	// MinRefetch limits fetching for unknown kids, which anyone can send
+	MinRefetch time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]publicKey
+	fetchedAt time.Time
+}
+
+func NewVerifier(issuer string, fetch func(ctx context.Context) ([]JWK, error)) *Verifier {
+	return &Verifier{
+		Issuer:     issuer,
+		Fetch:      fetch,
+		MaxAge:     5 * time.Minute,
+		MinRefetch: 10 * time.Second,
+	}
+}
+
+func parseKey(k JWK) (publicKey, error) {
+	switch {
+	case k.Kty == "RSA" && k.Alg == "RS256":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return publicKey{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return publicKey{}, err
+		}
+		return publicKey{k.Alg, &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519" && k.Alg == "EdDSA":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return publicKey{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return publicKey{}, errors.New("invalid ed25519 key")
+		}
+		return publicKey{k.Alg, ed25519.PublicKey(x)}, nil
+	}
+
+	return publicKey{}, errors.New("unsupported key " + k.Kty + " " + k.Alg)
+}
+
+// key returns the key of a kid, fetching the keys when they are stale or the
+// kid is unknown.
+func (v *Verifier) key(ctx context.Context, kid string) (publicKey, bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	age := time.Since(v.fetchedAt)
+	if (ok && age < v.MaxAge) || (!ok && v.keys != nil && age < v.MinRefetch) {
+		return key, ok, nil
+	}
+
+	jwks, err := v.Fetch(ctx)
+	if err != nil {
+		// the keys we have are better than none while the service is down
+		return key, ok, err
+	}
+
+	keys := map[string]publicKey{}
+	for _, k := range jwks {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		parsed, err := parseKey(k)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = parsed
+	}
+	v.keys = keys
+	v.fetchedAt = time.Now()
+
+	key, ok = v.keys[kid]
+
+	return key, ok, nil
+}
+
+// Verify checks the signature, issuer and expiry of an access token.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Kid == "" {
+		return nil, ErrInvalidToken
+	}
+
+	key, ok, err := v.key(ctx, header.Kid)
+	if !ok {
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+	// the key decides the algorithm, not the token
+	if header.Alg != key.alg {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	switch public := key.key.(type) {
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		if rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature) != nil {
+			return nil, ErrInvalidToken
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(public, signed, signature) {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	var claims Claims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != v.Issuer || claims.UserId == 0 {
+		return nil, ErrInvalidToken
+	}
+	if claims.ExpiresAt < time.Now().Unix() {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,179 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testKey struct {
+	jwk     JWK
+	private crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return testKey{JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: "RS256",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(private.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes()),
+	}, private}
+}
+
+func newEd25519Key(t *testing.T, kid string) testKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return testKey{JWK{
+		Kty: "OKP",
+		Kid: kid,
+		Alg: "EdDSA",
+		Use: "sig",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(public),
+	}, private}
+}
+
+func sign(t *testing.T, key testKey, alg string, claims Claims) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": key.jwk.Kid})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch private := key.private.(type) {
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, private, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(private, []byte(signed))
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() Claims {
+	return Claims{
+		UserId:    1,
+		SessionId: 2,
+		Role:      "user",
+		Jti:       "jti",
+		Issuer:    "user-service",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	edKey := newEd25519Key(t, "ed")
+	unknown := newEd25519Key(t, "unknown")
+
+	v := NewVerifier("user-service", func(ctx context.Context) ([]JWK, error) {
+		return []JWK{rsaKey.jwk, edKey.jwk}, nil
+	})
+
+	expired := validClaims()
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	otherIssuer := validClaims()
+	otherIssuer.Issuer = "another-service"
+
+	testCases := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"RS256", sign(t, rsaKey, "RS256", validClaims()), nil},
+		{"EdDSA", sign(t, edKey, "EdDSA", validClaims()), nil},
+		{"Expired", sign(t, edKey, "EdDSA", expired), ErrExpiredToken},
+		{"Other Issuer", sign(t, edKey, "EdDSA", otherIssuer), ErrInvalidToken},
+		{"Unknown Key", sign(t, unknown, "EdDSA", validClaims()), ErrInvalidToken},
+		{"Algorithm of another Key", sign(t, edKey, "RS256", validClaims()), ErrInvalidToken},
+		{"Tampered", sign(t, rsaKey, "RS256", validClaims()) + "x", ErrInvalidToken},
+		{"Malformed", "kpat_token", ErrInvalidToken},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), tc.token)
+			require.Equal(t, tc.err, err)
+			if tc.err == nil {
+				require.Equal(t, int32(1), claims.UserId)
+				require.Equal(t, int32(2), claims.SessionId)
+				require.Equal(t, "user", claims.Role)
+			}
+		})
+	}
+}
+
+func TestVerifyRotation(t *testing.T) {
+	current := newEd25519Key(t, "current")
+	next := newRSAKey(t, "next")
+
+	fetches := 0
+	published := []JWK{current.jwk}
+	v := NewVerifier("user-service", func(ctx context.Context) ([]JWK, error) {
+		fetches++
+		return published, nil
+	})
+	v.MinRefetch = 0
+
+	_, err := v.Verify(context.Background(), sign(t, current, "EdDSA", validClaims()))
+	require.NoError(t, err)
+
+	// a kid signed after the last fetch is fetched right away
+	published = []JWK{next.jwk, current.jwk}
+	_, err = v.Verify(context.Background(), sign(t, next, "RS256", validClaims()))
+	require.NoError(t, err)
+	require.Equal(t, 2, fetches)
+
+	// known keys are cached
+	_, err = v.Verify(context.Background(), sign(t, current, "EdDSA", validClaims()))
+	require.NoError(t, err)
+	require.Equal(t, 2, fetches)
+
+	// a stale key is used while the users service can't be reached
+	v.MaxAge = 0
+	v.Fetch = func(ctx context.Context) ([]JWK, error) {
+		return nil, errors.New("unavailable")
+	}
+	_, err = v.Verify(context.Background(), sign(t, current, "EdDSA", validClaims()))
+	require.NoError(t, err)
+}
+
+func TestVerifyUnknownKidRateLimit(t *testing.T) {
+	key := newEd25519Key(t, "key")
+	unknown := newEd25519Key(t, "unknown")
+
+	fetches := 0
+	v := NewVerifier("user-service", func(ctx context.Context) ([]JWK, error) {
+		fetches++
+		return []JWK{key.jwk}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := v.Verify(context.Background(), sign(t, unknown, "EdDSA", validClaims()))
+		require.Equal(t, ErrInvalidToken, err)
+	}
+	require.Equal(t, 1, fetches)
+}
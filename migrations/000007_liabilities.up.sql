@@ -0,0 +1,31 @@
+CREATE TABLE "liabilities" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "type" int NOT NULL, -- 0: credit card, 1: loan
+  "credit_limit" int NOT NULL DEFAULT 0,
+  "statement_day" int NOT NULL, -- day of month the statement closes
+  "due_day" int NOT NULL, -- day of month the payment is due
+  "minimum_payment" int NOT NULL DEFAULT 0,
+  "total" int DEFAULT 0, -- currently owed amount
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "liability_entries" (
+  "id" SERIAL PRIMARY KEY,
+  "liability_id" int NOT NULL,
+  "action" int NOT NULL, -- 0: charge, 1: payment
+  "total" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "liabilities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "liability_entries" ADD FOREIGN KEY ("liability_id") REFERENCES "liabilities" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "liabilities" ("user_id");
+CREATE INDEX ON "liability_entries" ("liability_id", "created_at");
+
+-- expenses booked against a liability instead of a cash/transfer balance
+ALTER TABLE transactions ADD liability_id INT DEFAULT NULL;
+ALTER TABLE "transactions" ADD FOREIGN KEY ("liability_id") REFERENCES "liabilities" ("id") ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+-- sub-categories, e.g. "Food > Groceries"
+ALTER TABLE pos ADD parent_id INT DEFAULT NULL;
+ALTER TABLE "pos" ADD FOREIGN KEY ("parent_id") REFERENCES "pos" ("id") ON DELETE RESTRICT;
+
+CREATE INDEX ON "pos" ("parent_id");
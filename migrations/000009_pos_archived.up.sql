@@ -0,0 +1 @@
+ALTER TABLE pos ADD archived BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,5 @@
+ALTER TABLE pos ADD sort_order INT NOT NULL DEFAULT 0;
+ALTER TABLE pos ADD icon varchar(50) DEFAULT NULL;
+
+-- keep the current (creation) order for existing pos
+UPDATE pos SET sort_order = id;
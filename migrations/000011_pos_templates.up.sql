@@ -0,0 +1,46 @@
+CREATE TABLE "pos_templates" (
+  "id" SERIAL PRIMARY KEY,
+  "code" varchar(50) NOT NULL UNIQUE,
+  "name" varchar(100) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "pos_template_items" (
+  "id" SERIAL PRIMARY KEY,
+  "template_id" int NOT NULL,
+  "name" varchar(255) NOT NULL,
+  "type" int NOT NULL, -- same as pos.type, 0: income, 1: expense
+  "color" varchar(10) NOT NULL DEFAULT '#FFFFFF',
+  "icon" varchar(50) DEFAULT NULL,
+  "sort_order" int NOT NULL DEFAULT 0
+);
+
+ALTER TABLE "pos_template_items" ADD FOREIGN KEY ("template_id") REFERENCES "pos_templates" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "pos_template_items" ("template_id", "sort_order");
+
+-- Indonesian household defaults, applied to new users through DEFAULT_POS_TEMPLATE
+INSERT INTO pos_templates (code, name)
+VALUES
+('id-household', 'Rumah Tangga');
+
+INSERT INTO pos_template_items (template_id, name, type, color, icon, sort_order)
+SELECT t.id, i.name, i.type, i.color, i.icon, i.sort_order
+FROM pos_templates t,
+(VALUES
+  ('Gaji', 0, '#2E7D32', 'wallet', 1),
+  ('Bonus & THR', 0, '#43A047', 'gift', 2),
+  ('Usaha Sampingan', 0, '#66BB6A', 'store', 3),
+  ('Makan & Minum', 1, '#EF6C00', 'utensils', 4),
+  ('Belanja Bulanan', 1, '#F4511E', 'shopping-cart', 5),
+  ('Transportasi', 1, '#1E88E5', 'car', 6),
+  ('Listrik & Air', 1, '#FDD835', 'bolt', 7),
+  ('Pulsa & Internet', 1, '#8E24AA', 'wifi', 8),
+  ('Cicilan', 1, '#6D4C41', 'credit-card', 9),
+  ('Pendidikan', 1, '#3949AB', 'book', 10),
+  ('Kesehatan', 1, '#E53935', 'heart', 11),
+  ('Hiburan', 1, '#D81B60', 'film', 12),
+  ('Zakat & Sedekah', 1, '#00897B', 'hand-heart', 13),
+  ('Tabungan', 1, '#546E7A', 'piggy-bank', 14)
+) AS i (name, type, color, icon, sort_order)
+WHERE t.code = 'id-household';
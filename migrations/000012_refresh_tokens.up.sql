@@ -0,0 +1,33 @@
+-- refresh tokens are stored as sha256 hashes, every rotation keeps the session_id
+-- of the token it replaces so a reused token can revoke the whole session.
+-- Expiry times are compared with the clock of the service, so they are stored
+-- with their time zone.
+CREATE TABLE "refresh_tokens" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "session_id" int NOT NULL,
+  "token_hash" varchar(64) NOT NULL UNIQUE,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz DEFAULT NULL,
+  "replaced_by" int DEFAULT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+-- access tokens revoked before they expire (logout), keyed by the jti claim
+CREATE TABLE "revoked_tokens" (
+  "jti" varchar(64) PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("replaced_by") REFERENCES "refresh_tokens" ("id") ON DELETE SET NULL;
+ALTER TABLE "revoked_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "refresh_tokens" ("user_id");
+CREATE INDEX ON "refresh_tokens" ("session_id");
+CREATE INDEX ON "revoked_tokens" ("expires_at");
+
+-- copied into every access token, bumping it (password change) rejects all tokens issued before
+ALTER TABLE users ADD token_version int NOT NULL DEFAULT 0;
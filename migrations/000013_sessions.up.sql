@@ -0,0 +1,18 @@
+-- every login creates a session, the refresh tokens rotated from it and the
+-- access tokens issued with them belong to it so a session can be revoked on its own
+CREATE TABLE "sessions" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "device_name" varchar(100) NOT NULL DEFAULT '',
+  "user_agent" varchar(255) NOT NULL DEFAULT '',
+  "ip" varchar(45) NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_seen_at" timestamptz NOT NULL DEFAULT (now()),
+  "revoked_at" timestamptz DEFAULT NULL
+);
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "sessions" ("user_id");
+
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("session_id") REFERENCES "sessions" ("id") ON DELETE CASCADE;
@@ -0,0 +1,13 @@
+-- single use tokens sent by ForgotPassword, stored as sha256 hashes
+CREATE TABLE "password_reset_tokens" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "token_hash" varchar(64) NOT NULL UNIQUE,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz DEFAULT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "password_reset_tokens" ("user_id");
@@ -0,0 +1,32 @@
+-- emails are stored lower case and must be unique, later duplicates of an
+-- address (like the second seeded user1@gmail.com) get a placeholder address
+ALTER TABLE "users" ALTER COLUMN "email" TYPE varchar(254);
+
+UPDATE "users" u SET "email" = 'duplicate-' || u.id || '@invalid'
+WHERE EXISTS (
+  SELECT 1 FROM "users" o
+  WHERE lower(trim(o.email)) = lower(trim(u.email)) AND o.id < u.id
+);
+
+UPDATE "users" SET "email" = lower(trim("email"));
+
+CREATE UNIQUE INDEX "users_email_key" ON "users" ("email");
+
+-- a changed address stays pending until it is confirmed, email keeps the old one
+ALTER TABLE "users" ADD "email_verified" boolean NOT NULL DEFAULT false;
+ALTER TABLE "users" ADD "pending_email" varchar(254) DEFAULT NULL;
+
+-- single use tokens mailed to confirm an address, email is the address it confirms
+CREATE TABLE "email_verification_tokens" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "email" varchar(254) NOT NULL,
+  "token_hash" varchar(64) NOT NULL UNIQUE,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz DEFAULT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "email_verification_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "email_verification_tokens" ("user_id");
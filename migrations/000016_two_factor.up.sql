@@ -0,0 +1,35 @@
+-- TOTP two factor authentication, the secret is set on enrollment and only
+-- enforced once a code confirmed it (totp_enabled). totp_last_step is the time
+-- step of the last accepted code so a code can't be used twice.
+ALTER TABLE "users" ADD "totp_secret" varchar(64) DEFAULT NULL;
+ALTER TABLE "users" ADD "totp_enabled" boolean NOT NULL DEFAULT false;
+ALTER TABLE "users" ADD "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+-- single use codes for when the authenticator is lost, stored as sha256 hashes
+CREATE TABLE "totp_recovery_codes" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "code_hash" varchar(64) NOT NULL,
+  "used_at" timestamptz DEFAULT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+-- a login that passed the password check and waits for the second factor, it
+-- keeps the device of the login for the session created once it's verified
+CREATE TABLE "two_factor_challenges" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "token_hash" varchar(64) NOT NULL UNIQUE,
+  "device_name" varchar(100) NOT NULL DEFAULT '',
+  "user_agent" varchar(255) NOT NULL DEFAULT '',
+  "ip" varchar(45) NOT NULL DEFAULT '',
+  "attempts" int NOT NULL DEFAULT 0,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "totp_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "two_factor_challenges" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "totp_recovery_codes" ("user_id");
+CREATE INDEX ON "two_factor_challenges" ("user_id");
@@ -0,0 +1,9 @@
+-- failed logins per account ("email:<address>") and per client ("ip:<address>"),
+-- once failures reach the configured limit the key is locked with a backoff
+-- that doubles on every further failure
+CREATE TABLE "login_attempts" (
+  "key" varchar(300) PRIMARY KEY,
+  "failures" int NOT NULL DEFAULT 0,
+  "last_failure_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz DEFAULT NULL
+);
@@ -0,0 +1,16 @@
+-- a user's export of all their data, the zip is built in the background and
+-- can be downloaded until it expires
+CREATE TABLE "data_exports" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "status" varchar(10) NOT NULL DEFAULT 'pending', -- pending, done, failed
+  "file_name" varchar(100) NOT NULL DEFAULT '',
+  "size" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "completed_at" timestamptz DEFAULT NULL,
+  "expires_at" timestamptz DEFAULT NULL
+);
+
+ALTER TABLE "data_exports" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "data_exports" ("user_id");
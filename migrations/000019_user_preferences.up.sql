@@ -0,0 +1,14 @@
+-- display and input preferences of a user, a user without a row uses the
+-- defaults of the services (IDR, id-ID, Asia/Jakarta, weeks start on monday,
+-- new transactions are paid by transfer)
+CREATE TABLE "user_preferences" (
+  "user_id" int PRIMARY KEY,
+  "currency" varchar(3) NOT NULL, -- ISO 4217
+  "locale" varchar(10) NOT NULL, -- BCP 47, e.g. id-ID
+  "timezone" varchar(64) NOT NULL, -- IANA, e.g. Asia/Jakarta
+  "week_start" int NOT NULL, -- 0: sunday ... 6: saturday
+  "default_balance_type" int NOT NULL, -- 0: transfer, 1: cash
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_preferences" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
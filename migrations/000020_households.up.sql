@@ -0,0 +1,57 @@
+-- a household shares one set of pos, balances and transactions between its
+-- members, the owner invites the others by email
+CREATE TABLE "households" (
+  "id" SERIAL PRIMARY KEY,
+  "name" varchar(100) NOT NULL,
+  "owner_id" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "household_members" (
+  "household_id" int NOT NULL,
+  "user_id" int NOT NULL,
+  "role" varchar(10) NOT NULL, -- owner, member
+  "joined_at" timestamptz NOT NULL DEFAULT (now()),
+
+  PRIMARY KEY ("household_id", "user_id")
+);
+
+-- single use tokens mailed to the invited address, accepting them needs an
+-- account with that address verified
+CREATE TABLE "household_invitations" (
+  "id" SERIAL PRIMARY KEY,
+  "household_id" int NOT NULL,
+  "email" varchar(254) NOT NULL,
+  "token_hash" varchar(64) NOT NULL UNIQUE,
+  "invited_by" int NOT NULL,
+  "status" varchar(10) NOT NULL DEFAULT 'pending', -- pending, accepted, declined
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "households" ADD FOREIGN KEY ("owner_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "household_members" ADD FOREIGN KEY ("household_id") REFERENCES "households" ("id") ON DELETE CASCADE;
+ALTER TABLE "household_members" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "household_invitations" ADD FOREIGN KEY ("household_id") REFERENCES "households" ("id") ON DELETE CASCADE;
+ALTER TABLE "household_invitations" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "household_members" ("user_id");
+CREATE INDEX ON "household_invitations" ("household_id", "email");
+
+-- rows with a household belong to all of its members, user_id is the member
+-- who created them
+ALTER TABLE "pos" ADD "household_id" int DEFAULT NULL;
+ALTER TABLE "balance" ADD "household_id" int DEFAULT NULL;
+ALTER TABLE "transactions" ADD "household_id" int DEFAULT NULL;
+
+ALTER TABLE "pos" ADD FOREIGN KEY ("household_id") REFERENCES "households" ("id") ON DELETE CASCADE;
+ALTER TABLE "balance" ADD FOREIGN KEY ("household_id") REFERENCES "households" ("id") ON DELETE CASCADE;
+ALTER TABLE "transactions" ADD FOREIGN KEY ("household_id") REFERENCES "households" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "pos" ("household_id");
+CREATE INDEX ON "transactions" ("household_id", "pos_id");
+
+-- personal balances stay unique per user and type, shared ones per household
+ALTER TABLE "balance" DROP CONSTRAINT "balance_user_id_type_key";
+CREATE UNIQUE INDEX "balance_user_id_type_key" ON "balance" ("user_id", "type") WHERE "household_id" IS NULL;
+CREATE UNIQUE INDEX "balance_household_id_type_key" ON "balance" ("household_id", "type") WHERE "household_id" IS NOT NULL;
@@ -0,0 +1,7 @@
+-- the role is embedded in the access tokens, changing it revokes the tokens
+-- of the user so the next login carries the new one
+ALTER TABLE "users" ADD "role" varchar(20) NOT NULL DEFAULT 'user';
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('user', 'admin', 'read-only'));
+
+-- a disabled user can't log in anymore, their data is kept
+ALTER TABLE "users" ADD "disabled_at" timestamptz DEFAULT NULL;
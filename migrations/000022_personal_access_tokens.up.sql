@@ -0,0 +1,19 @@
+-- long lived tokens for scripts and integrations, only the hash of a token is
+-- stored and prefix is its start so the user can tell their tokens apart.
+-- scopes are space separated, see the users service for the known ones
+CREATE TABLE "personal_access_tokens" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "token_hash" varchar(64) NOT NULL UNIQUE,
+  "prefix" varchar(16) NOT NULL,
+  "scopes" varchar(255) NOT NULL,
+  "expires_at" timestamptz DEFAULT NULL,
+  "last_used_at" timestamptz DEFAULT NULL,
+  "revoked_at" timestamptz DEFAULT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "personal_access_tokens" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "personal_access_tokens" ("user_id");
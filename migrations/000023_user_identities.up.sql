@@ -0,0 +1,16 @@
+-- accounts at OpenID Connect providers linked to a user, a provider identifies
+-- its accounts by issuer and subject, email is the address it reported
+CREATE TABLE "user_identities" (
+  "id" SERIAL PRIMARY KEY,
+  "user_id" int NOT NULL,
+  "issuer" varchar(255) NOT NULL,
+  "subject" varchar(255) NOT NULL,
+  "email" varchar(254) NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_login_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("issuer", "subject")
+);
+
+ALTER TABLE "user_identities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "user_identities" ("user_id");
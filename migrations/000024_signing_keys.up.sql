@@ -0,0 +1,12 @@
+-- keys the access tokens are signed with. A key is published as soon as it
+-- is created, signs from activated_at until a newer key activates and stays
+-- published until expires_at, when the tokens it signed expired
+CREATE TABLE "signing_keys" (
+  "id" SERIAL PRIMARY KEY,
+  "kid" varchar(64) NOT NULL UNIQUE,
+  "algorithm" varchar(10) NOT NULL CHECK ("algorithm" IN ('RS256', 'EdDSA')),
+  "private_key" text NOT NULL,
+  "activated_at" timestamptz NOT NULL,
+  "expires_at" timestamptz DEFAULT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
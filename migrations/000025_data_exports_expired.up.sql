@@ -0,0 +1,3 @@
+-- exports older than the download window get their file removed and are
+-- marked expired, the row stays so the user can see the export happened
+COMMENT ON COLUMN "data_exports"."status" IS 'pending, done, failed, expired (file removed)';
@@ -0,0 +1,6 @@
+-- private keys are now encrypted with the users service's JWT_SIGNING_KEY_SECRET.
+-- The stored plaintext keys can't be read anymore, the users service creates a
+-- new key at startup, so everyone has to log in again
+DELETE FROM "signing_keys";
+
+COMMENT ON COLUMN "signing_keys"."private_key" IS 'PKCS #8, encrypted with the users service''s JWT_SIGNING_KEY_SECRET';
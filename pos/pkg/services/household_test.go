@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/pos/pkg/config"
+	"github.com/maslow123/pos/pkg/pb"
+	"github.com/maslow123/pos/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// createHousehold stores a household of the seeded users, households are
+// managed by the users service. The transactions and balance tests keep copies
+// of it.
+func createHousehold(t *testing.T, ownerId int32, memberIds ...int32) int32 {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var householdId int32
+	q := `INSERT INTO households (name, owner_id) VALUES ($1, $2) RETURNING id`
+	require.NoError(t, db.QueryRow(q, utils.RandomString(10), ownerId).Scan(&householdId))
+
+	q = `INSERT INTO household_members (household_id, user_id, role) VALUES ($1, $2, $3)`
+	_, err = db.Exec(q, householdId, ownerId, "owner")
+	require.NoError(t, err)
+	for _, memberId := range memberIds {
+		_, err = db.Exec(q, householdId, memberId, "member")
+		require.NoError(t, err)
+	}
+
+	return householdId
+}
+
+func TestHouseholdPos(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewPosServiceClient(conn)
+	householdId := createHousehold(t, 1, 2)
+	otherHouseholdId := createHousehold(t, 1)
+
+	create, err := client.CreatePos(ctx, &pb.CreatePosRequest{
+		UserId:      1,
+		HouseholdId: householdId,
+		Name:        utils.RandomString(10),
+		Type:        0,
+		Color:       "#FF00FF",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), create.Status)
+
+	testCases := []struct {
+		name        string
+		userId      int32
+		householdId int32
+		resp        *pb.PosDetailResponse
+	}{
+		{
+			"OK Owner",
+			1,
+			householdId,
+			&pb.PosDetailResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"OK Member",
+			2,
+			householdId,
+			&pb.PosDetailResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Not in personal pos",
+			1,
+			0,
+			&pb.PosDetailResponse{
+				Status: http.StatusNotFound,
+				Error:  "pos-not-found",
+			},
+		},
+		{
+			"Other household",
+			1,
+			otherHouseholdId,
+			&pb.PosDetailResponse{
+				Status: http.StatusNotFound,
+				Error:  "pos-not-found",
+			},
+		},
+		{
+			"Not a member",
+			2,
+			otherHouseholdId,
+			&pb.PosDetailResponse{
+				Status: http.StatusForbidden,
+				Error:  "not-a-household-member",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.PosDetail(ctx, &pb.PosDetailRequest{
+				Id:          create.Id,
+				UserId:      tc.userId,
+				HouseholdId: tc.householdId,
+			})
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusOK {
+				require.Equal(t, tc.householdId, response.Pos.HouseholdId)
+			}
+		})
+	}
+
+	// every member edits the same pos
+	update, err := client.UpdatePosByUser(ctx, &pb.UpdatePosRequest{
+		Id:          create.Id,
+		UserId:      2,
+		HouseholdId: householdId,
+		Name:        "Belanja",
+		Color:       "#00FF00",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), update.Status)
+
+	list, err := client.GetPosByUser(ctx, &pb.GetPosListRequest{
+		UserId:      1,
+		HouseholdId: householdId,
+		Type:        2,
+		Page:        1,
+		Limit:       10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), list.Status)
+	require.Len(t, list.Pos, 1)
+	require.Equal(t, "Belanja", list.Pos[0].Name)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/maslow123/pos/pkg/pb"
+)
+
+const dateLayout = "2006-01-02"
+
+// parsePeriod validates an inclusive YYYY-MM-DD range. Both dates empty means
+// no period was requested and ok is false without an error.
+func parsePeriod(startDate, endDate string) (start, end time.Time, ok bool, errMessage string) {
+	if startDate == "" && endDate == "" {
+		return
+	}
+
+	start, err := time.Parse(dateLayout, startDate)
+	if err != nil {
+		errMessage = "invalid-start-date"
+		return
+	}
+
+	end, err = time.Parse(dateLayout, endDate)
+	if err != nil {
+		errMessage = "invalid-end-date"
+		return
+	}
+
+	if end.Before(start) {
+		errMessage = "invalid-date-range"
+		return
+	}
+
+	ok = true
+	return
+}
+
+// previousPeriod returns the range of the same number of days ending the day
+// before start.
+func previousPeriod(start, end time.Time) (time.Time, time.Time) {
+	days := int(end.Sub(start).Hours()/24) + 1
+	previousEnd := start.AddDate(0, 0, -1)
+
+	return previousEnd.AddDate(0, 0, -(days - 1)), previousEnd
+}
+
+// periodTotals sums the transactions of every pos of a user (or household) for
+// the period and the previous one, keyed by pos id. Action 0 is income, 1 is expense.
+func (s *Server) periodTotals(ctx context.Context, userId, householdId int32, start, end time.Time) (map[int32]*pb.PosPeriod, error) {
+	previousStart, previousEnd := previousPeriod(start, end)
+
+	q := fmt.Sprintf(`
+		SELECT
+			pos_id,
+			COALESCE(SUM(total) FILTER (WHERE action = 0 AND created_at::date >= $2), 0),
+			COALESCE(SUM(total) FILTER (WHERE action = 1 AND created_at::date >= $2), 0),
+			COALESCE(SUM(total) FILTER (WHERE action = 0 AND created_at::date <= $4), 0),
+			COALESCE(SUM(total) FILTER (WHERE action = 1 AND created_at::date <= $4), 0)
+		FROM transactions
+		WHERE %s AND created_at::date BETWEEN $5 AND $3
+		GROUP BY pos_id
+	`, ownedBy("", 1, 6))
+
+	rows, err := s.DB.QueryContext(ctx, q,
+		userId,
+		start.Format(dateLayout),
+		end.Format(dateLayout),
+		previousEnd.Format(dateLayout),
+		previousStart.Format(dateLayout),
+		householdId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[int32]*pb.PosPeriod)
+	for rows.Next() {
+		var posId int32
+		var period pb.PosPeriod
+
+		if err := rows.Scan(
+			&posId,
+			&period.Income,
+			&period.Expense,
+			&period.PreviousIncome,
+			&period.PreviousExpense,
+		); err != nil {
+			return nil, err
+		}
+		totals[posId] = &period
+	}
+
+	return totals, rows.Err()
+}
+
+// setPeriods attaches the period totals to every pos of the flat list, pos
+// without transactions in either period get zero totals.
+func setPeriods(list []*pb.Pos, totals map[int32]*pb.PosPeriod) {
+	for _, p := range list {
+		p.Period = &pb.PosPeriod{}
+		if period, ok := totals[p.Id]; ok {
+			p.Period = period
+		}
+	}
+}
+
+// setPeriodChange compares a period with the previous one. A change from
+// nothing has no percentage, it is left at 0 when the previous total is 0.
+func setPeriodChange(period *pb.PosPeriod) {
+	period.IncomeChange = period.Income - period.PreviousIncome
+	period.ExpenseChange = period.Expense - period.PreviousExpense
+	period.IncomeChangePercent = changePercent(period.Income, period.PreviousIncome)
+	period.ExpenseChangePercent = changePercent(period.Expense, period.PreviousExpense)
+}
+
+// changePercent is rounded to two decimals.
+func changePercent(current, previous int32) float64 {
+	if previous == 0 {
+		return 0
+	}
+
+	return math.Round(float64(current-previous)/float64(previous)*10000) / 100
+}
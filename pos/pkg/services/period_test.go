@@ -0,0 +1,112 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maslow123/pos/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeriod(t *testing.T) {
+	testCases := []struct {
+		name       string
+		startDate  string
+		endDate    string
+		ok         bool
+		errMessage string
+	}{
+		{"OK", "2022-05-01", "2022-05-31", true, ""},
+		{"No Period", "", "", false, ""},
+		{"Invalid Start Date", "2022-13-01", "2022-05-31", false, "invalid-start-date"},
+		{"Missing End Date", "2022-05-01", "", false, "invalid-end-date"},
+		{"End Before Start", "2022-05-31", "2022-05-01", false, "invalid-date-range"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, ok, errMessage := parsePeriod(tc.startDate, tc.endDate)
+
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.errMessage, errMessage)
+		})
+	}
+}
+
+func TestPreviousPeriod(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name          string
+		start         time.Time
+		end           time.Time
+		previousStart time.Time
+		previousEnd   time.Time
+	}{
+		{
+			"Single Day",
+			date(2022, time.May, 12), date(2022, time.May, 12),
+			date(2022, time.May, 11), date(2022, time.May, 11),
+		},
+		{
+			"Whole Month",
+			date(2022, time.March, 1), date(2022, time.March, 31),
+			date(2022, time.January, 29), date(2022, time.February, 28),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			previousStart, previousEnd := previousPeriod(tc.start, tc.end)
+
+			require.Equal(t, tc.previousStart, previousStart)
+			require.Equal(t, tc.previousEnd, previousEnd)
+		})
+	}
+}
+
+func TestSetPeriodChange(t *testing.T) {
+	testCases := []struct {
+		name           string
+		period         *pb.PosPeriod
+		incomeChange   int32
+		incomePercent  float64
+		expenseChange  int32
+		expensePercent float64
+	}{
+		{
+			"Increase And Decrease",
+			&pb.PosPeriod{Income: 1500, PreviousIncome: 1000, Expense: 300, PreviousExpense: 900},
+			500, 50, -600, -66.67,
+		},
+		{
+			"No Previous Period",
+			&pb.PosPeriod{Income: 1500, Expense: 300},
+			1500, 0, 300, 0,
+		},
+		{
+			"Nothing Booked",
+			&pb.PosPeriod{},
+			0, 0, 0, 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			setPeriodChange(tc.period)
+
+			require.Equal(t, tc.incomeChange, tc.period.IncomeChange)
+			require.Equal(t, tc.incomePercent, tc.period.IncomeChangePercent)
+			require.Equal(t, tc.expenseChange, tc.period.ExpenseChange)
+			require.Equal(t, tc.expensePercent, tc.period.ExpenseChangePercent)
+		})
+	}
+}
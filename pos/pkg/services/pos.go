@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/maslow123/pos/pkg/pb"
 )
 
+// iconPattern matches icon identifiers understood by the apps, e.g. "shopping-cart".
+var iconPattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
 func (s *Server) CreatePos(ctx context.Context, req *pb.CreatePosRequest) (*pb.CreatePosResponse, error) {
 	if req.UserId == 0 {
 		return genericCreatePosResponse(http.StatusBadRequest, "invalid-user")
@@ -24,18 +28,35 @@ func (s *Server) CreatePos(ctx context.Context, req *pb.CreatePosRequest) (*pb.C
 	if req.Color == "" {
 		return genericCreatePosResponse(http.StatusBadRequest, "invalid-color")
 	}
+	if req.Icon != "" && !iconPattern.MatchString(req.Icon) {
+		return genericCreatePosResponse(http.StatusBadRequest, "invalid-icon")
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericCreatePosResponse(status, errMessage)
+	}
+	if req.ParentId != 0 {
+		if status, errMessage := s.checkParent(ctx, s.DB, req.UserId, req.HouseholdId, req.ParentId, req.Type); status != http.StatusOK {
+			return genericCreatePosResponse(status, errMessage)
+		}
+	}
 
-	q := `
-		INSERT INTO pos (user_id, name, type, color)
-		VALUES ($1, $2, $3, $4)
+	q := fmt.Sprintf(`
+		INSERT INTO pos (user_id, household_id, name, type, color, parent_id, icon, sort_order)
+		VALUES (
+			$1, NULLIF($7, 0), $2, $3, $4, NULLIF($5, 0), NULLIF($6, ''),
+			(SELECT COALESCE(MAX(sort_order), 0) + 1 FROM pos WHERE %s)
+		)
 		RETURNING id
-	`
+	`, ownedBy("", 1, 7))
 
 	row := s.DB.QueryRowContext(ctx, q,
 		&req.UserId,
 		&req.Name,
 		&req.Type,
 		&req.Color,
+		&req.ParentId,
+		&req.Icon,
+		&req.HouseholdId,
 	)
 
 	var lastInsertedId int32
@@ -59,30 +80,41 @@ func (s *Server) GetPosByUser(ctx context.Context, req *pb.GetPosListRequest) (*
 	if req.UserId == 0 {
 		return genericListPosByUserResponse(http.StatusBadRequest, "invalid-user-id")
 	}
-	if req.Limit == 0 {
+	if req.Limit <= 0 {
 		return genericListPosByUserResponse(http.StatusBadRequest, "invalid-limit")
 	}
-	if req.Page == 0 {
+	if req.Page <= 0 {
 		return genericListPosByUserResponse(http.StatusBadRequest, "invalid-page")
 	}
 	if req.Type != 0 && req.Type != 1 && req.Type != 2 {
 		return genericListPosByUserResponse(http.StatusBadRequest, "invalid-type")
 	}
+	start, end, withPeriod, errMessage := parsePeriod(req.StartDate, req.EndDate)
+	if errMessage != "" {
+		return genericListPosByUserResponse(http.StatusBadRequest, errMessage)
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericListPosByUserResponse(status, errMessage)
+	}
 
-	q := `
-		SELECT id, name, type, total, color
+	// Pagination applies to the top level pos, sub-pos are nested below their parent.
+	q := fmt.Sprintf(`
+		SELECT id, name, type, total, color, COALESCE(parent_id, 0), archived, sort_order, COALESCE(icon, '')
 		FROM pos
-		WHERE user_id = $1 		
-	`
+		WHERE %s
+	`, ownedBy("", 1, 2))
 
 	if req.Type != 2 {
 		q = fmt.Sprintf("%s AND type = %d", q, req.Type)
 	}
 
-	q = fmt.Sprintf("%s LIMIT $2 OFFSET $3", q)
-	offset := (req.Page - 1) * req.Limit
+	if !req.IncludeArchived {
+		q = fmt.Sprintf("%s AND NOT archived", q)
+	}
+
+	q = fmt.Sprintf("%s ORDER BY sort_order, id", q)
 
-	rows, err := s.DB.QueryContext(ctx, q, req.UserId, req.Limit, offset)
+	rows, err := s.DB.QueryContext(ctx, q, req.UserId, req.HouseholdId)
 	if err != nil {
 		log.Println(err)
 		if err == sql.ErrNoRows {
@@ -92,7 +124,7 @@ func (s *Server) GetPosByUser(ctx context.Context, req *pb.GetPosListRequest) (*
 	}
 	defer rows.Close()
 
-	var pos []*pb.Pos
+	var list []*pb.Pos
 
 	for rows.Next() {
 		var p pb.Pos
@@ -102,12 +134,17 @@ func (s *Server) GetPosByUser(ctx context.Context, req *pb.GetPosListRequest) (*
 			&p.Type,
 			&p.Total,
 			&p.Color,
+			&p.ParentId,
+			&p.Archived,
+			&p.SortOrder,
+			&p.Icon,
 		); err != nil {
 			log.Println(err)
 			return genericListPosByUserResponse(http.StatusInternalServerError, err.Error())
 		}
 
-		pos = append(pos, &p)
+		p.HouseholdId = req.HouseholdId
+		list = append(list, &p)
 	}
 
 	if err := rows.Close(); err != nil {
@@ -118,10 +155,26 @@ func (s *Server) GetPosByUser(ctx context.Context, req *pb.GetPosListRequest) (*
 		return genericListPosByUserResponse(http.StatusInternalServerError, err.Error())
 	}
 
-	if len(pos) == 0 {
+	if withPeriod {
+		totals, err := s.periodTotals(ctx, req.UserId, req.HouseholdId, start, end)
+		if err != nil {
+			log.Println(err)
+			return genericListPosByUserResponse(http.StatusInternalServerError, err.Error())
+		}
+		setPeriods(list, totals)
+	}
+
+	roots := buildPosTree(list)
+	offset := int((req.Page - 1) * req.Limit)
+	if offset >= len(roots) {
 		return genericListPosByUserResponse(http.StatusNotFound, "pos-not-found")
 	}
 
+	pos := roots[offset:]
+	if len(pos) > int(req.Limit) {
+		pos = pos[:req.Limit]
+	}
+
 	resp := &pb.GetPosListResponse{
 		Status: http.StatusOK,
 		Error:  "",
@@ -137,39 +190,101 @@ func (s *Server) PosDetail(ctx context.Context, req *pb.PosDetailRequest) (*pb.P
 	if req.Id == 0 {
 		return genericPosDetailResponse(http.StatusBadRequest, "invalid-id")
 	}
-	q := `
-		SELECT id, name, type, total, color, created_at, updated_at
-		FROM pos
-		WHERE id = $1
-	`
-	var pos pb.Pos
-	var createdAt, updatedAt time.Time
-
-	row := s.DB.QueryRowContext(ctx, q, req.Id)
-	err := row.Scan(
-		&pos.Id,
-		&pos.Name,
-		&pos.Type,
-		&pos.Total,
-		&pos.Color,
-		&createdAt,
-		&updatedAt,
-	)
-
+	if req.UserId == 0 {
+		return genericPosDetailResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	start, end, withPeriod, errMessage := parsePeriod(req.StartDate, req.EndDate)
+	if errMessage != "" {
+		return genericPosDetailResponse(http.StatusBadRequest, errMessage)
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericPosDetailResponse(status, errMessage)
+	}
+	// The requested pos comes first, followed by all of its descendants. The
+	// path stops the walk at a pos it already passed should parents ever loop.
+	q := fmt.Sprintf(`
+		WITH RECURSIVE tree AS (
+			SELECT
+				id, parent_id, name, type, total, color, created_at, updated_at, archived, sort_order, icon,
+				0 AS depth, ARRAY[id] AS path
+			FROM pos
+			WHERE id = $1 AND %s
+			UNION ALL
+			SELECT
+				p.id, p.parent_id, p.name, p.type, p.total, p.color, p.created_at, p.updated_at,
+				p.archived, p.sort_order, p.icon, t.depth + 1, t.path || p.id
+			FROM pos p
+			JOIN tree t ON p.parent_id = t.id
+			WHERE p.id <> ALL(t.path)
+		)
+		SELECT
+			id, COALESCE(parent_id, 0), name, type, total, color, created_at, updated_at,
+			archived, sort_order, COALESCE(icon, '')
+		FROM tree
+		ORDER BY depth, sort_order, id
+	`, ownedBy("", 2, 3))
+
+	rows, err := s.DB.QueryContext(ctx, q, req.Id, req.UserId, req.HouseholdId)
 	if err != nil {
 		log.Println(err)
-		if err == sql.ErrNoRows {
-			return genericPosDetailResponse(http.StatusNotFound, "pos-not-found")
+		return genericPosDetailResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer rows.Close()
+
+	var list []*pb.Pos
+
+	for rows.Next() {
+		var p pb.Pos
+		var createdAt, updatedAt time.Time
+		if err := rows.Scan(
+			&p.Id,
+			&p.ParentId,
+			&p.Name,
+			&p.Type,
+			&p.Total,
+			&p.Color,
+			&createdAt,
+			&updatedAt,
+			&p.Archived,
+			&p.SortOrder,
+			&p.Icon,
+		); err != nil {
+			log.Println(err)
+			return genericPosDetailResponse(http.StatusInternalServerError, err.Error())
 		}
+
+		p.CreatedAt = int32(createdAt.Unix())
+		p.UpdatedAt = int32(updatedAt.Unix())
+		p.HouseholdId = req.HouseholdId
+		list = append(list, &p)
+	}
+
+	if err := rows.Close(); err != nil {
+		return genericPosDetailResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := rows.Err(); err != nil {
 		return genericPosDetailResponse(http.StatusInternalServerError, err.Error())
 	}
 
-	pos.CreatedAt = int32(createdAt.Unix())
-	pos.UpdatedAt = int32(updatedAt.Unix())
+	if len(list) == 0 {
+		return genericPosDetailResponse(http.StatusNotFound, "pos-not-found")
+	}
+
+	if withPeriod {
+		totals, err := s.periodTotals(ctx, req.UserId, req.HouseholdId, start, end)
+		if err != nil {
+			log.Println(err)
+			return genericPosDetailResponse(http.StatusInternalServerError, err.Error())
+		}
+		setPeriods(list, totals)
+	}
+
+	buildPosTree(list)
 
 	resp := &pb.PosDetailResponse{
 		Status: http.StatusOK,
-		Pos:    &pos,
+		Pos:    list[0],
 		Error:  "",
 	}
 	return resp, nil
@@ -179,24 +294,38 @@ func (s *Server) UpdatePosByUser(ctx context.Context, req *pb.UpdatePosRequest)
 	if req.Id == 0 {
 		return genericUpdatePosByUserResponse(http.StatusBadRequest, "invalid-id")
 	}
+	if req.UserId == 0 {
+		return genericUpdatePosByUserResponse(http.StatusBadRequest, "invalid-user-id")
+	}
 	if req.Name == "" {
 		return genericUpdatePosByUserResponse(http.StatusBadRequest, "invalid-name")
 	}
 	if req.Color == "" {
 		return genericUpdatePosByUserResponse(http.StatusBadRequest, "invalid-color")
 	}
+	if req.Icon != "" && !iconPattern.MatchString(req.Icon) {
+		return genericUpdatePosByUserResponse(http.StatusBadRequest, "invalid-icon")
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericUpdatePosByUserResponse(status, errMessage)
+	}
 
-	q := `
+	q := fmt.Sprintf(`
 		UPDATE pos
-		SET name = $2, color = $3, updated_at = now()
-		WHERE id = $1
-		RETURNING id, name, type, total, color, created_at, updated_at	
-	`
+		SET name = $2, color = $3, icon = COALESCE(NULLIF($5, ''), icon), updated_at = now()
+		WHERE id = $1 AND %s
+		RETURNING
+			id, name, type, total, color, created_at, updated_at,
+			COALESCE(parent_id, 0), archived, sort_order, COALESCE(icon, '')
+	`, ownedBy("", 4, 6))
 
 	row := s.DB.QueryRowContext(ctx, q,
 		&req.Id,
 		&req.Name,
 		&req.Color,
+		&req.UserId,
+		&req.Icon,
+		&req.HouseholdId,
 	)
 	var p pb.Pos
 	var createdAt, updatedAt time.Time
@@ -208,6 +337,10 @@ func (s *Server) UpdatePosByUser(ctx context.Context, req *pb.UpdatePosRequest)
 		&p.Color,
 		&createdAt,
 		&updatedAt,
+		&p.ParentId,
+		&p.Archived,
+		&p.SortOrder,
+		&p.Icon,
 	)
 
 	if err != nil {
@@ -220,6 +353,7 @@ func (s *Server) UpdatePosByUser(ctx context.Context, req *pb.UpdatePosRequest)
 
 	p.CreatedAt = int32(createdAt.Unix())
 	p.UpdatedAt = int32(updatedAt.Unix())
+	p.HouseholdId = req.HouseholdId
 
 	resp := &pb.UpdatePosResponse{
 		Status: http.StatusOK,
@@ -234,10 +368,28 @@ func (s *Server) DeletePosByUser(ctx context.Context, req *pb.DeletePosRequest)
 	if req.Id == 0 {
 		return genericDeletePosByUserResponse(http.StatusBadRequest, "invalid-id")
 	}
+	if req.UserId == 0 {
+		return genericDeletePosByUserResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericDeletePosByUserResponse(status, errMessage)
+	}
+
+	q := fmt.Sprintf(`SELECT COUNT(1) FROM pos WHERE parent_id = $1 AND %s`, ownedBy("", 2, 3))
+
+	var children int
+	row := s.DB.QueryRowContext(ctx, q, req.Id, req.UserId, req.HouseholdId)
+	if err := row.Scan(&children); err != nil {
+		log.Println(err)
+		return genericDeletePosByUserResponse(http.StatusInternalServerError, err.Error())
+	}
+	if children > 0 {
+		return genericDeletePosByUserResponse(http.StatusConflict, "pos-has-children")
+	}
 
-	q := `DELETE FROM pos WHERE id = $1`
+	q = fmt.Sprintf(`DELETE FROM pos WHERE id = $1 AND %s`, ownedBy("", 2, 3))
 
-	res, err := s.DB.ExecContext(ctx, q, req.Id)
+	res, err := s.DB.ExecContext(ctx, q, req.Id, req.UserId, req.HouseholdId)
 	if err != nil {
 		log.Println(err)
 		return genericDeletePosByUserResponse(http.StatusInternalServerError, err.Error())
@@ -250,10 +402,357 @@ func (s *Server) DeletePosByUser(ctx context.Context, req *pb.DeletePosRequest)
 	return genericDeletePosByUserResponse(http.StatusOK, "")
 }
 
+func (s *Server) MovePos(ctx context.Context, req *pb.MovePosRequest) (*pb.MovePosResponse, error) {
+	if req.Id == 0 {
+		return genericMovePosResponse(http.StatusBadRequest, "invalid-id")
+	}
+	if req.UserId == 0 {
+		return genericMovePosResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.ParentId == req.Id {
+		return genericMovePosResponse(http.StatusBadRequest, "invalid-parent-id")
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericMovePosResponse(status, errMessage)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericMovePosResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	// Two moves that are each fine on their own can make a cycle together,
+	// so the moves within one user's (or household's) pos run one at a time.
+	q := fmt.Sprintf(`SELECT id FROM pos WHERE %s ORDER BY id FOR UPDATE`, ownedBy("", 1, 2))
+	if _, err = tx.ExecContext(ctx, q, req.UserId, req.HouseholdId); err != nil {
+		log.Println(err)
+		return genericMovePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = fmt.Sprintf(`SELECT type FROM pos WHERE id = $1 AND %s`, ownedBy("", 2, 3))
+
+	var posType int32
+	row := tx.QueryRowContext(ctx, q, req.Id, req.UserId, req.HouseholdId)
+	err = row.Scan(&posType)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericMovePosResponse(http.StatusNotFound, "pos-not-found")
+		}
+		return genericMovePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if req.ParentId != 0 {
+		if status, errMessage := s.checkParent(ctx, tx, req.UserId, req.HouseholdId, req.ParentId, posType); status != http.StatusOK {
+			return genericMovePosResponse(status, errMessage)
+		}
+
+		// A pos cannot be moved below one of its own descendants.
+		q = `
+			WITH RECURSIVE descendants AS (
+				SELECT id FROM pos WHERE parent_id = $1
+				UNION
+				SELECT p.id FROM pos p JOIN descendants d ON p.parent_id = d.id
+			)
+			SELECT COUNT(1) FROM descendants WHERE id = $2
+		`
+
+		var cycle int
+		row = tx.QueryRowContext(ctx, q, req.Id, req.ParentId)
+		if err = row.Scan(&cycle); err != nil {
+			log.Println(err)
+			return genericMovePosResponse(http.StatusInternalServerError, err.Error())
+		}
+		if cycle > 0 {
+			return genericMovePosResponse(http.StatusBadRequest, "pos-cycle-detected")
+		}
+	}
+
+	q = fmt.Sprintf(`
+		UPDATE pos
+		SET parent_id = NULLIF($3, 0), updated_at = now()
+		WHERE id = $1 AND %s
+	`, ownedBy("", 2, 4))
+
+	_, err = tx.ExecContext(ctx, q, req.Id, req.UserId, req.ParentId, req.HouseholdId)
+	if err != nil {
+		log.Println(err)
+		return genericMovePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericMovePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericMovePosResponse(http.StatusOK, "")
+}
+
+// MergePos moves every transaction and sub-pos of a pos into another pos of
+// the same user and type, recomputes both totals and then archives or deletes
+// the emptied source, all within one database transaction.
+func (s *Server) MergePos(ctx context.Context, req *pb.MergePosRequest) (*pb.MergePosResponse, error) {
+	if req.Id == 0 {
+		return genericMergePosResponse(http.StatusBadRequest, "invalid-id")
+	}
+	if req.UserId == 0 {
+		return genericMergePosResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.TargetId == 0 || req.TargetId == req.Id {
+		return genericMergePosResponse(http.StatusBadRequest, "invalid-target-id")
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericMergePosResponse(status, errMessage)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := fmt.Sprintf(`SELECT type FROM pos WHERE id = $1 AND %s FOR UPDATE`, ownedBy("", 2, 3))
+
+	var sourceType, targetType int32
+	row := tx.QueryRowContext(ctx, q, req.Id, req.UserId, req.HouseholdId)
+	err = row.Scan(&sourceType)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericMergePosResponse(http.StatusNotFound, "pos-not-found")
+		}
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = fmt.Sprintf(`SELECT type, archived FROM pos WHERE id = $1 AND %s FOR UPDATE`, ownedBy("", 2, 3))
+
+	var targetArchived bool
+	row = tx.QueryRowContext(ctx, q, req.TargetId, req.UserId, req.HouseholdId)
+	err = row.Scan(&targetType, &targetArchived)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericMergePosResponse(http.StatusNotFound, "target-pos-not-found")
+		}
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if sourceType != targetType {
+		return genericMergePosResponse(http.StatusBadRequest, "invalid-target-type")
+	}
+	// the target becomes the parent of the sub-pos of the source, like in checkParent
+	if targetArchived {
+		return genericMergePosResponse(http.StatusBadRequest, "parent-pos-archived")
+	}
+
+	// The sub-pos of the source are moved to the target, which therefore cannot be one of them.
+	q = `
+		WITH RECURSIVE descendants AS (
+			SELECT id FROM pos WHERE parent_id = $1
+			UNION
+			SELECT p.id FROM pos p JOIN descendants d ON p.parent_id = d.id
+		)
+		SELECT COUNT(1) FROM descendants WHERE id = $2
+	`
+
+	var cycle int
+	row = tx.QueryRowContext(ctx, q, req.Id, req.TargetId)
+	if err = row.Scan(&cycle); err != nil {
+		log.Println(err)
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+	if cycle > 0 {
+		return genericMergePosResponse(http.StatusBadRequest, "pos-cycle-detected")
+	}
+
+	q = fmt.Sprintf(`UPDATE transactions SET pos_id = $2, updated_at = now() WHERE pos_id = $1 AND %s`, ownedBy("", 3, 4))
+	if _, err = tx.ExecContext(ctx, q, req.Id, req.TargetId, req.UserId, req.HouseholdId); err != nil {
+		log.Println(err)
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = fmt.Sprintf(`UPDATE pos SET parent_id = $2, updated_at = now() WHERE parent_id = $1 AND %s`, ownedBy("", 3, 4))
+	if _, err = tx.ExecContext(ctx, q, req.Id, req.TargetId, req.UserId, req.HouseholdId); err != nil {
+		log.Println(err)
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `
+		UPDATE pos p
+		SET total = (SELECT COALESCE(SUM(t.total), 0) FROM transactions t WHERE t.pos_id = p.id),
+			updated_at = now()
+		WHERE p.id IN ($1, $2)
+	`
+	if _, err = tx.ExecContext(ctx, q, req.Id, req.TargetId); err != nil {
+		log.Println(err)
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if req.ArchiveSource {
+		q = `UPDATE pos SET archived = true, updated_at = now() WHERE id = $1`
+	} else {
+		q = `DELETE FROM pos WHERE id = $1`
+	}
+	if _, err = tx.ExecContext(ctx, q, req.Id); err != nil {
+		log.Println(err)
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `
+		SELECT
+			id, name, type, total, color, created_at, updated_at,
+			COALESCE(parent_id, 0), archived, sort_order, COALESCE(icon, '')
+		FROM pos
+		WHERE id = $1
+	`
+
+	var p pb.Pos
+	var createdAt, updatedAt time.Time
+	row = tx.QueryRowContext(ctx, q, req.TargetId)
+	err = row.Scan(
+		&p.Id,
+		&p.Name,
+		&p.Type,
+		&p.Total,
+		&p.Color,
+		&createdAt,
+		&updatedAt,
+		&p.ParentId,
+		&p.Archived,
+		&p.SortOrder,
+		&p.Icon,
+	)
+	if err != nil {
+		log.Println(err)
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericMergePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	p.CreatedAt = int32(createdAt.Unix())
+	p.UpdatedAt = int32(updatedAt.Unix())
+	p.HouseholdId = req.HouseholdId
+
+	resp := &pb.MergePosResponse{
+		Status: http.StatusOK,
+		Error:  "",
+		Pos:    &p,
+	}
+
+	return resp, nil
+}
+
+// ArchivePos hides a pos and all of its sub-pos from GetPosByUser while keeping
+// their transactions, or restores them when archived is false.
+func (s *Server) ArchivePos(ctx context.Context, req *pb.ArchivePosRequest) (*pb.ArchivePosResponse, error) {
+	if req.Id == 0 {
+		return genericArchivePosResponse(http.StatusBadRequest, "invalid-id")
+	}
+	if req.UserId == 0 {
+		return genericArchivePosResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericArchivePosResponse(status, errMessage)
+	}
+
+	q := fmt.Sprintf(`
+		SELECT COALESCE(parent.archived, false)
+		FROM pos p
+		LEFT JOIN pos parent ON parent.id = p.parent_id
+		WHERE p.id = $1 AND %s
+	`, ownedBy("p.", 2, 3))
+
+	var parentArchived bool
+	row := s.DB.QueryRowContext(ctx, q, req.Id, req.UserId, req.HouseholdId)
+	err := row.Scan(&parentArchived)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericArchivePosResponse(http.StatusNotFound, "pos-not-found")
+		}
+		return genericArchivePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if !req.Archived && parentArchived {
+		return genericArchivePosResponse(http.StatusBadRequest, "parent-pos-archived")
+	}
+
+	q = fmt.Sprintf(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM pos WHERE id = $1
+			UNION
+			SELECT p.id FROM pos p JOIN tree t ON p.parent_id = t.id
+		)
+		UPDATE pos SET archived = $3, updated_at = now()
+		WHERE id IN (SELECT id FROM tree) AND %s
+	`, ownedBy("", 2, 4))
+
+	_, err = s.DB.ExecContext(ctx, q, req.Id, req.UserId, req.Archived, req.HouseholdId)
+	if err != nil {
+		log.Println(err)
+		return genericArchivePosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericArchivePosResponse(http.StatusOK, "")
+}
+
+// ReorderPos stores the display order of the given pos, the first id is shown first.
+func (s *Server) ReorderPos(ctx context.Context, req *pb.ReorderPosRequest) (*pb.ReorderPosResponse, error) {
+	if req.UserId == 0 {
+		return genericReorderPosResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if len(req.Ids) == 0 {
+		return genericReorderPosResponse(http.StatusBadRequest, "invalid-ids")
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericReorderPosResponse(status, errMessage)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericReorderPosResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := fmt.Sprintf(`UPDATE pos SET sort_order = $3, updated_at = now() WHERE id = $1 AND %s`, ownedBy("", 2, 4))
+
+	seen := make(map[int32]bool, len(req.Ids))
+	for i, id := range req.Ids {
+		if id == 0 || seen[id] {
+			return genericReorderPosResponse(http.StatusBadRequest, "invalid-ids")
+		}
+		seen[id] = true
+
+		res, err := tx.ExecContext(ctx, q, id, req.UserId, i+1, req.HouseholdId)
+		if err != nil {
+			log.Println(err)
+			return genericReorderPosResponse(http.StatusInternalServerError, err.Error())
+		}
+		count, err := res.RowsAffected()
+		if err == nil && count == 0 {
+			return genericReorderPosResponse(http.StatusNotFound, "pos-not-found")
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericReorderPosResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericReorderPosResponse(http.StatusOK, "")
+}
+
 func (s *Server) UpdateTotalPosByUser(ctx context.Context, req *pb.UpdateTotalPosRequest) (*pb.UpdateTotalPosResponse, error) {
 	if req.Id == 0 {
 		return genericUpdateTotalPosByUserResponse(http.StatusBadRequest, "invalid-id")
 	}
+	if req.UserId == 0 {
+		return genericUpdateTotalPosByUserResponse(http.StatusBadRequest, "invalid-user-id")
+	}
 
 	if req.Action != pb.UpdateTotalPosRequest_INCREASE && req.Action != pb.UpdateTotalPosRequest_DECREASE {
 		return genericUpdateTotalPosByUserResponse(http.StatusBadRequest, "invalid-action")
@@ -262,6 +761,9 @@ func (s *Server) UpdateTotalPosByUser(ctx context.Context, req *pb.UpdateTotalPo
 	if req.Amount == 0 {
 		return genericUpdateTotalPosByUserResponse(http.StatusBadRequest, "invalid-amount")
 	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericUpdateTotalPosByUserResponse(status, errMessage)
+	}
 
 	q := `
 		UPDATE pos 
@@ -274,9 +776,11 @@ func (s *Server) UpdateTotalPosByUser(ctx context.Context, req *pb.UpdateTotalPo
 		q = fmt.Sprintf("%s total - %d", q, req.Amount)
 	}
 
-	q = fmt.Sprintf("%s WHERE id = $1 RETURNING total", q)
+	q = fmt.Sprintf("%s WHERE id = $1 AND %s RETURNING total", q, ownedBy("", 2, 3))
 	row := s.DB.QueryRowContext(ctx, q,
 		&req.Id,
+		&req.UserId,
+		&req.HouseholdId,
 	)
 
 	var total int32
@@ -299,3 +803,34 @@ func (s *Server) UpdateTotalPosByUser(ctx context.Context, req *pb.UpdateTotalPo
 
 	return resp, nil
 }
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// checkParent verifies that parentId is a pos of the same user (or household)
+// and type that isn't archived, so it can hold a pos of posType as a child.
+func (s *Server) checkParent(ctx context.Context, db queryRower, userId, householdId, parentId, posType int32) (int, string) {
+	q := fmt.Sprintf(`SELECT type, archived FROM pos WHERE id = $1 AND %s`, ownedBy("", 2, 3))
+
+	var parentType int32
+	var archived bool
+	row := db.QueryRowContext(ctx, q, parentId, userId, householdId)
+	err := row.Scan(&parentType, &archived)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return http.StatusNotFound, "parent-pos-not-found"
+		}
+		return http.StatusInternalServerError, err.Error()
+	}
+
+	if parentType != posType {
+		return http.StatusBadRequest, "invalid-parent-type"
+	}
+	if archived {
+		return http.StatusBadRequest, "parent-pos-archived"
+	}
+
+	return http.StatusOK, ""
+}
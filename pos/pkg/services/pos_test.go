@@ -85,6 +85,62 @@ func TestCreatePos(t *testing.T) {
 				Error:  "invalid-color",
 			},
 		},
+		{
+			"OK With Icon",
+			&pb.CreatePosRequest{
+				Name:   utils.RandomString(10),
+				Type:   0,
+				Color:  "#FF00FF",
+				UserId: 1,
+				Icon:   "shopping-cart",
+			},
+			&pb.CreatePosResponse{
+				Status: int32(http.StatusCreated),
+				Error:  "",
+			},
+		},
+		{
+			"Invalid Pos Icon",
+			&pb.CreatePosRequest{
+				Name:   utils.RandomString(10),
+				Type:   0,
+				Color:  "#FF00FF",
+				UserId: 1,
+				Icon:   "Shopping Cart!",
+			},
+			&pb.CreatePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-icon",
+			},
+		},
+		{
+			"Parent Not Found",
+			&pb.CreatePosRequest{
+				Name:     utils.RandomString(10),
+				Type:     0,
+				Color:    "#FF00FF",
+				UserId:   1,
+				ParentId: 99999,
+			},
+			&pb.CreatePosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "parent-pos-not-found",
+			},
+		},
+		{
+			"Invalid Parent Type",
+			&pb.CreatePosRequest{
+				Name:     utils.RandomString(10),
+				Type:     1,
+				Color:    "#FF00FF",
+				UserId:   1,
+				ParentId: 1,
+			},
+			&pb.CreatePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-parent-type",
+			},
+		},
 	}
 
 	ctx := context.Background()
@@ -179,6 +235,36 @@ func TestPosList(t *testing.T) {
 				Error:  "invalid-page",
 			},
 		},
+		{
+			"OK With Period",
+			&pb.GetPosListRequest{
+				UserId:    1,
+				Type:      2,
+				Limit:     10,
+				Page:      1,
+				StartDate: "2022-05-01",
+				EndDate:   "2022-05-31",
+			},
+			&pb.GetPosListResponse{
+				Status: int32(http.StatusOK),
+				Error:  "",
+			},
+		},
+		{
+			"Invalid Date Range",
+			&pb.GetPosListRequest{
+				UserId:    1,
+				Type:      2,
+				Limit:     10,
+				Page:      1,
+				StartDate: "2022-05-31",
+				EndDate:   "2022-05-01",
+			},
+			&pb.GetPosListResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-date-range",
+			},
+		},
 		{
 			"Pos Not found",
 			&pb.GetPosListRequest{
@@ -214,6 +300,9 @@ func TestPosList(t *testing.T) {
 
 			if response.Status == int32(http.StatusOK) {
 				require.NotEmpty(t, response.Pos)
+				for _, p := range response.Pos {
+					require.Equal(t, tc.req.StartDate != "", p.Period != nil)
+				}
 			}
 		})
 	}
@@ -223,6 +312,7 @@ func TestPosDetail(t *testing.T) {
 	testCases := []struct {
 		name     string
 		getPosID func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32
+		userId   int32
 		resp     *pb.PosDetailResponse
 	}{
 		{
@@ -239,6 +329,7 @@ func TestPosDetail(t *testing.T) {
 
 				return pos.Id
 			},
+			1,
 			&pb.PosDetailResponse{
 				Status: int32(http.StatusOK),
 				Error:  "",
@@ -249,6 +340,7 @@ func TestPosDetail(t *testing.T) {
 			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
 				return 0
 			},
+			1,
 			&pb.PosDetailResponse{
 				Status: int32(http.StatusBadRequest),
 				Error:  "invalid-id",
@@ -259,6 +351,38 @@ func TestPosDetail(t *testing.T) {
 			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
 				return 9999
 			},
+			1,
+			&pb.PosDetailResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "pos-not-found",
+			},
+		},
+		{
+			"Invalid User ID",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
+				return 1
+			},
+			0,
+			&pb.PosDetailResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Other User's Pos",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
+				arg := &pb.CreatePosRequest{
+					UserId: 1,
+					Name:   utils.RandomString(10),
+					Type:   0,
+					Color:  fmt.Sprintf("#%s", utils.RandomString(6)),
+				}
+				pos, err := client.CreatePos(ctx, arg)
+				require.NoError(t, err)
+
+				return pos.Id
+			},
+			2,
 			&pb.PosDetailResponse{
 				Status: int32(http.StatusNotFound),
 				Error:  "pos-not-found",
@@ -278,7 +402,8 @@ func TestPosDetail(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			posId := tc.getPosID(t, ctx, client)
 			req := &pb.PosDetailRequest{
-				Id: posId,
+				Id:     posId,
+				UserId: tc.userId,
 			}
 			response, err := client.PosDetail(ctx, req)
 			require.NoError(t, err)
@@ -314,9 +439,10 @@ func TestUpdatePos(t *testing.T) {
 				return pos.Id
 			},
 			&pb.UpdatePosRequest{
-				Id:    2,
-				Name:  utils.RandomString(10),
-				Color: fmt.Sprintf("#%s", utils.RandomString(6)),
+				UserId: 1,
+				Id:     2,
+				Name:   utils.RandomString(10),
+				Color:  fmt.Sprintf("#%s", utils.RandomString(6)),
 			},
 			&pb.UpdatePosResponse{
 				Status: int32(http.StatusOK),
@@ -329,9 +455,10 @@ func TestUpdatePos(t *testing.T) {
 				return 0
 			},
 			&pb.UpdatePosRequest{
-				Id:    0,
-				Name:  utils.RandomString(10),
-				Color: fmt.Sprintf("#%s", utils.RandomString(6)),
+				UserId: 1,
+				Id:     0,
+				Name:   utils.RandomString(10),
+				Color:  fmt.Sprintf("#%s", utils.RandomString(6)),
 			},
 			&pb.UpdatePosResponse{
 				Status: int32(http.StatusBadRequest),
@@ -353,9 +480,10 @@ func TestUpdatePos(t *testing.T) {
 				return pos.Id
 			},
 			&pb.UpdatePosRequest{
-				Id:    1,
-				Name:  "",
-				Color: fmt.Sprintf("#%s", utils.RandomString(6)),
+				UserId: 1,
+				Id:     1,
+				Name:   "",
+				Color:  fmt.Sprintf("#%s", utils.RandomString(6)),
 			},
 			&pb.UpdatePosResponse{
 				Status: int32(http.StatusBadRequest),
@@ -378,9 +506,10 @@ func TestUpdatePos(t *testing.T) {
 				return pos.Id
 			},
 			&pb.UpdatePosRequest{
-				Id:    1,
-				Name:  utils.RandomString(10),
-				Color: "",
+				UserId: 1,
+				Id:     1,
+				Name:   utils.RandomString(10),
+				Color:  "",
 			},
 			&pb.UpdatePosResponse{
 				Status: int32(http.StatusBadRequest),
@@ -393,9 +522,49 @@ func TestUpdatePos(t *testing.T) {
 				return 9999
 			},
 			&pb.UpdatePosRequest{
-				Id:    99999,
-				Name:  utils.RandomString(10),
-				Color: fmt.Sprintf("#%s", utils.RandomString(6)),
+				UserId: 1,
+				Id:     99999,
+				Name:   utils.RandomString(10),
+				Color:  fmt.Sprintf("#%s", utils.RandomString(6)),
+			},
+			&pb.UpdatePosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "pos-not-found",
+			},
+		},
+		{
+			"Invalid User ID",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
+				return 1
+			},
+			&pb.UpdatePosRequest{
+				UserId: 0,
+				Name:   utils.RandomString(10),
+				Color:  fmt.Sprintf("#%s", utils.RandomString(6)),
+			},
+			&pb.UpdatePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Other User's Pos",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
+				arg := &pb.CreatePosRequest{
+					UserId: 1,
+					Name:   utils.RandomString(10),
+					Type:   0,
+					Color:  fmt.Sprintf("#%s", utils.RandomString(6)),
+				}
+				pos, err := client.CreatePos(ctx, arg)
+				require.NoError(t, err)
+
+				return pos.Id
+			},
+			&pb.UpdatePosRequest{
+				UserId: 2,
+				Name:   utils.RandomString(10),
+				Color:  fmt.Sprintf("#%s", utils.RandomString(6)),
 			},
 			&pb.UpdatePosResponse{
 				Status: int32(http.StatusNotFound),
@@ -428,10 +597,40 @@ func TestUpdatePos(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdatePosKeepsIcon(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewPosServiceClient(conn)
+
+	pos, err := client.CreatePos(ctx, &pb.CreatePosRequest{
+		UserId: 1,
+		Name:   utils.RandomString(10),
+		Type:   0,
+		Color:  "#ffffff",
+		Icon:   "shopping-cart",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), pos.Status)
+
+	// clients that don't know about icons send none
+	response, err := client.UpdatePosByUser(ctx, &pb.UpdatePosRequest{
+		Id:     pos.Id,
+		UserId: 1,
+		Name:   utils.RandomString(10),
+		Color:  "#000000",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), response.Status)
+	require.Equal(t, "shopping-cart", response.Pos.Icon)
+}
 func TestDeletePos(t *testing.T) {
 	testCases := []struct {
 		name     string
 		getPosID func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32
+		userId   int32
 		resp     *pb.DeletePosResponse
 	}{
 		{
@@ -449,6 +648,7 @@ func TestDeletePos(t *testing.T) {
 				return pos.Id
 
 			},
+			1,
 			&pb.DeletePosResponse{
 				Status: int32(http.StatusOK),
 				Error:  "",
@@ -459,6 +659,7 @@ func TestDeletePos(t *testing.T) {
 			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
 				return 0
 			},
+			1,
 			&pb.DeletePosResponse{
 				Status: int32(http.StatusBadRequest),
 				Error:  "invalid-id",
@@ -469,6 +670,43 @@ func TestDeletePos(t *testing.T) {
 			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
 				return 999
 			},
+			1,
+			&pb.DeletePosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "pos-not-found",
+			},
+		},
+		{
+			"Pos Has Children",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
+				parentId := createPos(t, ctx, client, 0)
+				createPos(t, ctx, client, parentId)
+
+				return parentId
+			},
+			1,
+			&pb.DeletePosResponse{
+				Status: int32(http.StatusConflict),
+				Error:  "pos-has-children",
+			},
+		},
+		{
+			"Invalid User ID",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
+				return 1
+			},
+			0,
+			&pb.DeletePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Other User's Pos",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) int32 {
+				return 1
+			},
+			2,
 			&pb.DeletePosResponse{
 				Status: int32(http.StatusNotFound),
 				Error:  "pos-not-found",
@@ -488,7 +726,8 @@ func TestDeletePos(t *testing.T) {
 			posId := tc.getPosID(t, ctx, client)
 
 			req := &pb.DeletePosRequest{
-				Id: posId,
+				Id:     posId,
+				UserId: tc.userId,
 			}
 
 			response, err := client.DeletePosByUser(ctx, req)
@@ -500,6 +739,477 @@ func TestDeletePos(t *testing.T) {
 	}
 }
 
+func createPos(t *testing.T, ctx context.Context, client pb.PosServiceClient, parentId int32) int32 {
+	arg := &pb.CreatePosRequest{
+		UserId:   1,
+		Name:     utils.RandomString(10),
+		Type:     0,
+		Color:    fmt.Sprintf("#%s", utils.RandomString(6)),
+		ParentId: parentId,
+	}
+	pos, err := client.CreatePos(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), pos.Status)
+
+	return pos.Id
+}
+
+func TestMovePos(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewPosServiceClient(conn)
+
+	parentId := createPos(t, ctx, client, 0)
+	childId := createPos(t, ctx, client, parentId)
+	otherId := createPos(t, ctx, client, 0)
+	archivedId := createPos(t, ctx, client, 0)
+
+	archived, err := client.ArchivePos(ctx, &pb.ArchivePosRequest{Id: archivedId, UserId: 1, Archived: true})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), archived.Status)
+
+	testCases := []struct {
+		name string
+		req  *pb.MovePosRequest
+		resp *pb.MovePosResponse
+	}{
+		{
+			"OK",
+			&pb.MovePosRequest{
+				Id:       otherId,
+				UserId:   1,
+				ParentId: childId,
+			},
+			&pb.MovePosResponse{
+				Status: int32(http.StatusOK),
+				Error:  "",
+			},
+		},
+		{
+			"OK Move To Top Level",
+			&pb.MovePosRequest{
+				Id:       otherId,
+				UserId:   1,
+				ParentId: 0,
+			},
+			&pb.MovePosResponse{
+				Status: int32(http.StatusOK),
+				Error:  "",
+			},
+		},
+		{
+			"Cycle",
+			&pb.MovePosRequest{
+				Id:       parentId,
+				UserId:   1,
+				ParentId: childId,
+			},
+			&pb.MovePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "pos-cycle-detected",
+			},
+		},
+		{
+			"Own Parent",
+			&pb.MovePosRequest{
+				Id:       parentId,
+				UserId:   1,
+				ParentId: parentId,
+			},
+			&pb.MovePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-parent-id",
+			},
+		},
+		{
+			"Other User's Pos",
+			&pb.MovePosRequest{
+				Id:       childId,
+				UserId:   2,
+				ParentId: 0,
+			},
+			&pb.MovePosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "pos-not-found",
+			},
+		},
+		{
+			"Parent Not Found",
+			&pb.MovePosRequest{
+				Id:       childId,
+				UserId:   1,
+				ParentId: 99999,
+			},
+			&pb.MovePosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "parent-pos-not-found",
+			},
+		},
+		{
+			"Archived Parent",
+			&pb.MovePosRequest{
+				Id:       otherId,
+				UserId:   1,
+				ParentId: archivedId,
+			},
+			&pb.MovePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "parent-pos-archived",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.MovePos(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	detail, err := client.PosDetail(ctx, &pb.PosDetailRequest{Id: parentId, UserId: 1})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), detail.Status)
+	require.Len(t, detail.Pos.Children, 1)
+	require.Equal(t, childId, detail.Pos.Children[0].Id)
+}
+
+func TestMergePos(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewPosServiceClient(conn)
+
+	targetId := createPos(t, ctx, client, 0)
+
+	testCases := []struct {
+		name   string
+		getIDs func(t *testing.T, ctx context.Context, client pb.PosServiceClient) (int32, int32)
+		req    *pb.MergePosRequest
+		resp   *pb.MergePosResponse
+	}{
+		{
+			"OK Delete Source",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) (int32, int32) {
+				sourceId := createPos(t, ctx, client, 0)
+				createPos(t, ctx, client, sourceId)
+
+				return sourceId, targetId
+			},
+			&pb.MergePosRequest{
+				UserId: 1,
+			},
+			&pb.MergePosResponse{
+				Status: int32(http.StatusOK),
+				Error:  "",
+			},
+		},
+		{
+			"OK Archive Source",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) (int32, int32) {
+				return createPos(t, ctx, client, 0), targetId
+			},
+			&pb.MergePosRequest{
+				UserId:        1,
+				ArchiveSource: true,
+			},
+			&pb.MergePosResponse{
+				Status: int32(http.StatusOK),
+				Error:  "",
+			},
+		},
+		{
+			"Same Pos",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) (int32, int32) {
+				return targetId, targetId
+			},
+			&pb.MergePosRequest{
+				UserId: 1,
+			},
+			&pb.MergePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-target-id",
+			},
+		},
+		{
+			"Target Not Found",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) (int32, int32) {
+				return createPos(t, ctx, client, 0), 99999
+			},
+			&pb.MergePosRequest{
+				UserId: 1,
+			},
+			&pb.MergePosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "target-pos-not-found",
+			},
+		},
+		{
+			"Target Archived",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) (int32, int32) {
+				archivedId := createPos(t, ctx, client, 0)
+
+				archived, err := client.ArchivePos(ctx, &pb.ArchivePosRequest{Id: archivedId, UserId: 1, Archived: true})
+				require.NoError(t, err)
+				require.Equal(t, int32(http.StatusOK), archived.Status)
+
+				return createPos(t, ctx, client, 0), archivedId
+			},
+			&pb.MergePosRequest{
+				UserId: 1,
+			},
+			&pb.MergePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "parent-pos-archived",
+			},
+		},
+		{
+			"Target Is Sub-pos",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) (int32, int32) {
+				sourceId := createPos(t, ctx, client, 0)
+
+				return sourceId, createPos(t, ctx, client, sourceId)
+			},
+			&pb.MergePosRequest{
+				UserId: 1,
+			},
+			&pb.MergePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "pos-cycle-detected",
+			},
+		},
+		{
+			"Other User's Pos",
+			func(t *testing.T, ctx context.Context, client pb.PosServiceClient) (int32, int32) {
+				return createPos(t, ctx, client, 0), targetId
+			},
+			&pb.MergePosRequest{
+				UserId: 2,
+			},
+			&pb.MergePosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "pos-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.Id, tc.req.TargetId = tc.getIDs(t, ctx, client)
+
+			response, err := client.MergePos(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+
+			if response.Status == int32(http.StatusOK) {
+				require.Equal(t, tc.req.TargetId, response.Pos.Id)
+
+				detail, err := client.PosDetail(ctx, &pb.PosDetailRequest{Id: tc.req.Id, UserId: 1})
+				require.NoError(t, err)
+				if tc.req.ArchiveSource {
+					require.Equal(t, int32(http.StatusOK), detail.Status)
+				} else {
+					require.Equal(t, int32(http.StatusNotFound), detail.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestArchivePos(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewPosServiceClient(conn)
+
+	parentId := createPos(t, ctx, client, 0)
+	childId := createPos(t, ctx, client, parentId)
+
+	testCases := []struct {
+		name string
+		req  *pb.ArchivePosRequest
+		resp *pb.ArchivePosResponse
+	}{
+		{
+			"OK",
+			&pb.ArchivePosRequest{
+				Id:       parentId,
+				UserId:   1,
+				Archived: true,
+			},
+			&pb.ArchivePosResponse{
+				Status: int32(http.StatusOK),
+				Error:  "",
+			},
+		},
+		{
+			"Parent Archived",
+			&pb.ArchivePosRequest{
+				Id:       childId,
+				UserId:   1,
+				Archived: false,
+			},
+			&pb.ArchivePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "parent-pos-archived",
+			},
+		},
+		{
+			"Invalid ID",
+			&pb.ArchivePosRequest{
+				Id:       0,
+				UserId:   1,
+				Archived: true,
+			},
+			&pb.ArchivePosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-id",
+			},
+		},
+		{
+			"Other User's Pos",
+			&pb.ArchivePosRequest{
+				Id:       parentId,
+				UserId:   2,
+				Archived: false,
+			},
+			&pb.ArchivePosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "pos-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ArchivePos(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	detail, err := client.PosDetail(ctx, &pb.PosDetailRequest{Id: parentId, UserId: 1})
+	require.NoError(t, err)
+	require.True(t, detail.Pos.Archived)
+	require.Len(t, detail.Pos.Children, 1)
+	require.True(t, detail.Pos.Children[0].Archived)
+
+	containsPos := func(includeArchived bool) bool {
+		list, err := client.GetPosByUser(ctx, &pb.GetPosListRequest{
+			UserId:          1,
+			Type:            2,
+			Limit:           1000,
+			Page:            1,
+			IncludeArchived: includeArchived,
+		})
+		require.NoError(t, err)
+		for _, p := range list.Pos {
+			if p.Id == parentId {
+				return true
+			}
+		}
+		return false
+	}
+	require.False(t, containsPos(false))
+	require.True(t, containsPos(true))
+}
+
+func TestReorderPos(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewPosServiceClient(conn)
+
+	firstId := createPos(t, ctx, client, 0)
+	secondId := createPos(t, ctx, client, 0)
+
+	testCases := []struct {
+		name string
+		req  *pb.ReorderPosRequest
+		resp *pb.ReorderPosResponse
+	}{
+		{
+			"OK",
+			&pb.ReorderPosRequest{
+				UserId: 1,
+				Ids:    []int32{secondId, firstId},
+			},
+			&pb.ReorderPosResponse{
+				Status: int32(http.StatusOK),
+				Error:  "",
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.ReorderPosRequest{
+				UserId: 0,
+				Ids:    []int32{secondId, firstId},
+			},
+			&pb.ReorderPosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Duplicate IDs",
+			&pb.ReorderPosRequest{
+				UserId: 1,
+				Ids:    []int32{firstId, firstId},
+			},
+			&pb.ReorderPosResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-ids",
+			},
+		},
+		{
+			"Other User's Pos",
+			&pb.ReorderPosRequest{
+				UserId: 2,
+				Ids:    []int32{firstId},
+			},
+			&pb.ReorderPosResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "pos-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ReorderPos(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	first, err := client.PosDetail(ctx, &pb.PosDetailRequest{Id: firstId, UserId: 1})
+	require.NoError(t, err)
+	second, err := client.PosDetail(ctx, &pb.PosDetailRequest{Id: secondId, UserId: 1})
+	require.NoError(t, err)
+	require.Less(t, second.Pos.SortOrder, first.Pos.SortOrder)
+}
+
 func TestUpdateTotalPos(t *testing.T) {
 	var lastInsertedId int32
 	testCases := []struct {
@@ -620,6 +1330,7 @@ func TestUpdateTotalPos(t *testing.T) {
 				Action: tc.action,
 				Amount: tc.amount,
 				Id:     posId,
+				UserId: 1,
 			}
 
 			response, err := client.UpdateTotalPosByUser(ctx, req)
@@ -43,3 +43,45 @@ func genericUpdateTotalPosByUserResponse(statusCode int, errorMessage string) (*
 		Error:  errorMessage,
 	}, nil
 }
+
+func genericMovePosResponse(statusCode int, errorMessage string) (*pb.MovePosResponse, error) {
+	return &pb.MovePosResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericMergePosResponse(statusCode int, errorMessage string) (*pb.MergePosResponse, error) {
+	return &pb.MergePosResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericArchivePosResponse(statusCode int, errorMessage string) (*pb.ArchivePosResponse, error) {
+	return &pb.ArchivePosResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericReorderPosResponse(statusCode int, errorMessage string) (*pb.ReorderPosResponse, error) {
+	return &pb.ReorderPosResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericGetPosTemplatesResponse(statusCode int, errorMessage string) (*pb.GetPosTemplatesResponse, error) {
+	return &pb.GetPosTemplatesResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericApplyPosTemplateResponse(statusCode int, errorMessage string) (*pb.ApplyPosTemplateResponse, error) {
+	return &pb.ApplyPosTemplateResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/maslow123/pos/pkg/pb"
+)
+
+// applyPosTemplateQuery copies the items of a template into the pos of a user
+// (or of the household in $3), appending them after the existing pos. Items
+// already there (same name and type) are skipped so a template can be applied
+// again safely. Register in the users service copies the default template
+// with a plain insert of its own, a new user has no pos to skip or append to.
+var applyPosTemplateQuery = fmt.Sprintf(`
+	INSERT INTO pos (user_id, household_id, name, type, color, icon, sort_order)
+	SELECT
+		$1, NULLIF($3, 0), i.name, i.type, i.color, i.icon,
+		(SELECT COALESCE(MAX(sort_order), 0) FROM pos WHERE %s) + i.sort_order
+	FROM pos_template_items i
+	JOIN pos_templates t ON t.id = i.template_id
+	WHERE t.code = $2
+	AND NOT EXISTS (
+		SELECT 1 FROM pos p
+		WHERE %s AND p.type = i.type AND lower(p.name) = lower(i.name)
+	)
+`, ownedBy("", 1, 3), ownedBy("p.", 1, 3))
+
+func (s *Server) GetPosTemplates(ctx context.Context, req *pb.GetPosTemplatesRequest) (*pb.GetPosTemplatesResponse, error) {
+	q := `
+		SELECT t.id, t.code, t.name, i.name, i.type, i.color, COALESCE(i.icon, '')
+		FROM pos_templates t
+		JOIN pos_template_items i ON i.template_id = t.id
+		ORDER BY t.id, i.sort_order, i.id
+	`
+
+	rows, err := s.DB.QueryContext(ctx, q)
+	if err != nil {
+		log.Println(err)
+		return genericGetPosTemplatesResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer rows.Close()
+
+	var templates []*pb.PosTemplate
+	for rows.Next() {
+		var t pb.PosTemplate
+		var item pb.PosTemplateItem
+
+		if err := rows.Scan(
+			&t.Id,
+			&t.Code,
+			&t.Name,
+			&item.Name,
+			&item.Type,
+			&item.Color,
+			&item.Icon,
+		); err != nil {
+			log.Println(err)
+			return genericGetPosTemplatesResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		if len(templates) == 0 || templates[len(templates)-1].Id != t.Id {
+			templates = append(templates, &t)
+		}
+		last := templates[len(templates)-1]
+		last.Items = append(last.Items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return genericGetPosTemplatesResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.GetPosTemplatesResponse{
+		Status:    int32(http.StatusOK),
+		Error:     "",
+		Templates: templates,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) ApplyPosTemplate(ctx context.Context, req *pb.ApplyPosTemplateRequest) (*pb.ApplyPosTemplateResponse, error) {
+	if req.UserId == 0 {
+		return genericApplyPosTemplateResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Code == "" {
+		return genericApplyPosTemplateResponse(http.StatusBadRequest, "invalid-code")
+	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericApplyPosTemplateResponse(status, errMessage)
+	}
+
+	q := `SELECT id FROM pos_templates WHERE code = $1`
+
+	var templateId int32
+	row := s.DB.QueryRowContext(ctx, q, req.Code)
+	err := row.Scan(&templateId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericApplyPosTemplateResponse(http.StatusNotFound, "template-not-found")
+		}
+		return genericApplyPosTemplateResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	res, err := s.DB.ExecContext(ctx, applyPosTemplateQuery, req.UserId, req.Code, req.HouseholdId)
+	if err != nil {
+		log.Println(err)
+		return genericApplyPosTemplateResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	created, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return genericApplyPosTemplateResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.ApplyPosTemplateResponse{
+		Status:  int32(http.StatusCreated),
+		Error:   "",
+		Created: int32(created),
+	}
+
+	return resp, nil
+}
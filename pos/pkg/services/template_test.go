@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/pos/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPosTemplates(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewPosServiceClient(conn)
+
+	response, err := client.GetPosTemplates(ctx, &pb.GetPosTemplatesRequest{})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), response.Status)
+
+	var household *pb.PosTemplate
+	for _, template := range response.Templates {
+		if template.Code == "id-household" {
+			household = template
+		}
+	}
+	require.NotNil(t, household)
+	require.NotEmpty(t, household.Items)
+}
+
+func TestApplyPosTemplate(t *testing.T) {
+	testCases := []struct {
+		name string
+		req  *pb.ApplyPosTemplateRequest
+		resp *pb.ApplyPosTemplateResponse
+	}{
+		{
+			"OK",
+			&pb.ApplyPosTemplateRequest{
+				UserId: 2,
+				Code:   "id-household",
+			},
+			&pb.ApplyPosTemplateResponse{
+				Status: int32(http.StatusCreated),
+				Error:  "",
+			},
+		},
+		{
+			"OK Applied Twice",
+			&pb.ApplyPosTemplateRequest{
+				UserId: 2,
+				Code:   "id-household",
+			},
+			&pb.ApplyPosTemplateResponse{
+				Status:  int32(http.StatusCreated),
+				Error:   "",
+				Created: 0,
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.ApplyPosTemplateRequest{
+				UserId: 0,
+				Code:   "id-household",
+			},
+			&pb.ApplyPosTemplateResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Invalid Code",
+			&pb.ApplyPosTemplateRequest{
+				UserId: 2,
+				Code:   "",
+			},
+			&pb.ApplyPosTemplateResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-code",
+			},
+		},
+		{
+			"Template Not Found",
+			&pb.ApplyPosTemplateRequest{
+				UserId: 2,
+				Code:   "not-a-template",
+			},
+			&pb.ApplyPosTemplateResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "template-not-found",
+			},
+		},
+	}
+
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewPosServiceClient(conn)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ApplyPosTemplate(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if tc.name == "OK Applied Twice" {
+				require.Equal(t, tc.resp.Created, response.Created)
+			}
+		})
+	}
+}
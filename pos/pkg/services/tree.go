@@ -0,0 +1,82 @@
+package services
+
+import "github.com/maslow123/pos/pkg/pb"
+
+// buildPosTree links a flat list of pos into trees and returns the roots in
+// the order they were given. A pos whose parent is not part of the list is
+// treated as a root. Totals are rolled up so that every pos reports the sum of
+// its own total and the totals of all its descendants, while OwnTotal keeps the
+// amount booked directly on the pos. Period totals, when present, are rolled up
+// the same way and then compared with the previous period. Should the parents
+// of some pos ever loop, the pos where the loop is entered is treated as a
+// root, so no pos goes missing.
+func buildPosTree(list []*pb.Pos) []*pb.Pos {
+	byId := make(map[int32]*pb.Pos, len(list))
+	for _, p := range list {
+		p.Children = nil
+		byId[p.Id] = p
+	}
+	broken := cycleEntries(list, byId)
+
+	var roots []*pb.Pos
+	for _, p := range list {
+		parent, ok := byId[p.ParentId]
+		if p.ParentId == 0 || !ok || broken[p.Id] {
+			roots = append(roots, p)
+			continue
+		}
+		parent.Children = append(parent.Children, p)
+	}
+
+	for _, root := range roots {
+		rollUpTotal(root)
+	}
+
+	return roots
+}
+
+// cycleEntries walks up from every pos and returns the first pos seen twice on
+// a walk, one for each loop of parents.
+func cycleEntries(list []*pb.Pos, byId map[int32]*pb.Pos) map[int32]bool {
+	const (
+		walking = 1
+		done    = 2
+	)
+	state := make(map[int32]int, len(list))
+	entries := map[int32]bool{}
+
+	for _, p := range list {
+		var path []*pb.Pos
+		for cur := p; cur != nil && state[cur.Id] == 0; cur = byId[cur.ParentId] {
+			state[cur.Id] = walking
+			path = append(path, cur)
+
+			if next := byId[cur.ParentId]; next != nil && state[next.Id] == walking {
+				entries[next.Id] = true
+			}
+		}
+		for _, seen := range path {
+			state[seen.Id] = done
+		}
+	}
+
+	return entries
+}
+
+func rollUpTotal(p *pb.Pos) int32 {
+	p.OwnTotal = p.Total
+	for _, child := range p.Children {
+		p.Total += rollUpTotal(child)
+		if p.Period != nil && child.Period != nil {
+			p.Period.Income += child.Period.Income
+			p.Period.Expense += child.Period.Expense
+			p.Period.PreviousIncome += child.Period.PreviousIncome
+			p.Period.PreviousExpense += child.Period.PreviousExpense
+		}
+	}
+	if p.Period != nil {
+		setPeriodChange(p.Period)
+	}
+
+	return p.Total
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/maslow123/pos/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPosTree(t *testing.T) {
+	list := []*pb.Pos{
+		{Id: 1, Name: "Makanan", Total: 1000},
+		{Id: 2, Name: "Belanja dapur", Total: 2000, ParentId: 1},
+		{Id: 3, Name: "Restoran", Total: 3000, ParentId: 1},
+		{Id: 4, Name: "Sayur", Total: 500, ParentId: 2},
+		{Id: 5, Name: "Transportasi", Total: 700},
+		{Id: 6, Name: "Parkir", Total: 100, ParentId: 99},
+	}
+
+	roots := buildPosTree(list)
+	require.Len(t, roots, 3)
+
+	food := roots[0]
+	require.Equal(t, int32(1), food.Id)
+	require.Equal(t, int32(1000), food.OwnTotal)
+	require.Equal(t, int32(6500), food.Total)
+	require.Len(t, food.Children, 2)
+
+	groceries := food.Children[0]
+	require.Equal(t, int32(2500), groceries.Total)
+	require.Equal(t, int32(2000), groceries.OwnTotal)
+	require.Len(t, groceries.Children, 1)
+
+	require.Equal(t, int32(700), roots[1].Total)
+	require.Empty(t, roots[1].Children)
+
+	// parent outside of the list (e.g. filtered out) is shown at the top level
+	require.Equal(t, int32(6), roots[2].Id)
+	require.Equal(t, int32(100), roots[2].Total)
+}
+
+func TestBuildPosTreePeriod(t *testing.T) {
+	list := []*pb.Pos{
+		{Id: 1, Name: "Makanan", Period: &pb.PosPeriod{Expense: 1000, PreviousExpense: 400}},
+		{Id: 2, Name: "Restoran", ParentId: 1, Period: &pb.PosPeriod{Expense: 3000, PreviousExpense: 600}},
+		{Id: 3, Name: "Gaji", Period: &pb.PosPeriod{Income: 5000}},
+	}
+
+	roots := buildPosTree(list)
+	require.Len(t, roots, 2)
+
+	require.Equal(t, int32(4000), roots[0].Period.Expense)
+	require.Equal(t, int32(1000), roots[0].Period.PreviousExpense)
+	require.Equal(t, float64(300), roots[0].Period.ExpenseChangePercent)
+	require.Equal(t, int32(3000), roots[0].Children[0].Period.Expense)
+	require.Equal(t, int32(5000), roots[1].Period.Income)
+}
+
+func TestBuildPosTreeCycle(t *testing.T) {
+	list := []*pb.Pos{
+		{Id: 1, Name: "Makanan", Total: 1000, ParentId: 3},
+		{Id: 2, Name: "Restoran", Total: 2000, ParentId: 1},
+		{Id: 3, Name: "Kopi", Total: 300, ParentId: 2},
+		{Id: 4, Name: "Sarapan", Total: 50, ParentId: 3},
+		{Id: 5, Name: "Transportasi", Total: 700},
+	}
+
+	roots := buildPosTree(list)
+	require.Len(t, roots, 2)
+
+	// the loop is cut where it was entered, every pos is still shown once
+	require.Equal(t, int32(1), roots[0].Id)
+	require.Equal(t, int32(3350), roots[0].Total)
+	require.Equal(t, int32(2), roots[0].Children[0].Id)
+	require.Equal(t, int32(3), roots[0].Children[0].Children[0].Id)
+	require.Len(t, roots[0].Children[0].Children[0].Children, 1)
+
+	require.Equal(t, int32(5), roots[1].Id)
+}
@@ -7,6 +7,8 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	// timezones of the user preferences, the images don't ship tzdata
+	_ "time/tzdata"
 
 	_ "github.com/lib/pq"
 	"github.com/maslow123/transactions/pkg/client"
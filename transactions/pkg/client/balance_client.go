@@ -26,18 +26,35 @@ func InitBalanceServiceClient(url string) BalanceServiceClient {
 	return c
 }
 
-func (c *BalanceServiceClient) UpsertBalance(userId, transactionType, action, total int32) (*pb.UpsertBalanceResponse, error) {
+func (c *BalanceServiceClient) UpsertBalance(userId, householdId, transactionType, action, total int32) (*pb.UpsertBalanceResponse, error) {
 	actionType := pb.UpsertBalanceRequest_ActionType(pb.UpsertBalanceRequest_ActionType_value["INCREASE"])
 	if action == 1 {
 		actionType = pb.UpsertBalanceRequest_ActionType(pb.UpsertBalanceRequest_ActionType_value["DECREASE"])
 	}
 
 	req := &pb.UpsertBalanceRequest{
+		UserId:      userId,
+		HouseholdId: householdId,
+		Type:        transactionType,
+		Action:      actionType,
+		Total:       total,
+	}
+
+	return c.Client.UpsertBalance(context.Background(), req)
+}
+
+func (c *BalanceServiceClient) UpsertLiability(userId, liabilityId, action, total int32) (*pb.UpsertLiabilityResponse, error) {
+	actionType := pb.UpsertLiabilityRequest_INCREASE
+	if action == 1 {
+		actionType = pb.UpsertLiabilityRequest_DECREASE
+	}
+
+	req := &pb.UpsertLiabilityRequest{
+		Id:     liabilityId,
 		UserId: userId,
-		Type:   transactionType,
 		Action: actionType,
 		Total:  total,
 	}
 
-	return c.Client.UpsertBalance(context.Background(), req)
+	return c.Client.UpsertLiability(context.Background(), req)
 }
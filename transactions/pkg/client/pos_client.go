@@ -26,19 +26,23 @@ func InitPosServiceClient(url string) PosServiceClient {
 	return c
 }
 
-func (c *PosServiceClient) PosDetail(posId int32) (*pb.PosDetailResponse, error) {
+func (c *PosServiceClient) PosDetail(userId, householdId, posId int32) (*pb.PosDetailResponse, error) {
 	req := &pb.PosDetailRequest{
-		Id: posId,
+		Id:          posId,
+		UserId:      userId,
+		HouseholdId: householdId,
 	}
 
 	return c.Client.PosDetail(context.Background(), req)
 }
 
-func (c *PosServiceClient) UpdateTotalPosByUser(posId, amount int32, action pb.UpdateTotalPosRequest_ActionTransaction) (*pb.UpdateTotalPosResponse, error) {
+func (c *PosServiceClient) UpdateTotalPosByUser(userId, householdId, posId, amount int32, action pb.UpdateTotalPosRequest_ActionTransaction) (*pb.UpdateTotalPosResponse, error) {
 	req := &pb.UpdateTotalPosRequest{
-		Id:     posId,
-		Amount: amount,
-		Action: action,
+		Id:          posId,
+		UserId:      userId,
+		HouseholdId: householdId,
+		Amount:      amount,
+		Action:      action,
 	}
 
 	return c.Client.UpdateTotalPosByUser(context.Background(), req)
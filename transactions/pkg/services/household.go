@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+)
+
+// checkMember is a copy of the one documented in the pos service.
+func (s *Server) checkMember(ctx context.Context, userId, householdId int32) (int, string) {
+	if householdId == 0 {
+		return http.StatusOK, ""
+	}
+	if householdId < 0 {
+		return http.StatusBadRequest, "invalid-household-id"
+	}
+
+	q := `SELECT 1 FROM household_members WHERE household_id = $1 AND user_id = $2`
+
+	var member int
+	err := s.DB.QueryRowContext(ctx, q, householdId, userId).Scan(&member)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return http.StatusForbidden, "not-a-household-member"
+		}
+		return http.StatusInternalServerError, err.Error()
+	}
+
+	return http.StatusOK, ""
+}
+
+// ownedBy is the condition matching the rows of a table (pos or transactions,
+// prefix is its alias with a dot or empty) of the household in placeholder $h,
+// or the personal rows of the user in $u when $h is 0.
+func ownedBy(prefix string, u, h int) string {
+	return fmt.Sprintf(
+		"(%[1]shousehold_id = $%[3]d OR ($%[3]d = 0 AND %[1]shousehold_id IS NULL AND %[1]suser_id = $%[2]d))",
+		prefix, u, h,
+	)
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maslow123/transactions/pkg/config"
+	"github.com/maslow123/transactions/pkg/pb"
+	"github.com/maslow123/transactions/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// createHousehold is a copy of the one documented in the pos tests.
+func createHousehold(t *testing.T, ownerId int32, memberIds ...int32) int32 {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var householdId int32
+	q := `INSERT INTO households (name, owner_id) VALUES ($1, $2) RETURNING id`
+	require.NoError(t, db.QueryRow(q, utils.RandomString(10), ownerId).Scan(&householdId))
+
+	q = `INSERT INTO household_members (household_id, user_id, role) VALUES ($1, $2, $3)`
+	_, err = db.Exec(q, householdId, ownerId, "owner")
+	require.NoError(t, err)
+	for _, memberId := range memberIds {
+		_, err = db.Exec(q, householdId, memberId, "member")
+		require.NoError(t, err)
+	}
+
+	return householdId
+}
+
+// createHouseholdPos stores a household of the seeded users with one pos.
+func createHouseholdPos(t *testing.T, ownerId int32, memberIds ...int32) (householdId int32, posId int32) {
+	householdId = createHousehold(t, ownerId, memberIds...)
+
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	q := `INSERT INTO pos (user_id, household_id, name, type, color) VALUES ($1, $2, $3, 0, '#FF00FF') RETURNING id`
+	require.NoError(t, db.QueryRow(q, ownerId, householdId, utils.RandomString(10)).Scan(&posId))
+
+	return householdId, posId
+}
+
+func TestHouseholdTransaction(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewTransactionServiceClient(conn)
+	householdId, posId := createHouseholdPos(t, 1, 2)
+
+	create, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{
+		UserId:      1,
+		HouseholdId: householdId,
+		PosId:       posId,
+		Total:       2000,
+		Details:     "Belanja bulanan",
+		ActionType:  1,
+		Type:        0,
+		Date:        int32(time.Now().Unix()),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), create.Status)
+
+	testCases := []struct {
+		name        string
+		userId      int32
+		householdId int32
+		resp        *pb.DetailTransactionResponse
+	}{
+		{
+			"OK Member",
+			2,
+			householdId,
+			&pb.DetailTransactionResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Not in personal transactions",
+			1,
+			0,
+			&pb.DetailTransactionResponse{
+				Status: http.StatusNotFound,
+				Error:  "transaction-not-found",
+			},
+		},
+		{
+			"Not a member",
+			3,
+			householdId,
+			&pb.DetailTransactionResponse{
+				Status: http.StatusForbidden,
+				Error:  "not-a-household-member",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.DetailTransaction(ctx, &pb.DetailTransactionRequest{
+				Id:          create.Id,
+				UserId:      tc.userId,
+				HouseholdId: tc.householdId,
+			})
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusOK {
+				require.Equal(t, householdId, response.Transaction.HouseholdId)
+				require.Equal(t, int32(1), response.Transaction.CreatedBy)
+				require.NotEmpty(t, response.Transaction.CreatedByName)
+			}
+		})
+	}
+
+	list, err := client.GetTransactionByUser(ctx, &pb.GetTransactionListRequest{
+		UserId:      2,
+		HouseholdId: householdId,
+		Page:        1,
+		Limit:       10,
+		Action:      2,
+		Period:      "today",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), list.Status)
+	require.Len(t, list.Transaction, 1)
+
+	// any member can remove it
+	remove, err := client.DeleteTransactionByUser(ctx, &pb.DeleteTransactionRequest{
+		Id:          create.Id,
+		UserId:      2,
+		HouseholdId: householdId,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), remove.Status)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// preferences of a user are saved by the users service, a user without any
+// gets the same defaults it uses.
+type preferences struct {
+	Currency           string
+	Locale             string
+	Location           *time.Location
+	WeekStart          time.Weekday
+	DefaultBalanceType int32
+}
+
+const (
+	defaultCurrency = "IDR"
+	defaultLocale   = "id-ID"
+	defaultTimezone = "Asia/Jakarta"
+)
+
+func (s *Server) userPreferences(ctx context.Context, userId int32) (preferences, error) {
+	p := preferences{
+		Currency:           defaultCurrency,
+		Locale:             defaultLocale,
+		WeekStart:          time.Monday,
+		DefaultBalanceType: 0,
+	}
+	timezone := defaultTimezone
+
+	q := `
+		SELECT currency, locale, timezone, week_start, default_balance_type
+		FROM user_preferences
+		WHERE user_id = $1
+	`
+
+	var weekStart int32
+	row := s.DB.QueryRowContext(ctx, q, userId)
+	err := row.Scan(&p.Currency, &p.Locale, &timezone, &weekStart, &p.DefaultBalanceType)
+	if err != nil && err != sql.ErrNoRows {
+		return p, err
+	}
+	if err == nil {
+		p.WeekStart = time.Weekday(weekStart)
+	}
+
+	p.Location, err = time.LoadLocation(timezone)
+	if err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+// period returns the first and last day of "today", "week" or "month" in the
+// user's timezone, weeks start on the user's week start. ok is false for any
+// other period.
+func (p preferences) period(name string, now time.Time) (start, end time.Time, ok bool) {
+	now = now.In(p.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, p.Location)
+
+	switch name {
+	case "today":
+		return today, today, true
+	case "week":
+		offset := (int(today.Weekday()) - int(p.WeekStart) + 7) % 7
+		start = today.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6), true
+	case "month":
+		start = today.AddDate(0, 0, 1-today.Day())
+		return start, start.AddDate(0, 1, -1), true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// wallTime reads a created_at scanned from a timestamp without time zone, its
+// clock is the user's wall time so it is placed in the user's timezone.
+func (p preferences) wallTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), p.Location)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferencesPeriod(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	require.NoError(t, err)
+
+	// wednesday 2022-03-09 in Jakarta, still tuesday in UTC
+	now := time.Date(2022, time.March, 8, 20, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		period    string
+		weekStart time.Weekday
+		start     string
+		end       string
+		ok        bool
+	}{
+		{"Today", "today", time.Monday, "2022-03-09", "2022-03-09", true},
+		{"Week from monday", "week", time.Monday, "2022-03-07", "2022-03-13", true},
+		{"Week from sunday", "week", time.Sunday, "2022-03-06", "2022-03-12", true},
+		{"Week from wednesday", "week", time.Wednesday, "2022-03-09", "2022-03-15", true},
+		{"Month", "month", time.Monday, "2022-03-01", "2022-03-31", true},
+		{"Invalid period", "year", time.Monday, "", "", false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			p := preferences{Location: jakarta, WeekStart: tc.weekStart}
+
+			start, end, ok := p.period(tc.period, now)
+			require.Equal(t, tc.ok, ok)
+			if ok {
+				require.Equal(t, tc.start, start.Format("2006-01-02"))
+				require.Equal(t, tc.end, end.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestPreferencesWallTime(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	require.NoError(t, err)
+
+	// created_at of a transaction made at 2022-03-09 08:00 in Jakarta, the
+	// driver scans it as UTC
+	scanned := time.Date(2022, time.March, 9, 8, 0, 0, 0, time.UTC)
+
+	p := preferences{Location: jakarta}
+	created := p.wallTime(scanned)
+	require.Equal(t, time.Date(2022, time.March, 9, 1, 0, 0, 0, time.UTC).Unix(), created.Unix())
+	require.Equal(t, "2022-03-09 08:00", created.Format("2006-01-02 15:04"))
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/maslow123/transactions/pkg/pb"
+	"github.com/maslow123/transactions/pkg/utils"
 )
 
 func (s *Server) CreateTransaction(ctx context.Context, req *pb.CreateTransactionRequest) (*pb.CreateTransactionResponse, error) {
@@ -27,33 +28,75 @@ func (s *Server) CreateTransaction(ctx context.Context, req *pb.CreateTransactio
 	if req.ActionType != 0 && req.ActionType != 1 {
 		return genericCreateTransactionResponse(http.StatusBadRequest, "invalid-action-type")
 	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericCreateTransactionResponse(status, errMessage)
+	}
+
+	prefs, err := s.userPreferences(ctx, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericCreateTransactionResponse(http.StatusInternalServerError, err.Error())
+	}
+	if req.DefaultType {
+		req.Type = prefs.DefaultBalanceType
+	}
 	if req.Type != 0 && req.Type != 1 {
 		return genericCreateTransactionResponse(http.StatusBadRequest, "invalid-type")
 	}
-	// check existing pos
-	pos, err := s.PosService.PosDetail(req.PosId)
+	// only expenses can be charged to a credit card or loan, payments go through PayLiability
+	if req.LiabilityId != 0 && req.ActionType != 1 {
+		return genericCreateTransactionResponse(http.StatusBadRequest, "invalid-liability-action")
+	}
+	// check the pos exists and belongs to the user (or household)
+	pos, err := s.PosService.PosDetail(req.UserId, req.HouseholdId, req.PosId)
 	if err != nil || pos.Status != int32(http.StatusOK) {
 		log.Println(err)
 		return genericCreateTransactionResponse(int(pos.Status), pos.Error)
 	}
+	if pos.Pos.Archived {
+		return genericCreateTransactionResponse(http.StatusBadRequest, "pos-archived")
+	}
+
+	// Charge the liability before the transaction row references it. The FK
+	// check of the insert would hold a key share lock on the liability until
+	// commit, and the balance service locks that row to update it. The charge
+	// also answers liability-not-found for ids that aren't the user's.
+	committed := false
+	if req.LiabilityId != 0 {
+		// liabilities are always personal
+		updateLiability, err := s.BalanceService.UpsertLiability(req.UserId, req.LiabilityId, 0, req.Total)
+		if err != nil {
+			log.Println(err)
+			return genericCreateTransactionResponse(http.StatusInternalServerError, err.Error())
+		}
+		if updateLiability.Status != int32(http.StatusCreated) {
+			return genericCreateTransactionResponse(int(updateLiability.Status), updateLiability.Error)
+		}
+		log.Printf("===== Liability %d currently owes Rp.%d =====", updateLiability.Id, updateLiability.CurrentOwed)
+
+		// reverse the charge when the transaction isn't booked after all
+		defer func() {
+			if committed {
+				return
+			}
+			if _, err := s.BalanceService.UpsertLiability(req.UserId, req.LiabilityId, 1, req.Total); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
 
 	// insert tx
 	q := `
 		INSERT INTO transactions
-		(user_id, pos_id, total, details, type, action, created_at)
+		(user_id, pos_id, total, details, type, action, created_at, liability_id, household_id)
 		VALUES
-		($1, $2, $3, $4, $5, $6, $7)
+		($1, $2, $3, $4, $5, $6, $7, NULLIF($8, 0), NULLIF($9, 0))
 		RETURNING id
 	`
 
-	dt := time.Unix(int64(req.Date), 0)
-	currentDate := time.Now().Format("2006-01-02")
-	selectedDate := dt.Format("2006-01-02")
+	// created_at holds the wall time in the user's timezone
+	dt := time.Unix(int64(req.Date), 0).In(prefs.Location)
 
-	dt = time.Unix(int64(req.Date), 0).Add(time.Hour * 7)
-	if currentDate == selectedDate {
-		dt = time.Unix(int64(req.Date), 0).Local().UTC()
-	}
 	// Start transaction
 	tx, err := s.DB.BeginTx(ctx, nil)
 	if err != nil {
@@ -70,6 +113,8 @@ func (s *Server) CreateTransaction(ctx context.Context, req *pb.CreateTransactio
 		&req.Type,
 		&req.ActionType,
 		&dt,
+		&req.LiabilityId,
+		&req.HouseholdId,
 	)
 	var lastInsertedId int
 	err = row.Scan(&lastInsertedId)
@@ -79,26 +124,56 @@ func (s *Server) CreateTransaction(ctx context.Context, req *pb.CreateTransactio
 		return genericCreateTransactionResponse(http.StatusInternalServerError, err.Error())
 	}
 
+	if req.LiabilityId == 0 {
+		// Update balance
+		updateBalance, err := s.BalanceService.UpsertBalance(req.UserId, req.HouseholdId, req.Type, req.ActionType, req.Total)
+		if err != nil {
+			log.Println(err)
+			return genericCreateTransactionResponse(http.StatusInternalServerError, err.Error())
+		}
+		if updateBalance.Status != int32(http.StatusCreated) {
+			return genericCreateTransactionResponse(int(updateBalance.Status), updateBalance.Error)
+		}
+		log.Printf("===== Balance %d currently has Rp.%d =====", updateBalance.Id, updateBalance.CurrentBalance)
+
+		// reverse the balance update like the liability charge
+		defer func() {
+			if committed {
+				return
+			}
+			if _, err := s.BalanceService.UpsertBalance(req.UserId, req.HouseholdId, req.Type, 1-req.ActionType, req.Total); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
 	// Update pos total
-	updatePos, err := s.PosService.UpdateTotalPosByUser(req.PosId, req.Total, 0)
-	if err != nil || updatePos.Status != int32(http.StatusOK) {
+	updatePos, err := s.PosService.UpdateTotalPosByUser(req.UserId, req.HouseholdId, req.PosId, req.Total, 0)
+	if err != nil {
 		log.Println(err)
-		return genericCreateTransactionResponse(int(pos.Status), pos.Error)
+		return genericCreateTransactionResponse(http.StatusInternalServerError, err.Error())
+	}
+	if updatePos.Status != int32(http.StatusOK) {
+		return genericCreateTransactionResponse(int(updatePos.Status), updatePos.Error)
 	}
 	log.Printf("===== Pos %s currently has Rp.%d =====", pos.Pos.Name, updatePos.Total)
 
-	// Update balance
-	updateBalance, err := s.BalanceService.UpsertBalance(req.UserId, req.Type, req.ActionType, req.Total)
-	if err != nil || updateBalance.Status != int32(http.StatusCreated) {
-		log.Println(err)
-		return genericCreateTransactionResponse(int(updateBalance.Status), updateBalance.Error)
-	}
-	log.Printf("===== Balance %d currently has Rp.%d =====", updateBalance.Id, updateBalance.CurrentBalance)
+	// and the pos total when the commit fails
+	defer func() {
+		if committed {
+			return
+		}
+		if _, err := s.PosService.UpdateTotalPosByUser(req.UserId, req.HouseholdId, req.PosId, req.Total, 1); err != nil {
+			log.Println(err)
+		}
+	}()
 
 	// Commit the transaction.
 	if err = tx.Commit(); err != nil {
+		log.Println(err)
 		return genericCreateTransactionResponse(http.StatusInternalServerError, err.Error())
 	}
+	committed = true
 
 	resp := &pb.CreateTransactionResponse{
 		Status: http.StatusCreated,
@@ -121,34 +196,52 @@ func (s *Server) GetTransactionByUser(ctx context.Context, req *pb.GetTransactio
 	if req.Action != 0 && req.Action != 1 && req.Action != 2 {
 		return genericGetTransactionListByUserResponse(http.StatusBadRequest, "invalid-type")
 	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericGetTransactionListByUserResponse(status, errMessage)
+	}
 
-	params := 1
+	prefs, err := s.userPreferences(ctx, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericGetTransactionListByUserResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// dates are filtered in the user's timezone, today when no period is given
+	var start, end time.Time
+	if req.Period != "" {
+		var ok bool
+		start, end, ok = prefs.period(req.Period, time.Now())
+		if !ok {
+			return genericGetTransactionListByUserResponse(http.StatusBadRequest, "invalid-period")
+		}
+	} else if req.StartDate != 0 && req.EndDate != 0 {
+		start = time.Unix(int64(req.StartDate), 0).In(prefs.Location)
+		end = time.Unix(int64(req.EndDate), 0).In(prefs.Location)
+	} else {
+		start, end, _ = prefs.period("today", time.Now())
+	}
+	startDate := start.Format("2006-01-02")
+	endDate := end.Format("2006-01-02")
+
+	params := 2
 	args := make([]interface{}, 0)
-	args = append(args, req.UserId)
-	q := `
+	args = append(args, req.UserId, req.HouseholdId)
+	q := fmt.Sprintf(`
 		SELECT 
-			t.id, t.total, t.details, t.type, t.created_at,
+			t.id, t.total, t.details, t.type, t.created_at, COALESCE(t.liability_id, 0),
+			t.user_id, COALESCE(u.name, ''),
 			p."name" pos_name, p.type pos_type, p.total pos_total, p.color pos_color
 		FROM transactions t
 		LEFT JOIN pos p ON p.id = t.pos_id
 		LEFT JOIN users u ON u.id = t.user_id
-		WHERE u.id = $1		
-	`
+		WHERE %s
+	`, ownedBy("t.", 1, 2))
 	if req.Action != 2 {
 		params++
 		q = fmt.Sprintf("%s AND t.action = $%d", q, params)
 		args = append(args, req.Action)
 	}
 
-	var startDate, endDate string
-	if req.StartDate != 0 && req.EndDate != 0 {
-		startDate = time.Unix(int64(req.StartDate), 0).Add(time.Hour * 7).Format("2006-01-02")
-		endDate = time.Unix(int64(req.EndDate), 0).Add(time.Hour * 7).Format("2006-01-02")
-	} else {
-		startDate = time.Now().Format("2006-01-02")
-		endDate = time.Now().Format("2006-01-02")
-	}
-
 	q = fmt.Sprintf("%s AND t.created_at::date BETWEEN '%s' AND '%s'", q, startDate, endDate)
 
 	q = fmt.Sprintf(
@@ -176,6 +269,9 @@ func (s *Server) GetTransactionByUser(ctx context.Context, req *pb.GetTransactio
 			&transaction.Details,
 			&transaction.Type,
 			&createdAt,
+			&transaction.LiabilityId,
+			&transaction.CreatedBy,
+			&transaction.CreatedByName,
 
 			&pos.Name,
 			&pos.Type,
@@ -186,8 +282,12 @@ func (s *Server) GetTransactionByUser(ctx context.Context, req *pb.GetTransactio
 			return genericGetTransactionListByUserResponse(http.StatusInternalServerError, err.Error())
 		}
 
+		createdAt = prefs.wallTime(createdAt)
 		transaction.CreatedAt = int32(createdAt.Unix())
+		transaction.FormattedTotal = utils.FormatMoney(int64(transaction.Total), prefs.Currency, prefs.Locale)
+		transaction.FormattedDate = utils.FormatDate(createdAt, prefs.Locale)
 		transaction.Pos = &pos
+		transaction.HouseholdId = req.HouseholdId
 		transactions = append(transactions, &transaction)
 	}
 	if err := rows.Close(); err != nil {
@@ -204,9 +304,9 @@ func (s *Server) GetTransactionByUser(ctx context.Context, req *pb.GetTransactio
 	// Get user total transaction by date
 	q = fmt.Sprintf(`
 		SELECT COALESCE(SUM(total), 0) as total_transaction FROM transactions 
-		WHERE user_id = $1 AND action = $2  AND created_at BETWEEN '%s 00:00:00' AND '%s 23:59:59'
-	`, startDate, endDate)
-	row := s.DB.QueryRowContext(ctx, q, req.UserId, req.Action)
+		WHERE %s AND action = $2  AND created_at BETWEEN '%s 00:00:00' AND '%s 23:59:59'
+	`, ownedBy("", 1, 3), startDate, endDate)
+	row := s.DB.QueryRowContext(ctx, q, req.UserId, req.Action, req.HouseholdId)
 	var totalTransaction int32
 	errTotalTx := row.Scan(&totalTransaction)
 	if errTotalTx != nil {
@@ -233,27 +333,34 @@ func (s *Server) DetailTransaction(ctx context.Context, req *pb.DetailTransactio
 	if req.Id == 0 {
 		return genericDetailTransactionResponse(http.StatusBadRequest, "invalid-transaction-id")
 	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericDetailTransactionResponse(status, errMessage)
+	}
 
-	q := `
+	q := fmt.Sprintf(`
 		SELECT 
-			t.id, t.total, t.details, t.type, t.created_at,
+			t.id, t.total, t.details, t.type, t.created_at, COALESCE(t.liability_id, 0),
+			t.user_id, COALESCE(u.name, ''),
 			p."name" pos_name, p.type pos_type, p.total pos_total, p.color pos_color
 		FROM transactions t
 		LEFT JOIN pos p ON p.id = t.pos_id
 		LEFT JOIN users u ON u.id = t.user_id
-		WHERE u.id = $1 AND t.id = $2		
-	`
+		WHERE t.id = $2 AND %s
+	`, ownedBy("t.", 1, 3))
 	var transaction pb.Transaction
 	var pos pb.Pos
 	var createdAt time.Time
 
-	row := s.DB.QueryRowContext(ctx, q, req.UserId, req.Id)
+	row := s.DB.QueryRowContext(ctx, q, req.UserId, req.Id, req.HouseholdId)
 	err := row.Scan(
 		&transaction.Id,
 		&transaction.Total,
 		&transaction.Details,
 		&transaction.Type,
 		&createdAt,
+		&transaction.LiabilityId,
+		&transaction.CreatedBy,
+		&transaction.CreatedByName,
 		&pos.Name,
 		&pos.Type,
 		&pos.Total,
@@ -268,8 +375,18 @@ func (s *Server) DetailTransaction(ctx context.Context, req *pb.DetailTransactio
 		return genericDetailTransactionResponse(http.StatusInternalServerError, err.Error())
 	}
 
+	prefs, err := s.userPreferences(ctx, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericDetailTransactionResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	createdAt = prefs.wallTime(createdAt)
 	transaction.CreatedAt = int32(createdAt.Unix())
+	transaction.FormattedTotal = utils.FormatMoney(int64(transaction.Total), prefs.Currency, prefs.Locale)
+	transaction.FormattedDate = utils.FormatDate(createdAt, prefs.Locale)
 	transaction.Pos = &pos
+	transaction.HouseholdId = req.HouseholdId
 
 	resp := &pb.DetailTransactionResponse{
 		Status:      http.StatusOK,
@@ -289,16 +406,19 @@ func (s *Server) DeleteTransactionByUser(ctx context.Context, req *pb.DeleteTran
 	if req.UserId == 0 {
 		return genericDeleteTransactionResponse(http.StatusBadRequest, "invalid-user-id")
 	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericDeleteTransactionResponse(status, errMessage)
+	}
 
-	q := `
+	q := fmt.Sprintf(`
 		DELETE FROM transactions 
-		WHERE id = $1 AND user_id = $2
-		RETURNING pos_id, total, user_id, type
-	`
+		WHERE id = $1 AND %s
+		RETURNING pos_id, total, user_id, type, COALESCE(liability_id, 0)
+	`, ownedBy("", 2, 3))
 
-	row := s.DB.QueryRowContext(ctx, q, req.Id, req.UserId)
-	var posId, posTotal, userId, paymentType int32
-	err := row.Scan(&posId, &posTotal, &userId, &paymentType)
+	row := s.DB.QueryRowContext(ctx, q, req.Id, req.UserId, req.HouseholdId)
+	var posId, posTotal, userId, paymentType, liabilityId int32
+	err := row.Scan(&posId, &posTotal, &userId, &paymentType, &liabilityId)
 
 	if err != nil {
 		log.Println(err)
@@ -309,17 +429,29 @@ func (s *Server) DeleteTransactionByUser(ctx context.Context, req *pb.DeleteTran
 	}
 
 	// update pos total
-	updatePos, err := s.PosService.UpdateTotalPosByUser(posId, posTotal, 1)
+	updatePos, err := s.PosService.UpdateTotalPosByUser(req.UserId, req.HouseholdId, posId, posTotal, 1)
 	if err != nil || updatePos.Status != int32(http.StatusOK) {
 		log.Println(err)
 		return genericDeleteTransactionResponse(int(updatePos.Status), updatePos.Error)
 	}
 
-	// Update balance
-	updateBalance, err := s.BalanceService.UpsertBalance(userId, paymentType, 1, posTotal)
-	if err != nil || updateBalance.Status != int32(http.StatusCreated) {
-		log.Println(err)
-		return genericDeleteTransactionResponse(int(updateBalance.Status), updateBalance.Error)
+	if liabilityId != 0 {
+		// Reverse the charge on the credit card / loan of the member who made it
+		updateLiability, err := s.BalanceService.UpsertLiability(userId, liabilityId, 1, posTotal)
+		if err != nil {
+			log.Println(err)
+			return genericDeleteTransactionResponse(http.StatusInternalServerError, err.Error())
+		}
+		if updateLiability.Status != int32(http.StatusCreated) {
+			return genericDeleteTransactionResponse(int(updateLiability.Status), updateLiability.Error)
+		}
+	} else {
+		// Update balance
+		updateBalance, err := s.BalanceService.UpsertBalance(req.UserId, req.HouseholdId, paymentType, 1, posTotal)
+		if err != nil || updateBalance.Status != int32(http.StatusCreated) {
+			log.Println(err)
+			return genericDeleteTransactionResponse(int(updateBalance.Status), updateBalance.Error)
+		}
 	}
 
 	resp := &pb.DeleteTransactionResponse{
@@ -344,8 +476,11 @@ func (s *Server) GetPercentageExpenditure(ctx context.Context, req *pb.GetPercen
 	if req.EndDate == "" || err != nil {
 		return genericGetPercentageExpenditureResponse(http.StatusBadRequest, "invalid-end-date")
 	}
+	if status, errMessage := s.checkMember(ctx, req.UserId, req.HouseholdId); status != http.StatusOK {
+		return genericGetPercentageExpenditureResponse(status, errMessage)
+	}
 
-	q := `
+	q := fmt.Sprintf(`
 		SELECT 
 			today_expenditure, other_expenditure
 		FROM 
@@ -353,20 +488,20 @@ func (s *Server) GetPercentageExpenditure(ctx context.Context, req *pb.GetPercen
 				(
 					SELECT SUM(total) AS today_expenditure, action
 					FROM transactions
-					WHERE action = 1 AND user_id = $1 AND created_at::date = $2
+					WHERE action = 1 AND %[1]s AND created_at::date = $2
 					GROUP BY action
 				) te 
 				JOIN (
 						SELECT SUM(total) AS other_expenditure, action
 						FROM transactions			
-						WHERE action = 1 and user_id = $1 AND created_at::date = $3
+						WHERE action = 1 and %[1]s AND created_at::date = $3
 						GROUP BY action
 				) oe ON te.action = oe.action
 			)
 		GROUP BY today_expenditure, other_expenditure
-	`
+	`, ownedBy("", 1, 4))
 
-	row := s.DB.QueryRowContext(ctx, q, req.UserId, req.StartDate, req.EndDate)
+	row := s.DB.QueryRowContext(ctx, q, req.UserId, req.StartDate, req.EndDate, req.HouseholdId)
 	var todayExpenses, otherDayExpenses, percentage float32
 
 	err = row.Scan(&todayExpenses, &otherDayExpenses)
@@ -2,12 +2,16 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/maslow123/transactions/pkg/client"
+	"github.com/maslow123/transactions/pkg/config"
 	"github.com/maslow123/transactions/pkg/pb"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 )
 
 func TestCreateTransaction(t *testing.T) {
@@ -48,6 +52,22 @@ func TestCreateTransaction(t *testing.T) {
 				Error:  "",
 			},
 		},
+		{
+			"OK with default type",
+			&pb.CreateTransactionRequest{
+				UserId:      1,
+				PosId:       1,
+				Total:       2000,
+				Details:     "Beli cilok",
+				ActionType:  1,
+				DefaultType: true,
+				Date:        int32(time.Now().Unix()),
+			},
+			&pb.CreateTransactionResponse{
+				Status: int32(http.StatusCreated),
+				Error:  "",
+			},
+		},
 		{
 			"Invalid UserID",
 			&pb.CreateTransactionRequest{
@@ -144,6 +164,40 @@ func TestCreateTransaction(t *testing.T) {
 				Error:  "invalid-type",
 			},
 		},
+		{
+			"Invalid Liability Action",
+			&pb.CreateTransactionRequest{
+				UserId:      1,
+				PosId:       1,
+				Total:       2000,
+				Details:     "Gaji",
+				ActionType:  0,
+				Type:        0,
+				Date:        int32(time.Now().Unix()),
+				LiabilityId: 1,
+			},
+			&pb.CreateTransactionResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-liability-action",
+			},
+		},
+		{
+			"Liability Not Found",
+			&pb.CreateTransactionRequest{
+				UserId:      1,
+				PosId:       1,
+				Total:       2000,
+				Details:     "Beli cireng",
+				ActionType:  1,
+				Type:        0,
+				Date:        int32(time.Now().Unix()),
+				LiabilityId: 9999999,
+			},
+			&pb.CreateTransactionResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "liability-not-found",
+			},
+		},
 		{
 			"Pos Not Found",
 			&pb.CreateTransactionRequest{
@@ -160,6 +214,22 @@ func TestCreateTransaction(t *testing.T) {
 				Error:  "pos-not-found",
 			},
 		},
+		{
+			"Other User's Pos",
+			&pb.CreateTransactionRequest{
+				UserId:     2,
+				PosId:      1,
+				Total:      2000,
+				Details:    "Beli cireng",
+				ActionType: 0,
+				Type:       0,
+				Date:       int32(time.Now().Unix()),
+			},
+			&pb.CreateTransactionResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "pos-not-found",
+			},
+		},
 	}
 
 	ctx := context.Background()
@@ -180,6 +250,158 @@ func TestCreateTransaction(t *testing.T) {
 		})
 	}
 }
+
+// createLiability stores a credit card of the user, liabilities are managed
+// by the balance service.
+func createLiability(t *testing.T, db *sql.DB, userId int32, creditLimit int32) int32 {
+	var id int32
+	q := `
+		INSERT INTO liabilities (user_id, name, type, credit_limit, statement_day, due_day)
+		VALUES ($1, 'Kartu kredit', 0, $2, 25, 10)
+		RETURNING id
+	`
+	require.NoError(t, db.QueryRow(q, userId, creditLimit).Scan(&id))
+
+	return id
+}
+
+func TestCreateTransactionLiability(t *testing.T) {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewTransactionServiceClient(conn)
+	liabilityId := createLiability(t, db, 1, 5000)
+
+	testCases := []struct {
+		name   string
+		userId int32
+		total  int32
+		resp   *pb.CreateTransactionResponse
+		owed   int32
+	}{
+		{
+			"OK",
+			1,
+			3000,
+			&pb.CreateTransactionResponse{
+				Status: int32(http.StatusCreated),
+				Error:  "",
+			},
+			3000,
+		},
+		{
+			"Credit Limit Exceeded",
+			1,
+			3000,
+			&pb.CreateTransactionResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "credit-limit-exceeded",
+			},
+			3000,
+		},
+		{
+			"Other User's Liability",
+			2,
+			1000,
+			&pb.CreateTransactionResponse{
+				Status: int32(http.StatusNotFound),
+				Error:  "liability-not-found",
+			},
+			3000,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.CreateTransaction(ctx, &pb.CreateTransactionRequest{
+				UserId:      tc.userId,
+				PosId:       1,
+				Total:       tc.total,
+				Details:     "Beli sepatu",
+				ActionType:  1,
+				Type:        0,
+				Date:        int32(time.Now().Unix()),
+				LiabilityId: liabilityId,
+			})
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+
+			var owed, transactions int32
+			q := `SELECT total, (SELECT count(*) FROM transactions WHERE liability_id = $1) FROM liabilities WHERE id = $1`
+			require.NoError(t, db.QueryRow(q, liabilityId).Scan(&owed, &transactions))
+			require.Equal(t, tc.owed, owed)
+			// only the charge that went through is booked
+			require.Equal(t, int32(1), transactions)
+		})
+	}
+}
+
+// failingPosClient is the pos service with a pos total that can't be updated.
+type failingPosClient struct {
+	pb.PosServiceClient
+}
+
+func (c failingPosClient) UpdateTotalPosByUser(ctx context.Context, in *pb.UpdateTotalPosRequest, opts ...grpc.CallOption) (*pb.UpdateTotalPosResponse, error) {
+	return &pb.UpdateTotalPosResponse{Status: int32(http.StatusInternalServerError), Error: "pos-update-failed"}, nil
+}
+
+func TestCreateTransactionCompensation(t *testing.T) {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	posService := client.InitPosServiceClient(c.PosServiceUrl)
+	s := Server{
+		DB:             db,
+		PosService:     client.PosServiceClient{Client: failingPosClient{posService.Client}},
+		BalanceService: client.InitBalanceServiceClient(c.BalanceServiceUrl),
+	}
+
+	balance := func() int32 {
+		var total int32
+		q := `SELECT total FROM balance WHERE user_id = 1 AND type = 0 AND household_id IS NULL`
+		require.NoError(t, db.QueryRow(q).Scan(&total))
+		return total
+	}
+	transactions := func() int32 {
+		var count int32
+		require.NoError(t, db.QueryRow(`SELECT count(*) FROM transactions WHERE user_id = 1`).Scan(&count))
+		return count
+	}
+	before, beforeCount := balance(), transactions()
+
+	ctx := context.Background()
+	response, err := s.CreateTransaction(ctx, &pb.CreateTransactionRequest{
+		UserId:     1,
+		PosId:      1,
+		Total:      5000,
+		Details:    "Gaji",
+		ActionType: 0,
+		Type:       0,
+		Date:       int32(time.Now().Unix()),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusInternalServerError), response.Status)
+	require.Equal(t, "pos-update-failed", response.Error)
+
+	// the balance update is reversed and the transaction isn't booked
+	require.Equal(t, before, balance())
+	require.Equal(t, beforeCount, transactions())
+}
+
 func TestGetTransactionList(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -276,6 +498,34 @@ func TestGetTransactionList(t *testing.T) {
 				Error:  "invalid-type",
 			},
 		},
+		{
+			"OK with period",
+			&pb.GetTransactionListRequest{
+				UserId: 1,
+				Page:   1,
+				Limit:  5,
+				Action: 2,
+				Period: "month",
+			},
+			&pb.GetTransactionListResponse{
+				Status: int32(http.StatusOK),
+				Error:  "",
+			},
+		},
+		{
+			"Invalid Period",
+			&pb.GetTransactionListRequest{
+				UserId: 1,
+				Page:   1,
+				Limit:  5,
+				Action: 2,
+				Period: "year",
+			},
+			&pb.GetTransactionListResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-period",
+			},
+		},
 		{
 			"Transaction Not Found",
 			&pb.GetTransactionListRequest{
@@ -310,6 +560,8 @@ func TestGetTransactionList(t *testing.T) {
 			require.Equal(t, tc.resp.Error, response.Error)
 			if response.Status == int32(http.StatusOK) {
 				require.NotEmpty(t, response.Transaction)
+				require.NotEmpty(t, response.Transaction[0].FormattedTotal)
+				require.NotEmpty(t, response.Transaction[0].FormattedDate)
 			}
 		})
 	}
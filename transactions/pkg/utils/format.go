@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type localeFormat struct {
+	// separates groups of thousands
+	group      string
+	dateLayout string
+	// whether the currency symbol is followed by a space
+	symbolSpace bool
+}
+
+// localeFormats are the locales the users service accepts as a preference.
+var localeFormats = map[string]localeFormat{
+	"id-ID": {group: ".", dateLayout: "02/01/2006", symbolSpace: true},
+	"en-US": {group: ",", dateLayout: "01/02/2006"},
+	"en-GB": {group: ",", dateLayout: "02/01/2006"},
+}
+
+// currencySymbols are the currencies the users service accepts as a preference.
+var currencySymbols = map[string]string{
+	"IDR": "Rp",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"SGD": "S$",
+	"MYR": "RM",
+	"AUD": "A$",
+	"JPY": "¥",
+}
+
+const defaultLocale = "id-ID"
+
+func getLocaleFormat(locale string) localeFormat {
+	if format, ok := localeFormats[locale]; ok {
+		return format
+	}
+
+	return localeFormats[defaultLocale]
+}
+
+// FormatMoney formats a whole amount of a currency for a locale, e.g.
+// "Rp 1.500.000" for IDR in id-ID or "$1,500,000" for USD in en-US.
+func FormatMoney(amount int64, currency string, locale string) string {
+	format := getLocaleFormat(locale)
+
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		symbol = currency
+	}
+	if format.symbolSpace || !ok {
+		symbol += " "
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteString(format.group)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	return sign + symbol + grouped.String()
+}
+
+// FormatDate formats the date of t, in its own location, for a locale.
+func FormatDate(t time.Time, locale string) string {
+	return t.Format(getLocaleFormat(locale).dateLayout)
+}
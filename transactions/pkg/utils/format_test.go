@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMoney(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		currency string
+		locale   string
+		want     string
+	}{
+		{1500000, "IDR", "id-ID", "Rp 1.500.000"},
+		{1500000, "USD", "en-US", "$1,500,000"},
+		{999, "IDR", "id-ID", "Rp 999"},
+		{1000, "EUR", "en-GB", "€1,000"},
+		{0, "IDR", "id-ID", "Rp 0"},
+		{-25000, "IDR", "id-ID", "-Rp 25.000"},
+		{123456, "XYZ", "en-US", "XYZ 123,456"},
+		{123456, "IDR", "xx-XX", "Rp 123.456"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.want, func(t *testing.T) {
+			require.Equal(t, tc.want, FormatMoney(tc.amount, tc.currency, tc.locale))
+		})
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2022, time.March, 7, 23, 0, 0, 0, time.UTC)
+
+	require.Equal(t, "07/03/2022", FormatDate(date, "id-ID"))
+	require.Equal(t, "03/07/2022", FormatDate(date, "en-US"))
+	require.Equal(t, "07/03/2022", FormatDate(date, "en-GB"))
+}
@@ -5,8 +5,12 @@ import (
 	"database/sql"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"time"
+	// timezones of the user preferences, the images don't ship tzdata
+	_ "time/tzdata"
 
 	_ "github.com/lib/pq"
 	"github.com/maslow123/users/pkg/client"
@@ -31,9 +35,9 @@ func main() {
 	defer db.Close()
 
 	jwt := utils.JwtWrapper{
-		SecretKey:       c.JWTSecretKey,
-		Issuer:          "user-service",
-		ExpirationHours: 24 * 1,
+		Issuer:            "user-service",
+		ExpirationMinutes: c.AccessTokenMinutes,
+		Keys:              &utils.KeyRing{},
 	}
 
 	listen, err := net.Listen("tcp", c.Port)
@@ -43,22 +47,107 @@ func main() {
 
 	balanceService := client.InitBalanceServiceClient(c.BalanceServiceUrl)
 	opts := []grpc.ServerOption{}
-	imageStore := services.NewDiskImageStore("img")
+
+	var imageStore services.ImageStore = services.NewDiskImageStore(c.ImageFolder, c.ImageBaseUrl)
+	switch c.ImageStoreDriver {
+	case "s3":
+		imageStore = &services.S3ImageStore{
+			Endpoint:  c.S3Endpoint,
+			Region:    c.S3Region,
+			Bucket:    c.S3Bucket,
+			AccessKey: c.S3AccessKey,
+			SecretKey: c.S3SecretKey,
+			Prefix:    c.S3Prefix,
+			PublicUrl: c.S3PublicUrl,
+			Client:    &http.Client{Timeout: 30 * time.Second},
+		}
+	case "cloudinary":
+		imageStore, err = services.NewCloudinaryImageStore(c.CloudinaryCloudName, c.CloudinaryApiKey, c.CloudinaryApiSecretKey, "keuanganku/users")
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	var mailer services.Mailer = services.NewFileMailer(c.MailFolder, c.MailFrom)
+	if c.MailerDriver == "smtp" {
+		mailer = &services.SMTPMailer{
+			Host:     c.SMTPHost,
+			Port:     c.SMTPPort,
+			Username: c.SMTPUsername,
+			Password: c.SMTPPassword,
+			From:     c.MailFrom,
+		}
+	}
+
+	hasher, err := utils.PasswordHasher{
+		Algorithm:     c.PasswordHashAlgorithm,
+		BcryptCost:    c.BcryptCost,
+		Argon2Time:    c.Argon2Time,
+		Argon2Memory:  c.Argon2MemoryKB,
+		Argon2Threads: c.Argon2Threads,
+	}.WithDefaults()
+	if err != nil {
+		log.Fatalln("Failed at password hashing", err)
+	}
 
 	api := services.Server{
-		DB:             db,
-		Jwt:            jwt,
-		BalanceService: balanceService,
-		ImageStore:     imageStore,
-		IsTesting:      false,
+		DB:                 db,
+		Jwt:                jwt,
+		BalanceService:     balanceService,
+		ImageStore:         imageStore,
+		DefaultPosTemplate: c.DefaultPosTemplate,
+		RefreshTokenTTL:    time.Hour * time.Duration(c.RefreshTokenHours),
+		Mailer:             mailer,
+		ResetPasswordUrl:   c.ResetPasswordUrl,
+		ResetTokenTTL:      time.Minute * time.Duration(c.ResetTokenMinutes),
+		VerifyEmailUrl:     c.VerifyEmailUrl,
+		VerifyTokenTTL:     time.Hour * time.Duration(c.VerifyTokenHours),
+		TOTPIssuer:         c.TOTPIssuer,
+		LoginThrottle: services.LoginThrottle{
+			MaxAttempts:      c.LoginMaxAttempts,
+			MaxAttemptsPerIp: c.LoginMaxAttemptsPerIp,
+			Lockout:          time.Second * time.Duration(c.LoginLockoutSeconds),
+			MaxLockout:       time.Second * time.Duration(c.LoginMaxLockoutSeconds),
+			Window:           time.Minute * time.Duration(c.LoginWindowMinutes),
+		},
+		Hasher: hasher,
+		PasswordPolicy: utils.PasswordPolicy{
+			MinLength: c.PasswordMinLength,
+			MaxLength: c.PasswordMaxLength,
+		},
+		ExportFolder:           c.ExportFolder,
+		ExportTTL:              time.Hour * time.Duration(c.ExportHours),
+		HouseholdInvitationUrl: c.HouseholdInvitationUrl,
+		InvitationTTL:          time.Hour * time.Duration(c.InvitationHours),
+		SigningAlgorithm:       c.JWTSigningAlgorithm,
+		SigningKeySecret:       c.JWTSigningKeySecret,
+		KeyRotationInterval:    time.Hour * time.Duration(c.JWTKeyRotationHours),
+		KeyPublishDelay:        time.Minute * time.Duration(c.JWTKeyPublishMinutes),
+		AdminEmail:             c.AdminEmail,
 	}
 
+	ctx := context.Background()
+
+	if err = api.CheckDefaultPosTemplate(ctx); err != nil {
+		log.Fatalln("Failed at DEFAULT_POS_TEMPLATE", err)
+	}
+
+	if err = api.BootstrapAdmin(ctx); err != nil {
+		log.Fatalln("Failed at ADMIN_EMAIL", err)
+	}
+
+	// tokens can't be issued without a key, so this has to work before serving
+	if err = api.RotateSigningKeys(ctx); err != nil {
+		log.Fatalln("Failed at signing keys", err)
+	}
+	go api.RunKeyRotation(ctx)
+	go api.RunExportCleanup(ctx)
+
 	server := grpc.NewServer(opts...)
 	pb.RegisterUserServiceServer(server, &api)
 
 	channel := make(chan os.Signal, 1)
 	signal.Notify(channel, os.Interrupt)
-	ctx := context.Background()
 
 	go func() {
 		for range channel {
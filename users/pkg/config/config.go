@@ -5,11 +5,56 @@ import "github.com/spf13/viper"
 type Config struct {
 	Port                   string `mapstructure:"PORT"`
 	DBUrl                  string `mapstructure:"DB_URL"`
-	JWTSecretKey           string `mapstructure:"JWT_SECRET_KEY"`
+	JWTSigningAlgorithm    string `mapstructure:"JWT_SIGNING_ALGORITHM"`
+	JWTSigningKeySecret    string `mapstructure:"JWT_SIGNING_KEY_SECRET"`
+	JWTKeyRotationHours    int32  `mapstructure:"JWT_KEY_ROTATION_HOURS"`
+	JWTKeyPublishMinutes   int32  `mapstructure:"JWT_KEY_PUBLISH_MINUTES"`
 	BalanceServiceUrl      string `mapstructure:"BALANCE_SERVICE_URL"`
+	ImageStoreDriver       string `mapstructure:"IMAGE_STORE"`
+	ImageFolder            string `mapstructure:"IMAGE_FOLDER"`
+	ImageBaseUrl           string `mapstructure:"IMAGE_BASE_URL"`
+	S3Endpoint             string `mapstructure:"S3_ENDPOINT"`
+	S3Region               string `mapstructure:"S3_REGION"`
+	S3Bucket               string `mapstructure:"S3_BUCKET"`
+	S3AccessKey            string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey            string `mapstructure:"S3_SECRET_KEY"`
+	S3Prefix               string `mapstructure:"S3_PREFIX"`
+	S3PublicUrl            string `mapstructure:"S3_PUBLIC_URL"`
 	CloudinaryCloudName    string `mapstructure:"CLOUDINARY_CLOUD_NAME"`
 	CloudinaryApiKey       string `mapstructure:"CLOUDINARY_API_KEY"`
 	CloudinaryApiSecretKey string `mapstructure:"CLOUDINARY_API_SECRET_KEY"`
+	DefaultPosTemplate     string `mapstructure:"DEFAULT_POS_TEMPLATE"`
+	AccessTokenMinutes     int32  `mapstructure:"ACCESS_TOKEN_EXPIRATION_MINUTES"`
+	RefreshTokenHours      int32  `mapstructure:"REFRESH_TOKEN_EXPIRATION_HOURS"`
+	ResetTokenMinutes      int32  `mapstructure:"RESET_TOKEN_EXPIRATION_MINUTES"`
+	ResetPasswordUrl       string `mapstructure:"RESET_PASSWORD_URL"`
+	VerifyTokenHours       int32  `mapstructure:"VERIFY_TOKEN_EXPIRATION_HOURS"`
+	VerifyEmailUrl         string `mapstructure:"VERIFY_EMAIL_URL"`
+	TOTPIssuer             string `mapstructure:"TOTP_ISSUER"`
+	LoginMaxAttempts       int32  `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginMaxAttemptsPerIp  int32  `mapstructure:"LOGIN_MAX_ATTEMPTS_PER_IP"`
+	LoginLockoutSeconds    int32  `mapstructure:"LOGIN_LOCKOUT_SECONDS"`
+	LoginMaxLockoutSeconds int32  `mapstructure:"LOGIN_MAX_LOCKOUT_SECONDS"`
+	LoginWindowMinutes     int32  `mapstructure:"LOGIN_ATTEMPT_WINDOW_MINUTES"`
+	PasswordHashAlgorithm  string `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	BcryptCost             int    `mapstructure:"BCRYPT_COST"`
+	Argon2Time             uint32 `mapstructure:"ARGON2_TIME"`
+	Argon2MemoryKB         uint32 `mapstructure:"ARGON2_MEMORY_KB"`
+	Argon2Threads          uint8  `mapstructure:"ARGON2_THREADS"`
+	PasswordMinLength      int    `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength      int    `mapstructure:"PASSWORD_MAX_LENGTH"`
+	ExportFolder           string `mapstructure:"EXPORT_FOLDER"`
+	ExportHours            int32  `mapstructure:"EXPORT_EXPIRATION_HOURS"`
+	InvitationHours        int32  `mapstructure:"HOUSEHOLD_INVITATION_EXPIRATION_HOURS"`
+	HouseholdInvitationUrl string `mapstructure:"HOUSEHOLD_INVITATION_URL"`
+	MailerDriver           string `mapstructure:"MAILER_DRIVER"`
+	MailFolder             string `mapstructure:"MAIL_FOLDER"`
+	MailFrom               string `mapstructure:"MAIL_FROM"`
+	SMTPHost               string `mapstructure:"SMTP_HOST"`
+	SMTPPort               string `mapstructure:"SMTP_PORT"`
+	SMTPUsername           string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword           string `mapstructure:"SMTP_PASSWORD"`
+	AdminEmail             string `mapstructure:"ADMIN_EMAIL"`
 }
 
 func LoadConfig(path string, filename string) (config Config, err error) {
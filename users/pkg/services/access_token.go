@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+// accessTokenPrefix starts every personal access token, Validate tells them
+// from JWTs by it and it makes leaked tokens easy to search for.
+const accessTokenPrefix = "kpat_"
+
+// Scopes of personal access tokens. Every token can read, the write scopes
+// allow changing the data of one service.
+const (
+	scopeRead              = "read"
+	scopePosWrite          = "pos:write"
+	scopeTransactionsWrite = "transactions:write"
+	scopeBalanceWrite      = "balance:write"
+)
+
+var accessTokenScopes = map[string]bool{
+	scopeRead:              true,
+	scopePosWrite:          true,
+	scopeTransactionsWrite: true,
+	scopeBalanceWrite:      true,
+}
+
+const maxAccessTokenDays = 365
+
+// normalizeScopes sorts the scopes and drops duplicates, ok is false for an
+// empty list or an unknown scope.
+func normalizeScopes(scopes []string) (normalized []string, ok bool) {
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if !accessTokenScopes[scope] {
+			return nil, false
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	sort.Strings(normalized)
+
+	return normalized, len(normalized) > 0
+}
+
+func scanAccessToken(scanner interface{ Scan(...interface{}) error }) (*pb.AccessToken, error) {
+	var token pb.AccessToken
+	var scopes string
+	var createdAt time.Time
+	var expiresAt, lastUsedAt sql.NullTime
+
+	err := scanner.Scan(&token.Id, &token.Name, &token.Prefix, &scopes, &createdAt, &expiresAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	token.CreatedAt = int32(createdAt.Unix())
+	if expiresAt.Valid {
+		token.ExpiresAt = int32(expiresAt.Time.Unix())
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = int32(lastUsedAt.Time.Unix())
+	}
+
+	return &token, nil
+}
+
+// CreateAccessToken issues a personal access token, the token is only returned
+// here and stored as its hash. Read-only users can only create read tokens.
+func (s *Server) CreateAccessToken(ctx context.Context, req *pb.CreateAccessTokenRequest) (*pb.CreateAccessTokenResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+
+	if req.UserId == 0 {
+		return genericCreateAccessTokenResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Name == "" || len([]rune(req.Name)) > 100 {
+		return genericCreateAccessTokenResponse(http.StatusBadRequest, "invalid-name")
+	}
+	scopes, ok := normalizeScopes(req.Scopes)
+	if !ok {
+		return genericCreateAccessTokenResponse(http.StatusBadRequest, "invalid-scopes")
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxAccessTokenDays {
+		return genericCreateAccessTokenResponse(http.StatusBadRequest, "invalid-expiration")
+	}
+
+	var role string
+	q := `SELECT role FROM users WHERE id = $1`
+
+	err := s.DB.QueryRowContext(ctx, q, req.UserId).Scan(&role)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericCreateAccessTokenResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericCreateAccessTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+	if role == roleReadOnly && (len(scopes) > 1 || scopes[0] != scopeRead) {
+		return genericCreateAccessTokenResponse(http.StatusForbidden, "scope-not-allowed")
+	}
+
+	random, err := utils.NewOpaqueToken()
+	if err != nil {
+		log.Println(err)
+		return genericCreateAccessTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+	token := accessTokenPrefix + random
+
+	q = `
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, prefix, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, CASE WHEN $6::int > 0 THEN now() + make_interval(days => $6::int) END)
+		RETURNING id, name, prefix, scopes, created_at, expires_at, last_used_at
+	`
+
+	row := s.DB.QueryRowContext(ctx, q,
+		req.UserId,
+		req.Name,
+		utils.HashToken(token),
+		token[:len(accessTokenPrefix)+4],
+		strings.Join(scopes, " "),
+		req.ExpiresInDays,
+	)
+	accessToken, err := scanAccessToken(row)
+	if err != nil {
+		log.Println(err)
+		return genericCreateAccessTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.CreateAccessTokenResponse{
+		Status:      http.StatusCreated,
+		Error:       "",
+		Token:       token,
+		AccessToken: accessToken,
+	}
+
+	return resp, nil
+}
+
+// ListAccessTokens lists the tokens of a user that weren't revoked, expired
+// ones included so the user sees which to replace.
+func (s *Server) ListAccessTokens(ctx context.Context, req *pb.ListAccessTokensRequest) (*pb.ListAccessTokensResponse, error) {
+	if req.UserId == 0 {
+		return genericListAccessTokensResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	q := `
+		SELECT id, name, prefix, scopes, created_at, expires_at, last_used_at
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := s.DB.QueryContext(ctx, q, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericListAccessTokensResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer rows.Close()
+
+	tokens := []*pb.AccessToken{}
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			log.Println(err)
+			return genericListAccessTokensResponse(http.StatusInternalServerError, err.Error())
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return genericListAccessTokensResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.ListAccessTokensResponse{
+		Status:       http.StatusOK,
+		Error:        "",
+		AccessTokens: tokens,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) RevokeAccessToken(ctx context.Context, req *pb.RevokeAccessTokenRequest) (*pb.RevokeAccessTokenResponse, error) {
+	if req.UserId == 0 {
+		return genericRevokeAccessTokenResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Id == 0 {
+		return genericRevokeAccessTokenResponse(http.StatusBadRequest, "invalid-access-token-id")
+	}
+
+	q := `
+		UPDATE personal_access_tokens SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	res, err := s.DB.ExecContext(ctx, q, req.Id, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericRevokeAccessTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return genericRevokeAccessTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+	if rows == 0 {
+		return genericRevokeAccessTokenResponse(http.StatusNotFound, "access-token-not-found")
+	}
+
+	return genericRevokeAccessTokenResponse(http.StatusOK, "")
+}
+
+// validateAccessToken is Validate for personal access tokens. The role is read
+// from the user, so it follows role changes like a new login would.
+func (s *Server) validateAccessToken(ctx context.Context, token string) (*pb.ValidateResponse, error) {
+	q := `
+		SELECT
+			t.id, t.user_id, t.scopes, t.expires_at, t.revoked_at IS NOT NULL,
+			u.role, u.disabled_at IS NOT NULL
+		FROM personal_access_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+	`
+
+	var id, userId int32
+	var scopes, role string
+	var expiresAt sql.NullTime
+	var revoked, disabled bool
+
+	row := s.DB.QueryRowContext(ctx, q, utils.HashToken(token))
+	err := row.Scan(&id, &userId, &scopes, &expiresAt, &revoked, &role, &disabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &pb.ValidateResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "invalid-token",
+			}, nil
+		}
+		log.Println(err)
+		return &pb.ValidateResponse{
+			Status: http.StatusInternalServerError,
+			Error:  err.Error(),
+		}, nil
+	}
+
+	if revoked || disabled {
+		return &pb.ValidateResponse{
+			Status: http.StatusUnauthorized,
+			Error:  "token-revoked",
+		}, nil
+	}
+	if expiresAt.Valid && expiresAt.Time.Before(time.Now()) {
+		return &pb.ValidateResponse{
+			Status: http.StatusUnauthorized,
+			Error:  "token-expired",
+		}, nil
+	}
+
+	// like sessions, at most once a minute
+	q = `
+		UPDATE personal_access_tokens SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`
+	if _, err = s.DB.ExecContext(ctx, q, id); err != nil {
+		log.Println(err)
+	}
+
+	return &pb.ValidateResponse{
+		Status:        http.StatusOK,
+		UserId:        userId,
+		Role:          role,
+		AccessTokenId: id,
+		Scopes:        strings.Fields(scopes),
+	}, nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, ok := normalizeScopes([]string{"transactions:write", "read", "transactions:write"})
+	require.True(t, ok)
+	require.Equal(t, []string{"read", "transactions:write"}, scopes)
+
+	_, ok = normalizeScopes([]string{"read", "users:write"})
+	require.False(t, ok)
+
+	_, ok = normalizeScopes(nil)
+	require.False(t, ok)
+}
+
+func TestCreateAccessToken(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.CreateAccessTokenRequest
+		resp *pb.CreateAccessTokenResponse
+	}{
+		{
+			"OK",
+			&pb.CreateAccessTokenRequest{UserId: login.User.Id, Name: "Import", Scopes: []string{"read", "transactions:write"}, ExpiresInDays: 30},
+			&pb.CreateAccessTokenResponse{
+				Status: http.StatusCreated,
+				Error:  "",
+			},
+		},
+		{
+			"OK Without Expiration",
+			&pb.CreateAccessTokenRequest{UserId: login.User.Id, Name: "Backup", Scopes: []string{"read"}},
+			&pb.CreateAccessTokenResponse{
+				Status: http.StatusCreated,
+				Error:  "",
+			},
+		},
+		{
+			"Invalid Name",
+			&pb.CreateAccessTokenRequest{UserId: login.User.Id, Name: " ", Scopes: []string{"read"}},
+			&pb.CreateAccessTokenResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-name",
+			},
+		},
+		{
+			"Invalid Scopes",
+			&pb.CreateAccessTokenRequest{UserId: login.User.Id, Name: "Import", Scopes: []string{"admin"}},
+			&pb.CreateAccessTokenResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-scopes",
+			},
+		},
+		{
+			"Invalid Expiration",
+			&pb.CreateAccessTokenRequest{UserId: login.User.Id, Name: "Import", Scopes: []string{"read"}, ExpiresInDays: 366},
+			&pb.CreateAccessTokenResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-expiration",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.CreateAccessToken(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusCreated {
+				require.True(t, strings.HasPrefix(response.Token, response.AccessToken.Prefix))
+				require.Equal(t, tc.req.Name, response.AccessToken.Name)
+				require.Equal(t, tc.req.ExpiresInDays > 0, response.AccessToken.ExpiresAt > 0)
+
+				validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: response.Token})
+				require.NoError(t, err)
+				require.Equal(t, int32(http.StatusOK), validate.Status)
+				require.Equal(t, login.User.Id, validate.UserId)
+				require.Equal(t, response.AccessToken.Id, validate.AccessTokenId)
+				require.Equal(t, tc.req.Scopes, validate.Scopes)
+			}
+		})
+	}
+
+	list, err := client.ListAccessTokens(ctx, &pb.ListAccessTokensRequest{UserId: login.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), list.Status)
+	require.Len(t, list.AccessTokens, 2)
+	require.NotZero(t, list.AccessTokens[0].LastUsedAt)
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+	other, _ := createUser(t, ctx, client)
+
+	create, err := client.CreateAccessToken(ctx, &pb.CreateAccessTokenRequest{UserId: login.User.Id, Name: "Import", Scopes: []string{"read"}})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), create.Status)
+
+	testCases := []struct {
+		name string
+		req  *pb.RevokeAccessTokenRequest
+		resp *pb.RevokeAccessTokenResponse
+	}{
+		{
+			"Invalid ID",
+			&pb.RevokeAccessTokenRequest{UserId: login.User.Id, Id: 0},
+			&pb.RevokeAccessTokenResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-access-token-id",
+			},
+		},
+		{
+			"Other User",
+			&pb.RevokeAccessTokenRequest{UserId: other.User.Id, Id: create.AccessToken.Id},
+			&pb.RevokeAccessTokenResponse{
+				Status: http.StatusNotFound,
+				Error:  "access-token-not-found",
+			},
+		},
+		{
+			"OK",
+			&pb.RevokeAccessTokenRequest{UserId: login.User.Id, Id: create.AccessToken.Id},
+			&pb.RevokeAccessTokenResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Already Revoked",
+			&pb.RevokeAccessTokenRequest{UserId: login.User.Id, Id: create.AccessToken.Id},
+			&pb.RevokeAccessTokenResponse{
+				Status: http.StatusNotFound,
+				Error:  "access-token-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.RevokeAccessToken(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: create.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+	require.Equal(t, "token-revoked", validate.Error)
+
+	validate, err = client.Validate(ctx, &pb.ValidateRequest{Token: accessTokenPrefix + "unknown"})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+	require.Equal(t, "invalid-token", validate.Error)
+}
+
+func TestReadOnlyAccessToken(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	admin := loginAdmin(t, ctx, client)
+	login, _ := createUser(t, ctx, client)
+
+	role, err := client.SetUserRole(ctx, &pb.SetUserRoleRequest{UserId: admin.User.Id, Id: login.User.Id, Role: "read-only"})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), role.Status)
+
+	create, err := client.CreateAccessToken(ctx, &pb.CreateAccessTokenRequest{UserId: login.User.Id, Name: "Import", Scopes: []string{"pos:write"}})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusForbidden), create.Status)
+	require.Equal(t, "scope-not-allowed", create.Error)
+
+	create, err = client.CreateAccessToken(ctx, &pb.CreateAccessTokenRequest{UserId: login.User.Id, Name: "Report", Scopes: []string{"read"}})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), create.Status)
+
+	// disabling the user rejects their tokens
+	disable, err := client.SetUserDisabled(ctx, &pb.SetUserDisabledRequest{UserId: admin.User.Id, Id: login.User.Id, Disabled: true})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), disable.Status)
+
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: create.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+}
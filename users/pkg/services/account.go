@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/maslow123/users/pkg/pb"
+)
+
+// deleteAccountQueries remove what a user owns in the tables of the other
+// services, they share the database so it's done in the same transaction as
+// deleting the user. Tables of the users service cascade from users.
+var deleteAccountQueries = []string{
+	// what they created in a household stays with it, credited to its owner
+	`UPDATE transactions t SET user_id = h.owner_id FROM households h WHERE t.household_id = h.id AND t.user_id = $1`,
+	`UPDATE pos p SET user_id = h.owner_id FROM households h WHERE p.household_id = h.id AND p.user_id = $1`,
+	`UPDATE balance b SET user_id = h.owner_id FROM households h WHERE b.household_id = h.id AND b.user_id = $1`,
+	`DELETE FROM transactions WHERE user_id = $1 AND household_id IS NULL`,
+	`DELETE FROM liabilities WHERE user_id = $1`,
+	// sub pos restrict deleting their parent
+	`UPDATE pos SET parent_id = NULL WHERE user_id = $1 AND household_id IS NULL`,
+	`DELETE FROM pos WHERE user_id = $1 AND household_id IS NULL`,
+	`DELETE FROM balance WHERE user_id = $1 AND household_id IS NULL`,
+	`DELETE FROM users WHERE id = $1`,
+}
+
+func (s *Server) avatarUrl(photo string) string {
+	if photo == "" {
+		return ""
+	}
+
+	return s.ImageStore.URL(photo)
+}
+
+func (s *Server) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.GetProfileResponse, error) {
+	if req.UserId <= 0 {
+		return genericGetProfileResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	q := `
+		SELECT
+			id, name, email, COALESCE(photo, ''), email_verified, COALESCE(pending_email, ''),
+			totp_enabled, role
+		FROM users
+		WHERE id = $1
+	`
+
+	var user pb.User
+	var twoFactorEnabled bool
+
+	row := s.DB.QueryRowContext(ctx, q, req.UserId)
+	err := row.Scan(
+		&user.Id,
+		&user.Name,
+		&user.Email,
+		&user.Photo,
+		&user.EmailVerified,
+		&user.PendingEmail,
+		&twoFactorEnabled,
+		&user.Role,
+	)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericGetProfileResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericGetProfileResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	preferences, err := getPreferences(ctx, s.DB, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericGetProfileResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.GetProfileResponse{
+		Status:           int32(http.StatusOK),
+		Error:            "",
+		User:             &user,
+		AvatarUrl:        s.avatarUrl(user.Photo),
+		TwoFactorEnabled: twoFactorEnabled,
+		Preferences:      preferences,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	if req.UserId <= 0 {
+		return genericDeleteAccountResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Password == "" {
+		return genericDeleteAccountResponse(http.StatusBadRequest, "invalid-password")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	var userPass, email, photo string
+	q := `SELECT password, email, COALESCE(photo, '') FROM users WHERE id = $1 FOR UPDATE`
+
+	row := tx.QueryRowContext(ctx, q, req.UserId)
+	err = row.Scan(&userPass, &email, &photo)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericDeleteAccountResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	match, _, err := s.Hasher.Verify(req.Password, userPass)
+	if err != nil {
+		log.Println(err)
+		return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+	}
+	if !match {
+		return genericDeleteAccountResponse(http.StatusBadRequest, "password-not-match")
+	}
+
+	var exportFiles []string
+	q = `SELECT file_name FROM data_exports WHERE user_id = $1 AND file_name <> ''`
+	rows, err := tx.QueryContext(ctx, q, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+	}
+	for rows.Next() {
+		var fileName string
+		if err = rows.Scan(&fileName); err != nil {
+			rows.Close()
+			log.Println(err)
+			return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+		}
+		exportFiles = append(exportFiles, fileName)
+	}
+	if err = rows.Err(); err != nil {
+		log.Println(err)
+		return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// the households they own get a new owner before their rows are credited
+	// to it
+	if err = handOverHouseholds(ctx, tx, req.UserId); err != nil {
+		log.Println(err)
+		return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	for _, q := range deleteAccountQueries {
+		if _, err = tx.ExecContext(ctx, q, req.UserId); err != nil {
+			log.Println(err)
+			return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	q = `DELETE FROM login_attempts WHERE key = $1`
+	if _, err = tx.ExecContext(ctx, q, "email:"+email); err != nil {
+		log.Println(err)
+		return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericDeleteAccountResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	s.removeExportFiles(exportFiles)
+
+	// the account is gone already, an image left behind is only logged
+	if photo != "" {
+		s.deleteImage(photo)
+	}
+
+	return genericDeleteAccountResponse(http.StatusOK, "")
+}
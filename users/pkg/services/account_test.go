@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maslow123/users/pkg/config"
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProfile(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.GetProfileRequest
+		resp *pb.GetProfileResponse
+	}{
+		{
+			"OK",
+			&pb.GetProfileRequest{UserId: login.User.Id},
+			&pb.GetProfileResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.GetProfileRequest{UserId: 0},
+			&pb.GetProfileResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"User not found",
+			&pb.GetProfileRequest{UserId: 999999},
+			&pb.GetProfileResponse{
+				Status: http.StatusNotFound,
+				Error:  "user-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.GetProfile(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusOK {
+				require.Equal(t, login.User.Email, response.User.Email)
+				require.Equal(t, login.User.Name, response.User.Name)
+				require.Empty(t, response.AvatarUrl)
+				require.False(t, response.TwoFactorEnabled)
+			}
+		})
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.DeleteAccountRequest
+		resp *pb.DeleteAccountResponse
+	}{
+		{
+			"Invalid User ID",
+			&pb.DeleteAccountRequest{UserId: 0, Password: password},
+			&pb.DeleteAccountResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Invalid Password",
+			&pb.DeleteAccountRequest{UserId: login.User.Id, Password: ""},
+			&pb.DeleteAccountResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-password",
+			},
+		},
+		{
+			"Password doesnt match",
+			&pb.DeleteAccountRequest{UserId: login.User.Id, Password: "wrong password"},
+			&pb.DeleteAccountResponse{
+				Status: http.StatusBadRequest,
+				Error:  "password-not-match",
+			},
+		},
+		{
+			"OK",
+			&pb.DeleteAccountRequest{UserId: login.User.Id, Password: password},
+			&pb.DeleteAccountResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Already deleted",
+			&pb.DeleteAccountRequest{UserId: login.User.Id, Password: password},
+			&pb.DeleteAccountResponse{
+				Status: http.StatusNotFound,
+				Error:  "user-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.DeleteAccount(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	// the tokens of the deleted user don't work anymore
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: login.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+
+	relogin, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), relogin.Status)
+}
+
+func TestDeleteAccountPhoto(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+
+	var photo bytes.Buffer
+	require.NoError(t, jpeg.Encode(&photo, image.NewNRGBA(image.Rect(0, 0, 300, 200)), nil))
+	uploaded := uploadImage(t, ctx, client, login.User.Id, photo.Bytes())
+
+	// the test server keeps its images on disk in ../tmp
+	fileNames := append([]string{uploaded.Id + uploaded.Type}, uploaded.Thumbnails...)
+	for _, fileName := range fileNames {
+		require.FileExists(t, filepath.Join("../tmp", fileName))
+	}
+
+	response, err := client.DeleteAccount(ctx, &pb.DeleteAccountRequest{UserId: login.User.Id, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), response.Status)
+
+	// removed through the image store the server was started with
+	for _, fileName := range fileNames {
+		_, err := os.Stat(filepath.Join("../tmp", fileName))
+		require.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestDeleteAccountHousehold(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	owner, ownerPassword := createUser(t, ctx, client)
+	create, err := client.CreateHousehold(ctx, &pb.CreateHouseholdRequest{UserId: owner.User.Id, Name: "Keluarga"})
+	require.NoError(t, err)
+	household := create.Household
+	member, memberPassword := joinHousehold(t, ctx, client, owner.User.Id, household.Id)
+	other, _ := joinHousehold(t, ctx, client, owner.User.Id, household.Id)
+
+	// a shared pos and transaction created by the member, and a personal pos
+	var sharedPosId, transactionId, personalPosId int32
+	q := `INSERT INTO pos (user_id, household_id, name, type) VALUES ($1, $2, 'Belanja', 1) RETURNING id`
+	require.NoError(t, db.QueryRow(q, member.User.Id, household.Id).Scan(&sharedPosId))
+	q = `
+		INSERT INTO transactions (user_id, household_id, pos_id, total, details, type, action)
+		VALUES ($1, $2, $3, 10000, 'Sayur', 1, 1)
+		RETURNING id
+	`
+	require.NoError(t, db.QueryRow(q, member.User.Id, household.Id, sharedPosId).Scan(&transactionId))
+	q = `INSERT INTO pos (user_id, name, type) VALUES ($1, 'Pribadi', 1) RETURNING id`
+	require.NoError(t, db.QueryRow(q, member.User.Id).Scan(&personalPosId))
+
+	// a member leaves their shared rows to the owner
+	response, err := client.DeleteAccount(ctx, &pb.DeleteAccountRequest{UserId: member.User.Id, Password: memberPassword})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), response.Status)
+
+	var userId int32
+	require.NoError(t, db.QueryRow(`SELECT user_id FROM pos WHERE id = $1`, sharedPosId).Scan(&userId))
+	require.Equal(t, owner.User.Id, userId)
+	require.NoError(t, db.QueryRow(`SELECT user_id FROM transactions WHERE id = $1`, transactionId).Scan(&userId))
+	require.Equal(t, owner.User.Id, userId)
+	require.Equal(t, sql.ErrNoRows, db.QueryRow(`SELECT user_id FROM pos WHERE id = $1`, personalPosId).Scan(&userId))
+
+	// the owner hands the household to the remaining member
+	response, err = client.DeleteAccount(ctx, &pb.DeleteAccountRequest{UserId: owner.User.Id, Password: ownerPassword})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), response.Status)
+
+	get, err := client.GetHousehold(ctx, &pb.GetHouseholdRequest{UserId: other.User.Id, Id: household.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), get.Status)
+	require.Equal(t, other.User.Id, get.Household.OwnerId)
+	require.Equal(t, "owner", get.Household.Role)
+
+	require.NoError(t, db.QueryRow(`SELECT user_id FROM transactions WHERE id = $1`, transactionId).Scan(&userId))
+	require.Equal(t, other.User.Id, userId)
+}
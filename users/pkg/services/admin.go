@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+)
+
+const (
+	roleUser     = "user"
+	roleAdmin    = "admin"
+	roleReadOnly = "read-only"
+)
+
+func validRole(role string) bool {
+	return role == roleUser || role == roleAdmin || role == roleReadOnly
+}
+
+// checkAdmin verifies that the user asking is an administrator whose account
+// isn't disabled. The gateway only lets admin tokens through already, this
+// keeps the service safe on its own.
+func (s *Server) checkAdmin(ctx context.Context, userId int32) (int, string) {
+	if userId == 0 {
+		return http.StatusBadRequest, "invalid-user-id"
+	}
+
+	q := `SELECT role, disabled_at IS NOT NULL FROM users WHERE id = $1`
+
+	var role string
+	var disabled bool
+	err := s.DB.QueryRowContext(ctx, q, userId).Scan(&role, &disabled)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println(err)
+		return http.StatusInternalServerError, err.Error()
+	}
+	if err == sql.ErrNoRows || role != roleAdmin || disabled {
+		return http.StatusForbidden, "admin-required"
+	}
+
+	return http.StatusOK, ""
+}
+
+// BootstrapAdmin makes the account AdminEmail names an administrator while
+// the installation has none, so the first admin comes from the configuration
+// instead of a seeded account. Later admins are appointed with SetUserRole.
+func (s *Server) BootstrapAdmin(ctx context.Context) error {
+	if s.AdminEmail == "" {
+		return nil
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userId int32
+	q := `
+		UPDATE users SET role = $1, updated_at = now()
+		WHERE email = lower(trim($2)) AND NOT EXISTS (SELECT 1 FROM users WHERE role = $1)
+		RETURNING id
+	`
+	err = tx.QueryRowContext(ctx, q, roleAdmin, s.AdminEmail).Scan(&userId)
+	if err == sql.ErrNoRows {
+		// there is an admin already, or the account isn't registered yet
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = revokeAllTokens(ctx, tx, userId); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("%s is the first administrator", s.AdminEmail)
+	return nil
+}
+
+func (s *Server) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	if req.Limit <= 0 {
+		return genericListUsersResponse(http.StatusBadRequest, "invalid-limit")
+	}
+	if req.Page <= 0 {
+		return genericListUsersResponse(http.StatusBadRequest, "invalid-page")
+	}
+	if status, errMessage := s.checkAdmin(ctx, req.UserId); status != http.StatusOK {
+		return genericListUsersResponse(status, errMessage)
+	}
+
+	search := `$1 = '' OR name ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%'`
+
+	var total int32
+	q := fmt.Sprintf(`SELECT COUNT(*) FROM users WHERE %s`, search)
+
+	err := s.DB.QueryRowContext(ctx, q, req.Search).Scan(&total)
+	if err != nil {
+		log.Println(err)
+		return genericListUsersResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = fmt.Sprintf(`
+		SELECT id, name, email, role, email_verified, disabled_at IS NOT NULL, created_at
+		FROM users
+		WHERE %s
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`, search)
+
+	rows, err := s.DB.QueryContext(ctx, q, req.Search, req.Limit, (req.Page-1)*req.Limit)
+	if err != nil {
+		log.Println(err)
+		return genericListUsersResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer rows.Close()
+
+	users := []*pb.AdminUser{}
+	for rows.Next() {
+		var user pb.AdminUser
+		var createdAt time.Time
+		if err := rows.Scan(
+			&user.Id,
+			&user.Name,
+			&user.Email,
+			&user.Role,
+			&user.EmailVerified,
+			&user.Disabled,
+			&createdAt,
+		); err != nil {
+			log.Println(err)
+			return genericListUsersResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		user.CreatedAt = int32(createdAt.Unix())
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return genericListUsersResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.ListUsersResponse{
+		Status: http.StatusOK,
+		Error:  "",
+		Page:   req.Page,
+		Limit:  req.Limit,
+		Total:  total,
+		Users:  users,
+	}
+
+	return resp, nil
+}
+
+// SetUserDisabled disables or enables another account. Disabling logs the
+// user out everywhere, Login refuses them until they are enabled again.
+func (s *Server) SetUserDisabled(ctx context.Context, req *pb.SetUserDisabledRequest) (*pb.SetUserDisabledResponse, error) {
+	if req.Id <= 0 {
+		return genericSetUserDisabledResponse(http.StatusBadRequest, "invalid-account-id")
+	}
+	if status, errMessage := s.checkAdmin(ctx, req.UserId); status != http.StatusOK {
+		return genericSetUserDisabledResponse(status, errMessage)
+	}
+	if req.Id == req.UserId {
+		return genericSetUserDisabledResponse(http.StatusBadRequest, "cannot-change-own-account")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericSetUserDisabledResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `
+		UPDATE users
+		SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, now()) END
+		WHERE id = $1
+	`
+	res, err := tx.ExecContext(ctx, q, req.Id, req.Disabled)
+	if err != nil {
+		log.Println(err)
+		return genericSetUserDisabledResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return genericSetUserDisabledResponse(http.StatusInternalServerError, err.Error())
+	}
+	if rows == 0 {
+		return genericSetUserDisabledResponse(http.StatusNotFound, "user-not-found")
+	}
+
+	if req.Disabled {
+		if err = revokeAllTokens(ctx, tx, req.Id); err != nil {
+			log.Println(err)
+			return genericSetUserDisabledResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		// a login waiting for its second factor can't be finished either
+		q = `DELETE FROM two_factor_challenges WHERE user_id = $1`
+		if _, err = tx.ExecContext(ctx, q, req.Id); err != nil {
+			log.Println(err)
+			return genericSetUserDisabledResponse(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericSetUserDisabledResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericSetUserDisabledResponse(http.StatusOK, "")
+}
+
+// SetUserRole changes the role of another user. The role is part of the access
+// tokens, so the tokens of the user are revoked when it changes.
+func (s *Server) SetUserRole(ctx context.Context, req *pb.SetUserRoleRequest) (*pb.SetUserRoleResponse, error) {
+	if req.Id <= 0 {
+		return genericSetUserRoleResponse(http.StatusBadRequest, "invalid-account-id")
+	}
+	if !validRole(req.Role) {
+		return genericSetUserRoleResponse(http.StatusBadRequest, "invalid-role")
+	}
+	if status, errMessage := s.checkAdmin(ctx, req.UserId); status != http.StatusOK {
+		return genericSetUserRoleResponse(status, errMessage)
+	}
+	if req.Id == req.UserId {
+		return genericSetUserRoleResponse(http.StatusBadRequest, "cannot-change-own-account")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericSetUserRoleResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	var role string
+	q := `SELECT role FROM users WHERE id = $1 FOR UPDATE`
+
+	err = tx.QueryRowContext(ctx, q, req.Id).Scan(&role)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericSetUserRoleResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericSetUserRoleResponse(http.StatusInternalServerError, err.Error())
+	}
+	if role == req.Role {
+		return genericSetUserRoleResponse(http.StatusOK, "")
+	}
+
+	q = `UPDATE users SET role = $2, updated_at = now() WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, req.Id, req.Role); err != nil {
+		log.Println(err)
+		return genericSetUserRoleResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = revokeAllTokens(ctx, tx, req.Id); err != nil {
+		log.Println(err)
+		return genericSetUserRoleResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericSetUserRoleResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericSetUserRoleResponse(http.StatusOK, "")
+}
+
+// GetSystemStats counts the users and what they use, a session counts as
+// active when it isn't revoked and was used in the last 30 days.
+func (s *Server) GetSystemStats(ctx context.Context, req *pb.GetSystemStatsRequest) (*pb.GetSystemStatsResponse, error) {
+	if status, errMessage := s.checkAdmin(ctx, req.UserId); status != http.StatusOK {
+		return genericGetSystemStatsResponse(status, errMessage)
+	}
+
+	q := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE email_verified),
+			COUNT(*) FILTER (WHERE disabled_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE role = 'admin'),
+			COUNT(*) FILTER (WHERE created_at > now() - interval '30 days'),
+			(SELECT COUNT(*) FROM sessions WHERE revoked_at IS NULL AND last_seen_at > now() - interval '30 days'),
+			(SELECT COUNT(*) FROM households),
+			(SELECT COUNT(*) FROM transactions)
+		FROM users
+	`
+
+	var stats pb.SystemStats
+	err := s.DB.QueryRowContext(ctx, q).Scan(
+		&stats.Users,
+		&stats.VerifiedUsers,
+		&stats.DisabledUsers,
+		&stats.Admins,
+		&stats.NewUsers,
+		&stats.ActiveSessions,
+		&stats.Households,
+		&stats.Transactions,
+	)
+	if err != nil {
+		log.Println(err)
+		return genericGetSystemStatsResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.GetSystemStatsResponse{
+		Status: http.StatusOK,
+		Error:  "",
+		Stats:  &stats,
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/users/pkg/config"
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+// loginAdmin logs in as the administrator ADMIN_EMAIL of the test config
+// names, the dialer makes it one.
+func loginAdmin(t *testing.T, ctx context.Context, client pb.UserServiceClient) *pb.LoginResponse {
+	login, err := client.Login(ctx, &pb.LoginRequest{
+		Email:    "user1@gmail.com",
+		Password: "111111",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), login.Status)
+	require.Equal(t, "admin", login.User.Role)
+
+	return login
+}
+
+func TestListUsers(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	admin := loginAdmin(t, ctx, client)
+	user, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.ListUsersRequest
+		resp *pb.ListUsersResponse
+	}{
+		{
+			"OK",
+			&pb.ListUsersRequest{UserId: admin.User.Id, Page: 1, Limit: 10, Search: user.User.Email},
+			&pb.ListUsersResponse{
+				Status: http.StatusOK,
+				Error:  "",
+				Total:  1,
+			},
+		},
+		{
+			"Not an Admin",
+			&pb.ListUsersRequest{UserId: user.User.Id, Page: 1, Limit: 10},
+			&pb.ListUsersResponse{
+				Status: http.StatusForbidden,
+				Error:  "admin-required",
+			},
+		},
+		{
+			"Invalid Limit",
+			&pb.ListUsersRequest{UserId: admin.User.Id, Page: 1, Limit: 0},
+			&pb.ListUsersResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-limit",
+			},
+		},
+		{
+			"Invalid Page",
+			&pb.ListUsersRequest{UserId: admin.User.Id, Page: 0, Limit: 10},
+			&pb.ListUsersResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-page",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ListUsers(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusOK {
+				require.Equal(t, tc.resp.Total, response.Total)
+				require.Len(t, response.Users, 1)
+				require.Equal(t, user.User.Id, response.Users[0].Id)
+				require.Equal(t, "user", response.Users[0].Role)
+				require.False(t, response.Users[0].Disabled)
+			}
+		})
+	}
+}
+
+func TestSetUserDisabled(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	admin := loginAdmin(t, ctx, client)
+	user, password := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.SetUserDisabledRequest
+		resp *pb.SetUserDisabledResponse
+	}{
+		{
+			"Not an Admin",
+			&pb.SetUserDisabledRequest{UserId: user.User.Id, Id: admin.User.Id, Disabled: true},
+			&pb.SetUserDisabledResponse{
+				Status: http.StatusForbidden,
+				Error:  "admin-required",
+			},
+		},
+		{
+			"Own Account",
+			&pb.SetUserDisabledRequest{UserId: admin.User.Id, Id: admin.User.Id, Disabled: true},
+			&pb.SetUserDisabledResponse{
+				Status: http.StatusBadRequest,
+				Error:  "cannot-change-own-account",
+			},
+		},
+		{
+			"User not found",
+			&pb.SetUserDisabledRequest{UserId: admin.User.Id, Id: 999999, Disabled: true},
+			&pb.SetUserDisabledResponse{
+				Status: http.StatusNotFound,
+				Error:  "user-not-found",
+			},
+		},
+		{
+			"OK",
+			&pb.SetUserDisabledRequest{UserId: admin.User.Id, Id: user.User.Id, Disabled: true},
+			&pb.SetUserDisabledResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.SetUserDisabled(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	// the tokens of the disabled user are revoked and they can't log in
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: user.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+
+	login, err := client.Login(ctx, &pb.LoginRequest{Email: user.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusForbidden), login.Status)
+	require.Equal(t, "account-disabled", login.Error)
+
+	enable, err := client.SetUserDisabled(ctx, &pb.SetUserDisabledRequest{UserId: admin.User.Id, Id: user.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), enable.Status)
+
+	login, err = client.Login(ctx, &pb.LoginRequest{Email: user.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), login.Status)
+}
+
+func TestSetUserRole(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	admin := loginAdmin(t, ctx, client)
+	user, password := createUser(t, ctx, client)
+
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: user.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), validate.Status)
+	require.Equal(t, "user", validate.Role)
+
+	testCases := []struct {
+		name string
+		req  *pb.SetUserRoleRequest
+		resp *pb.SetUserRoleResponse
+	}{
+		{
+			"Invalid Role",
+			&pb.SetUserRoleRequest{UserId: admin.User.Id, Id: user.User.Id, Role: "owner"},
+			&pb.SetUserRoleResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-role",
+			},
+		},
+		{
+			"Not an Admin",
+			&pb.SetUserRoleRequest{UserId: user.User.Id, Id: user.User.Id, Role: "admin"},
+			&pb.SetUserRoleResponse{
+				Status: http.StatusForbidden,
+				Error:  "admin-required",
+			},
+		},
+		{
+			"OK",
+			&pb.SetUserRoleRequest{UserId: admin.User.Id, Id: user.User.Id, Role: "read-only"},
+			&pb.SetUserRoleResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.SetUserRole(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	// the old token still carries the old role
+	validate, err = client.Validate(ctx, &pb.ValidateRequest{Token: user.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+
+	login, err := client.Login(ctx, &pb.LoginRequest{Email: user.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), login.Status)
+	require.Equal(t, "read-only", login.User.Role)
+
+	validate, err = client.Validate(ctx, &pb.ValidateRequest{Token: login.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), validate.Status)
+	require.Equal(t, "read-only", validate.Role)
+}
+
+func TestGetSystemStats(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	admin := loginAdmin(t, ctx, client)
+	user, _ := createUser(t, ctx, client)
+
+	stats, err := client.GetSystemStats(ctx, &pb.GetSystemStatsRequest{UserId: admin.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), stats.Status)
+	require.GreaterOrEqual(t, stats.Stats.Users, int32(2))
+	require.GreaterOrEqual(t, stats.Stats.Admins, int32(1))
+	require.GreaterOrEqual(t, stats.Stats.NewUsers, int32(1))
+	require.GreaterOrEqual(t, stats.Stats.ActiveSessions, int32(2))
+
+	stats, err = client.GetSystemStats(ctx, &pb.GetSystemStatsRequest{UserId: user.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusForbidden), stats.Status)
+	require.Equal(t, "admin-required", stats.Error)
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	loginAdmin(t, ctx, client)
+	user, password := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name  string
+		email string
+	}{
+		{"Disabled", ""},
+		// the installation has its first administrator already
+		{"Admin Exists", user.User.Email},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			s := Server{DB: db, AdminEmail: tc.email}
+			require.NoError(t, s.BootstrapAdmin(ctx))
+
+			login, err := client.Login(ctx, &pb.LoginRequest{Email: user.User.Email, Password: password})
+			require.NoError(t, err)
+			require.Equal(t, int32(http.StatusOK), login.Status)
+			require.Equal(t, "user", login.User.Role)
+		})
+	}
+}
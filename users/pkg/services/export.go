@@ -0,0 +1,536 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maslow123/users/pkg/pb"
+)
+
+const (
+	exportPending = "pending"
+	exportDone    = "done"
+	exportFailed  = "failed"
+	exportExpired = "expired"
+
+	// a pending export older than this was lost with a restart of the server
+	exportTimeout   = time.Hour
+	exportChunkSize = 64 * 1024
+)
+
+// ExportCleanupInterval is how often the files of expired exports are removed.
+const ExportCleanupInterval = 10 * time.Minute
+
+// dataExportTables are written to the zip as <name>.json and <name>.csv. The
+// services share the database, so the data of the other services is read from
+// their tables directly.
+var dataExportTables = []struct {
+	name  string
+	query string
+}{
+	{"profile", `SELECT id, name, email, email_verified, photo, created_at, updated_at FROM users WHERE id = $1`},
+	{"preferences", `SELECT * FROM user_preferences WHERE user_id = $1`},
+	{"pos", `SELECT * FROM pos WHERE user_id = $1 ORDER BY id`},
+	{"transactions", `SELECT * FROM transactions WHERE user_id = $1 ORDER BY id`},
+	{"balance", `SELECT * FROM balance WHERE user_id = $1 ORDER BY id`},
+	{"liabilities", `SELECT * FROM liabilities WHERE user_id = $1 ORDER BY id`},
+	{"liability_entries", `
+		SELECT e.* FROM liability_entries e
+		JOIN liabilities l ON l.id = e.liability_id
+		WHERE l.user_id = $1
+		ORDER BY e.id
+	`},
+	{"sessions", `
+		SELECT id, device_name, user_agent, ip, created_at, last_seen_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1
+		ORDER BY id
+	`},
+}
+
+func (s *Server) RequestDataExport(ctx context.Context, req *pb.RequestDataExportRequest) (*pb.DataExportResponse, error) {
+	if req.UserId <= 0 {
+		return genericDataExportResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	// serializes the requests of a user
+	q := `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+	var userId int32
+	if err = tx.QueryRowContext(ctx, q, req.UserId).Scan(&userId); err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericDataExportResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `SELECT status, created_at, file_name FROM data_exports WHERE user_id = $1`
+	rows, err := tx.QueryContext(ctx, q, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer rows.Close()
+
+	var fileNames []string
+	for rows.Next() {
+		var status, fileName string
+		var createdAt time.Time
+		if err := rows.Scan(&status, &createdAt, &fileName); err != nil {
+			log.Println(err)
+			return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+		}
+		if status == exportPending && time.Since(createdAt) < exportTimeout {
+			return genericDataExportResponse(http.StatusConflict, "export-in-progress")
+		}
+		if fileName != "" {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// a user only keeps their latest export
+	q = `DELETE FROM data_exports WHERE user_id = $1`
+	if _, err = tx.ExecContext(ctx, q, req.UserId); err != nil {
+		log.Println(err)
+		return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	var export pb.DataExport
+	var createdAt time.Time
+	q = `INSERT INTO data_exports (user_id) VALUES ($1) RETURNING id, status, created_at`
+	err = tx.QueryRowContext(ctx, q, req.UserId).Scan(&export.Id, &export.Status, &createdAt)
+	if err != nil {
+		log.Println(err)
+		return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+	}
+	export.CreatedAt = int32(createdAt.Unix())
+
+	if err = tx.Commit(); err != nil {
+		return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	s.removeExportFiles(fileNames)
+	go s.runDataExport(export.Id, req.UserId)
+
+	resp := &pb.DataExportResponse{
+		Status: int32(http.StatusAccepted),
+		Error:  "",
+		Export: &export,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetDataExport(ctx context.Context, req *pb.GetDataExportRequest) (*pb.DataExportResponse, error) {
+	if req.UserId <= 0 {
+		return genericDataExportResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Id <= 0 {
+		return genericDataExportResponse(http.StatusBadRequest, "invalid-export-id")
+	}
+
+	export, _, err := s.getDataExport(ctx, req.UserId, req.Id)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericDataExportResponse(http.StatusNotFound, "export-not-found")
+		}
+		return genericDataExportResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.DataExportResponse{
+		Status: int32(http.StatusOK),
+		Error:  "",
+		Export: export,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) DownloadDataExport(req *pb.DownloadDataExportRequest, stream pb.UserService_DownloadDataExportServer) error {
+	sendInfo := func(info *pb.DataExportFile) error {
+		return stream.Send(&pb.DownloadDataExportResponse{
+			Data: &pb.DownloadDataExportResponse_Info{Info: info},
+		})
+	}
+	sendError := func(statusCode int, errorMessage string) error {
+		return sendInfo(&pb.DataExportFile{Status: int32(statusCode), Error: errorMessage})
+	}
+
+	if req.UserId <= 0 {
+		return sendError(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Id <= 0 {
+		return sendError(http.StatusBadRequest, "invalid-export-id")
+	}
+
+	export, fileName, err := s.getDataExport(stream.Context(), req.UserId, req.Id)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return sendError(http.StatusNotFound, "export-not-found")
+		}
+		return sendError(http.StatusInternalServerError, err.Error())
+	}
+
+	switch export.Status {
+	case exportPending:
+		return sendError(http.StatusConflict, "export-not-ready")
+	case exportFailed:
+		return sendError(http.StatusConflict, "export-failed")
+	case exportExpired:
+		return sendError(http.StatusGone, "export-expired")
+	}
+
+	file, err := os.Open(filepath.Join(s.ExportFolder, fileName))
+	if err != nil {
+		log.Println(err)
+		return sendError(http.StatusInternalServerError, err.Error())
+	}
+	defer file.Close()
+
+	err = sendInfo(&pb.DataExportFile{
+		Status:   int32(http.StatusOK),
+		Error:    "",
+		FileName: fmt.Sprintf("keuanganku-export-%s.zip", time.Unix(int64(export.CompletedAt), 0).Format("2006-01-02")),
+		Size:     export.Size,
+	})
+	if err != nil {
+		return err
+	}
+
+	buffer := make([]byte, exportChunkSize)
+	for {
+		n, err := file.Read(buffer)
+		if n > 0 {
+			err := stream.Send(&pb.DownloadDataExportResponse{
+				Data: &pb.DownloadDataExportResponse_ChunkData{ChunkData: buffer[:n]},
+			})
+			if err != nil {
+				log.Println("Cannot send chunk data: ", err)
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+}
+
+// getDataExport returns an export of a user with the name of its file, a done
+// export past its expiry is reported as expired.
+func (s *Server) getDataExport(ctx context.Context, userId int32, id int32) (*pb.DataExport, string, error) {
+	q := `
+		SELECT id, status, size, created_at, completed_at, expires_at, file_name
+		FROM data_exports
+		WHERE id = $1 AND user_id = $2
+	`
+
+	var export pb.DataExport
+	var createdAt time.Time
+	var completedAt, expiresAt sql.NullTime
+	var fileName string
+
+	row := s.DB.QueryRowContext(ctx, q, id, userId)
+	err := row.Scan(&export.Id, &export.Status, &export.Size, &createdAt, &completedAt, &expiresAt, &fileName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	export.CreatedAt = int32(createdAt.Unix())
+	if completedAt.Valid {
+		export.CompletedAt = int32(completedAt.Time.Unix())
+	}
+	if expiresAt.Valid {
+		export.ExpiresAt = int32(expiresAt.Time.Unix())
+		if export.Status == exportDone && expiresAt.Time.Before(time.Now()) {
+			export.Status = exportExpired
+		}
+	}
+
+	return &export, fileName, nil
+}
+
+// runDataExport builds the zip of an export and records the outcome, it runs
+// in the background after the export was requested.
+func (s *Server) runDataExport(id int32, userId int32) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	fileName, size, err := s.writeDataExportFile(ctx, userId)
+	if err != nil {
+		log.Printf("data export %d failed: %v", id, err)
+
+		q := `UPDATE data_exports SET status = $2, completed_at = now() WHERE id = $1`
+		if _, err = s.DB.ExecContext(ctx, q, id, exportFailed); err != nil {
+			log.Println(err)
+		}
+		return
+	}
+
+	q := `
+		UPDATE data_exports
+		SET status = $2, file_name = $3, size = $4, completed_at = now(), expires_at = $5
+		WHERE id = $1
+	`
+	res, err := s.DB.ExecContext(ctx, q, id, exportDone, fileName, size, time.Now().Add(s.ExportTTL))
+	if err != nil {
+		log.Println(err)
+		s.removeExportFiles([]string{fileName})
+		return
+	}
+
+	// the account was deleted in the meantime
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		s.removeExportFiles([]string{fileName})
+	}
+}
+
+func (s *Server) writeDataExportFile(ctx context.Context, userId int32) (string, int64, error) {
+	if err := os.MkdirAll(s.ExportFolder, 0o700); err != nil {
+		return "", 0, err
+	}
+
+	fileName := fmt.Sprintf("%d-%s.zip", userId, uuid.NewString())
+	filePath := filepath.Join(s.ExportFolder, fileName)
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+
+	err = s.writeDataExport(ctx, file, userId)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return fileName, info.Size(), nil
+}
+
+// writeDataExport writes the zip of everything stored about a user: a JSON
+// and a CSV file per table and the uploaded photo in images/.
+func (s *Server) writeDataExport(ctx context.Context, w io.Writer, userId int32) error {
+	zw := zip.NewWriter(w)
+
+	for _, table := range dataExportTables {
+		if err := s.exportTable(ctx, zw, table.name, table.query, userId); err != nil {
+			return fmt.Errorf("export %s: %w", table.name, err)
+		}
+	}
+
+	var photo string
+	q := `SELECT COALESCE(photo, '') FROM users WHERE id = $1`
+	if err := s.DB.QueryRowContext(ctx, q, userId).Scan(&photo); err != nil {
+		return err
+	}
+
+	if photo != "" {
+		image, err := s.ImageStore.Get(photo)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			// e.g. removed from the store by hand
+			log.Printf("data export of user %d: photo %s not in the image store", userId, photo)
+		case err != nil:
+			return err
+		default:
+			defer image.Close()
+
+			entry, err := zw.Create("images/" + photo)
+			if err != nil {
+				return err
+			}
+			if _, err = io.Copy(entry, image); err != nil {
+				return err
+			}
+		}
+	}
+
+	return zw.Close()
+}
+
+// exportTable writes the rows of a query as <name>.json, an array of objects
+// keyed by column, and <name>.csv with a header row.
+func (s *Server) exportTable(ctx context.Context, zw *zip.Writer, name string, query string, userId int32) error {
+	rows, err := s.DB.QueryContext(ctx, query, userId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var csvData bytes.Buffer
+	csvWriter := csv.NewWriter(&csvData)
+	if err = csvWriter.Write(columns); err != nil {
+		return err
+	}
+
+	records := []map[string]interface{}{}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		line := make([]string, len(columns))
+		for i, column := range columns {
+			value := values[i]
+			switch v := value.(type) {
+			case []byte:
+				value = string(v)
+			case time.Time:
+				value = v.Format(time.RFC3339)
+			}
+
+			record[column] = value
+			if value != nil {
+				line[i] = fmt.Sprint(value)
+			}
+		}
+
+		records = append(records, record)
+		if err = csvWriter.Write(line); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	if err = csvWriter.Error(); err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	entry, err := zw.Create(name + ".json")
+	if err != nil {
+		return err
+	}
+	if _, err = entry.Write(jsonData); err != nil {
+		return err
+	}
+
+	entry, err = zw.Create(name + ".csv")
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(csvData.Bytes())
+
+	return err
+}
+
+// CleanupDataExports removes the files of the exports past their expiry and
+// marks them expired, otherwise they'd stay until the user asks for a new one.
+func (s *Server) CleanupDataExports(ctx context.Context) error {
+	// SKIP LOCKED lets several instances clean up at once
+	q := `
+		WITH expired AS (
+			SELECT id, file_name FROM data_exports
+			WHERE status = $1 AND expires_at < now()
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE data_exports d SET status = $2, file_name = ''
+		FROM expired e
+		WHERE d.id = e.id
+		RETURNING e.file_name
+	`
+
+	rows, err := s.DB.QueryContext(ctx, q, exportDone, exportExpired)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var fileNames []string
+	for rows.Next() {
+		var fileName string
+		if err := rows.Scan(&fileName); err != nil {
+			return err
+		}
+		fileNames = append(fileNames, fileName)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.removeExportFiles(fileNames)
+
+	return nil
+}
+
+// RunExportCleanup cleans up the expired exports until ctx is done.
+func (s *Server) RunExportCleanup(ctx context.Context) {
+	ticker := time.NewTicker(ExportCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CleanupDataExports(ctx); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+func (s *Server) removeExportFiles(fileNames []string) {
+	for _, fileName := range fileNames {
+		err := os.Remove(filepath.Join(s.ExportFolder, fileName))
+		if err != nil && !os.IsNotExist(err) {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maslow123/users/pkg/config"
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+// downloadDataExport returns the info message of a download and the file.
+func downloadDataExport(t *testing.T, ctx context.Context, client pb.UserServiceClient, req *pb.DownloadDataExportRequest) (*pb.DataExportFile, []byte) {
+	stream, err := client.DownloadDataExport(ctx, req)
+	require.NoError(t, err)
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	info := res.GetInfo()
+	require.NotNil(t, info)
+
+	var data bytes.Buffer
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data.Write(res.GetChunkData())
+	}
+
+	return info, data.Bytes()
+}
+
+func TestDataExport(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	requested, err := client.RequestDataExport(ctx, &pb.RequestDataExportRequest{UserId: login.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusAccepted), requested.Status)
+	require.Equal(t, exportPending, requested.Export.Status)
+
+	// the zip is built in the background
+	var export *pb.DataExport
+	require.Eventually(t, func() bool {
+		response, err := client.GetDataExport(ctx, &pb.GetDataExportRequest{UserId: login.User.Id, Id: requested.Export.Id})
+		if err != nil || response.Status != http.StatusOK {
+			return false
+		}
+
+		export = response.Export
+		return export.Status != exportPending
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, exportDone, export.Status)
+	require.NotZero(t, export.ExpiresAt)
+
+	info, data := downloadDataExport(t, ctx, client, &pb.DownloadDataExportRequest{UserId: login.User.Id, Id: export.Id})
+	require.Equal(t, int32(http.StatusOK), info.Status)
+	require.EqualValues(t, export.Size, len(data))
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	for _, table := range dataExportTables {
+		require.Contains(t, files, table.name+".json")
+		require.Contains(t, files, table.name+".csv")
+	}
+
+	file, err := files["profile.json"].Open()
+	require.NoError(t, err)
+	defer file.Close()
+
+	var profile []map[string]interface{}
+	err = json.NewDecoder(file).Decode(&profile)
+	require.NoError(t, err)
+	require.Len(t, profile, 1)
+	require.Equal(t, login.User.Email, profile[0]["email"])
+	require.NotContains(t, profile[0], "password")
+
+	// the export belongs to the user only
+	other, err := client.GetDataExport(ctx, &pb.GetDataExportRequest{UserId: 1, Id: export.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusNotFound), other.Status)
+
+	info, data = downloadDataExport(t, ctx, client, &pb.DownloadDataExportRequest{UserId: 1, Id: export.Id})
+	require.Equal(t, int32(http.StatusNotFound), info.Status)
+	require.Equal(t, "export-not-found", info.Error)
+	require.Empty(t, data)
+}
+
+func TestGetDataExport(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+
+	testCases := []struct {
+		name string
+		req  *pb.GetDataExportRequest
+		resp *pb.DataExportResponse
+	}{
+		{
+			"Invalid User ID",
+			&pb.GetDataExportRequest{UserId: 0, Id: 1},
+			&pb.DataExportResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Invalid Export ID",
+			&pb.GetDataExportRequest{UserId: 1, Id: 0},
+			&pb.DataExportResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-export-id",
+			},
+		},
+		{
+			"Export not found",
+			&pb.GetDataExportRequest{UserId: 1, Id: 2147483647},
+			&pb.DataExportResponse{
+				Status: http.StatusNotFound,
+				Error:  "export-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.GetDataExport(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+}
+
+func TestCleanupDataExports(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	s := Server{DB: db, ExportFolder: t.TempDir()}
+
+	// one export past its expiry and one that can still be downloaded
+	exports := map[string]time.Duration{"expired.zip": -time.Minute, "valid.zip": time.Hour}
+	ids := map[string]int32{}
+	for fileName, expiresIn := range exports {
+		require.NoError(t, os.WriteFile(filepath.Join(s.ExportFolder, fileName), []byte("zip"), 0o600))
+
+		var id int32
+		q := `
+			INSERT INTO data_exports (user_id, status, file_name, completed_at, expires_at)
+			VALUES ($1, 'done', $2, now(), $3)
+			RETURNING id
+		`
+		require.NoError(t, db.QueryRow(q, login.User.Id, fileName, time.Now().Add(expiresIn)).Scan(&id))
+		ids[fileName] = id
+	}
+
+	require.NoError(t, s.CleanupDataExports(ctx))
+
+	require.NoFileExists(t, filepath.Join(s.ExportFolder, "expired.zip"))
+	require.FileExists(t, filepath.Join(s.ExportFolder, "valid.zip"))
+
+	expired, err := client.GetDataExport(ctx, &pb.GetDataExportRequest{UserId: login.User.Id, Id: ids["expired.zip"]})
+	require.NoError(t, err)
+	require.Equal(t, "expired", expired.Export.Status)
+
+	valid, err := client.GetDataExport(ctx, &pb.GetDataExportRequest{UserId: login.User.Id, Id: ids["valid.zip"]})
+	require.NoError(t, err)
+	require.Equal(t, "done", valid.Export.Status)
+}
@@ -0,0 +1,669 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+const (
+	householdOwner  = "owner"
+	householdMember = "member"
+)
+
+// householdRole returns the role of a user in a household, sql.ErrNoRows
+// means they aren't a member.
+func householdRole(ctx context.Context, db queryRower, householdId int32, userId int32) (string, error) {
+	q := `SELECT role FROM household_members WHERE household_id = $1 AND user_id = $2`
+
+	var role string
+	err := db.QueryRowContext(ctx, q, householdId, userId).Scan(&role)
+
+	return role, err
+}
+
+// getHousehold returns a household with its members as seen by one of them,
+// sql.ErrNoRows means it doesn't exist or the user isn't a member.
+func (s *Server) getHousehold(ctx context.Context, householdId int32, userId int32) (*pb.Household, error) {
+	q := `
+		SELECT h.id, h.name, h.owner_id, m.role, h.created_at
+		FROM households h
+		JOIN household_members m ON m.household_id = h.id
+		WHERE h.id = $1 AND m.user_id = $2
+	`
+
+	var household pb.Household
+	var createdAt time.Time
+
+	row := s.DB.QueryRowContext(ctx, q, householdId, userId)
+	err := row.Scan(&household.Id, &household.Name, &household.OwnerId, &household.Role, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	household.CreatedAt = int32(createdAt.Unix())
+
+	q = `
+		SELECT u.id, u.name, u.email, m.role, m.joined_at
+		FROM household_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.household_id = $1
+		ORDER BY m.joined_at, u.id
+	`
+
+	rows, err := s.DB.QueryContext(ctx, q, householdId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member pb.HouseholdMember
+		var joinedAt time.Time
+		if err := rows.Scan(&member.UserId, &member.Name, &member.Email, &member.Role, &joinedAt); err != nil {
+			return nil, err
+		}
+
+		member.JoinedAt = int32(joinedAt.Unix())
+		household.Members = append(household.Members, &member)
+	}
+
+	return &household, rows.Err()
+}
+
+// CreateHousehold creates a household owned by the user, with empty transfer
+// and cash balances.
+func (s *Server) CreateHousehold(ctx context.Context, req *pb.CreateHouseholdRequest) (*pb.HouseholdResponse, error) {
+	if req.UserId == 0 {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Name == "" || len(req.Name) > 100 {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-name")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `
+		INSERT INTO households (name, owner_id)
+		SELECT $1, id FROM users WHERE id = $2
+		RETURNING id
+	`
+
+	var householdId int32
+	err = tx.QueryRowContext(ctx, q, req.Name, req.UserId).Scan(&householdId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericHouseholdResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `INSERT INTO household_members (household_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err = tx.ExecContext(ctx, q, householdId, req.UserId, householdOwner); err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `INSERT INTO balance (user_id, household_id, type, total) VALUES ($1, $2, 0, 0), ($1, $2, 1, 0)`
+	if _, err = tx.ExecContext(ctx, q, req.UserId, householdId); err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	household, err := s.getHousehold(ctx, householdId, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.HouseholdResponse{
+		Status:    http.StatusCreated,
+		Error:     "",
+		Household: household,
+	}
+
+	return resp, nil
+}
+
+// GetHouseholds lists the households the user is a member of, without their
+// members.
+func (s *Server) GetHouseholds(ctx context.Context, req *pb.GetHouseholdsRequest) (*pb.GetHouseholdsResponse, error) {
+	if req.UserId == 0 {
+		return genericGetHouseholdsResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	q := `
+		SELECT h.id, h.name, h.owner_id, m.role, h.created_at
+		FROM households h
+		JOIN household_members m ON m.household_id = h.id
+		WHERE m.user_id = $1
+		ORDER BY h.name, h.id
+	`
+
+	rows, err := s.DB.QueryContext(ctx, q, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericGetHouseholdsResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer rows.Close()
+
+	households := []*pb.Household{}
+	for rows.Next() {
+		var household pb.Household
+		var createdAt time.Time
+		if err := rows.Scan(&household.Id, &household.Name, &household.OwnerId, &household.Role, &createdAt); err != nil {
+			log.Println(err)
+			return genericGetHouseholdsResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		household.CreatedAt = int32(createdAt.Unix())
+		households = append(households, &household)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return genericGetHouseholdsResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.GetHouseholdsResponse{
+		Status:     http.StatusOK,
+		Error:      "",
+		Households: households,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetHousehold(ctx context.Context, req *pb.GetHouseholdRequest) (*pb.HouseholdResponse, error) {
+	if req.UserId == 0 {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Id == 0 {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-household-id")
+	}
+
+	household, err := s.getHousehold(ctx, req.Id, req.UserId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericHouseholdResponse(http.StatusNotFound, "household-not-found")
+		}
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.HouseholdResponse{
+		Status:    http.StatusOK,
+		Error:     "",
+		Household: household,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) DeleteHousehold(ctx context.Context, req *pb.DeleteHouseholdRequest) (*pb.DeleteHouseholdResponse, error) {
+	if req.UserId == 0 {
+		return genericDeleteHouseholdResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Id == 0 {
+		return genericDeleteHouseholdResponse(http.StatusBadRequest, "invalid-household-id")
+	}
+
+	role, err := householdRole(ctx, s.DB, req.Id, req.UserId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericDeleteHouseholdResponse(http.StatusNotFound, "household-not-found")
+		}
+		return genericDeleteHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+	if role != householdOwner {
+		return genericDeleteHouseholdResponse(http.StatusForbidden, "not-household-owner")
+	}
+
+	// the shared pos, balances and transactions are deleted with it
+	q := `DELETE FROM households WHERE id = $1`
+	if _, err = s.DB.ExecContext(ctx, q, req.Id); err != nil {
+		log.Println(err)
+		return genericDeleteHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericDeleteHouseholdResponse(http.StatusOK, "")
+}
+
+// InviteHouseholdMember mails a link to join a household to an address,
+// earlier pending invitations of the address stop working.
+func (s *Server) InviteHouseholdMember(ctx context.Context, req *pb.InviteHouseholdMemberRequest) (*pb.InviteHouseholdMemberResponse, error) {
+	if req.UserId == 0 {
+		return genericInviteHouseholdMemberResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.HouseholdId == 0 {
+		return genericInviteHouseholdMemberResponse(http.StatusBadRequest, "invalid-household-id")
+	}
+	email := utils.NormalizeEmail(req.Email)
+	if !utils.ValidEmail(email) {
+		return genericInviteHouseholdMemberResponse(http.StatusBadRequest, "invalid-email")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericInviteHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	role, err := householdRole(ctx, tx, req.HouseholdId, req.UserId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericInviteHouseholdMemberResponse(http.StatusNotFound, "household-not-found")
+		}
+		return genericInviteHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+	if role != householdOwner {
+		return genericInviteHouseholdMemberResponse(http.StatusForbidden, "not-household-owner")
+	}
+
+	q := `
+		SELECT h.name, u.name, EXISTS (
+			SELECT 1 FROM household_members m
+			JOIN users mu ON mu.id = m.user_id
+			WHERE m.household_id = h.id AND mu.email = $3
+		)
+		FROM households h, users u
+		WHERE h.id = $1 AND u.id = $2
+	`
+
+	var householdName, inviterName string
+	var member bool
+
+	row := tx.QueryRowContext(ctx, q, req.HouseholdId, req.UserId, email)
+	if err = row.Scan(&householdName, &inviterName, &member); err != nil {
+		log.Println(err)
+		return genericInviteHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+	if member {
+		return genericInviteHouseholdMemberResponse(http.StatusConflict, "already-a-member")
+	}
+
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		log.Println(err)
+		return genericInviteHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `DELETE FROM household_invitations WHERE household_id = $1 AND email = $2 AND status = 'pending'`
+	if _, err = tx.ExecContext(ctx, q, req.HouseholdId, email); err != nil {
+		log.Println(err)
+		return genericInviteHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `
+		INSERT INTO household_invitations (household_id, email, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	var id int32
+	row = tx.QueryRowContext(ctx, q, req.HouseholdId, email, utils.HashToken(token), req.UserId, time.Now().Add(s.InvitationTTL))
+	if err = row.Scan(&id); err != nil {
+		log.Println(err)
+		return genericInviteHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	err = s.Mailer.Send(Mail{
+		To:      email,
+		Subject: fmt.Sprintf("%s invited you to %s", inviterName, householdName),
+		Body: fmt.Sprintf(
+			"%s invited you to share the wallets of %s. Open the link below to accept or decline, it expires in %d hours.\r\n\r\n%s?token=%s\r\n",
+			inviterName,
+			householdName,
+			int(s.InvitationTTL.Hours()),
+			s.HouseholdInvitationUrl,
+			url.QueryEscape(token),
+		),
+	})
+	if err != nil {
+		log.Println(err)
+		return genericInviteHouseholdMemberResponse(http.StatusInternalServerError, "send-mail-failed")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericInviteHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.InviteHouseholdMemberResponse{
+		Status: http.StatusCreated,
+		Error:  "",
+		Id:     id,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) AcceptHouseholdInvitation(ctx context.Context, req *pb.AcceptHouseholdInvitationRequest) (*pb.HouseholdResponse, error) {
+	if req.UserId == 0 {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Token == "" {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-token")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `
+		SELECT id, household_id, email, expires_at
+		FROM household_invitations
+		WHERE token_hash = $1 AND status = 'pending'
+		FOR UPDATE
+	`
+
+	var id, householdId int32
+	var invitedEmail string
+	var expiresAt time.Time
+
+	row := tx.QueryRowContext(ctx, q, utils.HashToken(req.Token))
+	err = row.Scan(&id, &householdId, &invitedEmail, &expiresAt)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericHouseholdResponse(http.StatusBadRequest, "invalid-token")
+		}
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if expiresAt.Before(time.Now()) {
+		return genericHouseholdResponse(http.StatusBadRequest, "token-expired")
+	}
+
+	q = `SELECT email, email_verified FROM users WHERE id = $1`
+
+	var email string
+	var verified bool
+
+	err = tx.QueryRowContext(ctx, q, req.UserId).Scan(&email, &verified)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericHouseholdResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// the link could have been forwarded, only the invited address can join
+	if email != invitedEmail {
+		return genericHouseholdResponse(http.StatusForbidden, "invitation-email-mismatch")
+	}
+	if !verified {
+		return genericHouseholdResponse(http.StatusForbidden, "email-not-verified")
+	}
+
+	q = `
+		INSERT INTO household_members (household_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (household_id, user_id) DO NOTHING
+	`
+	if _, err = tx.ExecContext(ctx, q, householdId, req.UserId, householdMember); err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `UPDATE household_invitations SET status = 'accepted' WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, id); err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	household, err := s.getHousehold(ctx, householdId, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.HouseholdResponse{
+		Status:    http.StatusOK,
+		Error:     "",
+		Household: household,
+	}
+
+	return resp, nil
+}
+
+// DeclineHouseholdInvitation only needs the mailed token, the invited person
+// doesn't need an account to decline.
+func (s *Server) DeclineHouseholdInvitation(ctx context.Context, req *pb.DeclineHouseholdInvitationRequest) (*pb.DeclineHouseholdInvitationResponse, error) {
+	if req.Token == "" {
+		return genericDeclineHouseholdInvitationResponse(http.StatusBadRequest, "invalid-token")
+	}
+
+	q := `
+		UPDATE household_invitations SET status = 'declined'
+		WHERE token_hash = $1 AND status = 'pending' AND expires_at > now()
+	`
+
+	res, err := s.DB.ExecContext(ctx, q, utils.HashToken(req.Token))
+	if err != nil {
+		log.Println(err)
+		return genericDeclineHouseholdInvitationResponse(http.StatusInternalServerError, err.Error())
+	}
+	count, err := res.RowsAffected()
+	if err == nil && count == 0 {
+		return genericDeclineHouseholdInvitationResponse(http.StatusBadRequest, "invalid-token")
+	}
+
+	return genericDeclineHouseholdInvitationResponse(http.StatusOK, "")
+}
+
+func (s *Server) RemoveHouseholdMember(ctx context.Context, req *pb.RemoveHouseholdMemberRequest) (*pb.RemoveHouseholdMemberResponse, error) {
+	if req.UserId == 0 {
+		return genericRemoveHouseholdMemberResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.HouseholdId == 0 {
+		return genericRemoveHouseholdMemberResponse(http.StatusBadRequest, "invalid-household-id")
+	}
+	if req.MemberId == 0 {
+		return genericRemoveHouseholdMemberResponse(http.StatusBadRequest, "invalid-member-id")
+	}
+
+	role, err := householdRole(ctx, s.DB, req.HouseholdId, req.UserId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericRemoveHouseholdMemberResponse(http.StatusNotFound, "household-not-found")
+		}
+		return genericRemoveHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// members can only leave themselves, the owner transfers the ownership
+	// first or deletes the household
+	if req.MemberId != req.UserId && role != householdOwner {
+		return genericRemoveHouseholdMemberResponse(http.StatusForbidden, "not-household-owner")
+	}
+	if req.MemberId == req.UserId && role == householdOwner {
+		return genericRemoveHouseholdMemberResponse(http.StatusBadRequest, "owner-cannot-leave")
+	}
+
+	// the transactions they created stay with the household
+	q := `DELETE FROM household_members WHERE household_id = $1 AND user_id = $2`
+
+	res, err := s.DB.ExecContext(ctx, q, req.HouseholdId, req.MemberId)
+	if err != nil {
+		log.Println(err)
+		return genericRemoveHouseholdMemberResponse(http.StatusInternalServerError, err.Error())
+	}
+	count, err := res.RowsAffected()
+	if err == nil && count == 0 {
+		return genericRemoveHouseholdMemberResponse(http.StatusNotFound, "member-not-found")
+	}
+
+	return genericRemoveHouseholdMemberResponse(http.StatusOK, "")
+}
+
+// transferOwnership makes a member the owner of a household, the old owner
+// stays as a member.
+func transferOwnership(ctx context.Context, tx *sql.Tx, householdId int32, ownerId int32, memberId int32) error {
+	q := `
+		UPDATE household_members
+		SET role = CASE WHEN user_id = $3 THEN $4 ELSE $5 END
+		WHERE household_id = $1 AND user_id IN ($2, $3)
+	`
+	if _, err := tx.ExecContext(ctx, q, householdId, ownerId, memberId, householdOwner, householdMember); err != nil {
+		return err
+	}
+
+	q = `UPDATE households SET owner_id = $2 WHERE id = $1`
+	_, err := tx.ExecContext(ctx, q, householdId, memberId)
+
+	return err
+}
+
+// handOverHouseholds passes the households a user owns to their longest
+// standing other member, households without one are deleted with their data.
+func handOverHouseholds(ctx context.Context, tx *sql.Tx, userId int32) error {
+	q := `
+		SELECT h.id, COALESCE((
+			SELECT m.user_id FROM household_members m
+			WHERE m.household_id = h.id AND m.user_id <> h.owner_id
+			ORDER BY m.joined_at, m.user_id
+			LIMIT 1
+		), 0)
+		FROM households h
+		WHERE h.owner_id = $1
+		FOR UPDATE OF h
+	`
+
+	rows, err := tx.QueryContext(ctx, q, userId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	next := map[int32]int32{}
+	for rows.Next() {
+		var householdId, memberId int32
+		if err := rows.Scan(&householdId, &memberId); err != nil {
+			return err
+		}
+		next[householdId] = memberId
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for householdId, memberId := range next {
+		if memberId == 0 {
+			q = `DELETE FROM households WHERE id = $1`
+			if _, err := tx.ExecContext(ctx, q, householdId); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := transferOwnership(ctx, tx, householdId, userId, memberId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TransferHouseholdOwnership lets the owner hand a household to another
+// member, after which the old owner can leave it.
+func (s *Server) TransferHouseholdOwnership(ctx context.Context, req *pb.TransferHouseholdOwnershipRequest) (*pb.HouseholdResponse, error) {
+	if req.UserId == 0 {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.HouseholdId == 0 {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-household-id")
+	}
+	if req.MemberId == 0 {
+		return genericHouseholdResponse(http.StatusBadRequest, "invalid-member-id")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	// two transfers of a household wait for each other
+	var householdId int32
+	q := `SELECT id FROM households WHERE id = $1 FOR UPDATE`
+	err = tx.QueryRowContext(ctx, q, req.HouseholdId).Scan(&householdId)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	role, err := householdRole(ctx, tx, req.HouseholdId, req.UserId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericHouseholdResponse(http.StatusNotFound, "household-not-found")
+		}
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+	if role != householdOwner {
+		return genericHouseholdResponse(http.StatusForbidden, "not-household-owner")
+	}
+	if req.MemberId == req.UserId {
+		return genericHouseholdResponse(http.StatusBadRequest, "already-household-owner")
+	}
+
+	_, err = householdRole(ctx, tx, req.HouseholdId, req.MemberId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericHouseholdResponse(http.StatusNotFound, "member-not-found")
+		}
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = transferOwnership(ctx, tx, req.HouseholdId, req.UserId, req.MemberId); err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	household, err := s.getHousehold(ctx, req.HouseholdId, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericHouseholdResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.HouseholdResponse{
+		Status:    http.StatusOK,
+		Error:     "",
+		Household: household,
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+// createHousehold creates a household owned by a new user.
+func createHousehold(t *testing.T, ctx context.Context, client pb.UserServiceClient) (*pb.LoginResponse, *pb.Household) {
+	owner, _ := createUser(t, ctx, client)
+
+	res, err := client.CreateHousehold(ctx, &pb.CreateHouseholdRequest{UserId: owner.User.Id, Name: "Keluarga"})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), res.Status)
+
+	return owner, res.Household
+}
+
+// verifiedUser creates a user and confirms their address with the mailed token.
+func verifiedUser(t *testing.T, ctx context.Context, client pb.UserServiceClient) (*pb.LoginResponse, string) {
+	login, password := createUser(t, ctx, client)
+
+	res, err := client.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: tokenFromMail(t, login.User.Email)})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), res.Status)
+
+	return login, password
+}
+
+// joinHousehold invites a new verified user to a household and accepts it.
+func joinHousehold(t *testing.T, ctx context.Context, client pb.UserServiceClient, ownerId int32, householdId int32) (*pb.LoginResponse, string) {
+	member, password := verifiedUser(t, ctx, client)
+
+	invite, err := client.InviteHouseholdMember(ctx, &pb.InviteHouseholdMemberRequest{
+		UserId:      ownerId,
+		HouseholdId: householdId,
+		Email:       member.User.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), invite.Status)
+
+	accept, err := client.AcceptHouseholdInvitation(ctx, &pb.AcceptHouseholdInvitationRequest{
+		UserId: member.User.Id,
+		Token:  tokenFromMail(t, member.User.Email),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), accept.Status)
+
+	return member, password
+}
+
+func TestCreateHousehold(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.CreateHouseholdRequest
+		resp *pb.HouseholdResponse
+	}{
+		{
+			"OK",
+			&pb.CreateHouseholdRequest{UserId: login.User.Id, Name: "Keluarga"},
+			&pb.HouseholdResponse{
+				Status: http.StatusCreated,
+				Error:  "",
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.CreateHouseholdRequest{UserId: 0, Name: "Keluarga"},
+			&pb.HouseholdResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Invalid Name",
+			&pb.CreateHouseholdRequest{UserId: login.User.Id, Name: ""},
+			&pb.HouseholdResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-name",
+			},
+		},
+		{
+			"User not found",
+			&pb.CreateHouseholdRequest{UserId: 999999, Name: "Keluarga"},
+			&pb.HouseholdResponse{
+				Status: http.StatusNotFound,
+				Error:  "user-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.CreateHousehold(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusCreated {
+				require.Equal(t, tc.req.Name, response.Household.Name)
+				require.Equal(t, login.User.Id, response.Household.OwnerId)
+				require.Equal(t, "owner", response.Household.Role)
+				require.Len(t, response.Household.Members, 1)
+			}
+		})
+	}
+}
+
+func TestHouseholdInvitation(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	owner, household := createHousehold(t, ctx, client)
+	member, _ := verifiedUser(t, ctx, client)
+	other, _ := verifiedUser(t, ctx, client)
+
+	invite, err := client.InviteHouseholdMember(ctx, &pb.InviteHouseholdMemberRequest{
+		UserId:      owner.User.Id,
+		HouseholdId: household.Id,
+		Email:       member.User.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), invite.Status)
+	token := tokenFromMail(t, member.User.Email)
+
+	testCases := []struct {
+		name string
+		req  *pb.AcceptHouseholdInvitationRequest
+		resp *pb.HouseholdResponse
+	}{
+		{
+			"Other Email",
+			&pb.AcceptHouseholdInvitationRequest{UserId: other.User.Id, Token: token},
+			&pb.HouseholdResponse{
+				Status: http.StatusForbidden,
+				Error:  "invitation-email-mismatch",
+			},
+		},
+		{
+			"Invalid Token",
+			&pb.AcceptHouseholdInvitationRequest{UserId: member.User.Id, Token: "invalid"},
+			&pb.HouseholdResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-token",
+			},
+		},
+		{
+			"OK",
+			&pb.AcceptHouseholdInvitationRequest{UserId: member.User.Id, Token: token},
+			&pb.HouseholdResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Used Token",
+			&pb.AcceptHouseholdInvitationRequest{UserId: member.User.Id, Token: token},
+			&pb.HouseholdResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-token",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.AcceptHouseholdInvitation(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusOK {
+				require.Equal(t, household.Id, response.Household.Id)
+				require.Equal(t, "member", response.Household.Role)
+				require.Len(t, response.Household.Members, 2)
+			}
+		})
+	}
+
+	households, err := client.GetHouseholds(ctx, &pb.GetHouseholdsRequest{UserId: member.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), households.Status)
+	require.Len(t, households.Households, 1)
+	require.Equal(t, household.Id, households.Households[0].Id)
+
+	// only the owner invites
+	invite, err = client.InviteHouseholdMember(ctx, &pb.InviteHouseholdMemberRequest{
+		UserId:      member.User.Id,
+		HouseholdId: household.Id,
+		Email:       other.User.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusForbidden), invite.Status)
+	require.Equal(t, "not-household-owner", invite.Error)
+
+	invite, err = client.InviteHouseholdMember(ctx, &pb.InviteHouseholdMemberRequest{
+		UserId:      owner.User.Id,
+		HouseholdId: household.Id,
+		Email:       member.User.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusConflict), invite.Status)
+	require.Equal(t, "already-a-member", invite.Error)
+}
+
+func TestDeclineHouseholdInvitation(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	owner, household := createHousehold(t, ctx, client)
+	invited, _ := createUser(t, ctx, client)
+
+	invite, err := client.InviteHouseholdMember(ctx, &pb.InviteHouseholdMemberRequest{
+		UserId:      owner.User.Id,
+		HouseholdId: household.Id,
+		Email:       invited.User.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), invite.Status)
+	token := tokenFromMail(t, invited.User.Email)
+
+	// the address of the invited user isn't verified yet
+	accept, err := client.AcceptHouseholdInvitation(ctx, &pb.AcceptHouseholdInvitationRequest{UserId: invited.User.Id, Token: token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusForbidden), accept.Status)
+	require.Equal(t, "email-not-verified", accept.Error)
+
+	decline, err := client.DeclineHouseholdInvitation(ctx, &pb.DeclineHouseholdInvitationRequest{Token: token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), decline.Status)
+
+	decline, err = client.DeclineHouseholdInvitation(ctx, &pb.DeclineHouseholdInvitationRequest{Token: token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusBadRequest), decline.Status)
+	require.Equal(t, "invalid-token", decline.Error)
+}
+
+func TestRemoveHouseholdMember(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	owner, household := createHousehold(t, ctx, client)
+	member, _ := joinHousehold(t, ctx, client, owner.User.Id, household.Id)
+	outsider, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.RemoveHouseholdMemberRequest
+		resp *pb.RemoveHouseholdMemberResponse
+	}{
+		{
+			"Not a Member",
+			&pb.RemoveHouseholdMemberRequest{UserId: outsider.User.Id, HouseholdId: household.Id, MemberId: member.User.Id},
+			&pb.RemoveHouseholdMemberResponse{
+				Status: http.StatusNotFound,
+				Error:  "household-not-found",
+			},
+		},
+		{
+			"Member removes Owner",
+			&pb.RemoveHouseholdMemberRequest{UserId: member.User.Id, HouseholdId: household.Id, MemberId: owner.User.Id},
+			&pb.RemoveHouseholdMemberResponse{
+				Status: http.StatusForbidden,
+				Error:  "not-household-owner",
+			},
+		},
+		{
+			"Owner leaves",
+			&pb.RemoveHouseholdMemberRequest{UserId: owner.User.Id, HouseholdId: household.Id, MemberId: owner.User.Id},
+			&pb.RemoveHouseholdMemberResponse{
+				Status: http.StatusBadRequest,
+				Error:  "owner-cannot-leave",
+			},
+		},
+		{
+			"Invalid Member ID",
+			&pb.RemoveHouseholdMemberRequest{UserId: owner.User.Id, HouseholdId: household.Id, MemberId: 0},
+			&pb.RemoveHouseholdMemberResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-member-id",
+			},
+		},
+		{
+			"Member leaves",
+			&pb.RemoveHouseholdMemberRequest{UserId: member.User.Id, HouseholdId: household.Id, MemberId: member.User.Id},
+			&pb.RemoveHouseholdMemberResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Member not found",
+			&pb.RemoveHouseholdMemberRequest{UserId: owner.User.Id, HouseholdId: household.Id, MemberId: member.User.Id},
+			&pb.RemoveHouseholdMemberResponse{
+				Status: http.StatusNotFound,
+				Error:  "member-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.RemoveHouseholdMember(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	get, err := client.GetHousehold(ctx, &pb.GetHouseholdRequest{UserId: member.User.Id, Id: household.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusNotFound), get.Status)
+
+	remove, err := client.DeleteHousehold(ctx, &pb.DeleteHouseholdRequest{UserId: owner.User.Id, Id: household.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), remove.Status)
+
+	get, err = client.GetHousehold(ctx, &pb.GetHouseholdRequest{UserId: owner.User.Id, Id: household.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusNotFound), get.Status)
+}
+
+func TestTransferHouseholdOwnership(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	owner, household := createHousehold(t, ctx, client)
+	member, _ := joinHousehold(t, ctx, client, owner.User.Id, household.Id)
+	outsider, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.TransferHouseholdOwnershipRequest
+		resp *pb.HouseholdResponse
+	}{
+		{
+			"Invalid Member ID",
+			&pb.TransferHouseholdOwnershipRequest{UserId: owner.User.Id, HouseholdId: household.Id, MemberId: 0},
+			&pb.HouseholdResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-member-id",
+			},
+		},
+		{
+			"Not a Member",
+			&pb.TransferHouseholdOwnershipRequest{UserId: outsider.User.Id, HouseholdId: household.Id, MemberId: outsider.User.Id},
+			&pb.HouseholdResponse{
+				Status: http.StatusNotFound,
+				Error:  "household-not-found",
+			},
+		},
+		{
+			"Member transfers",
+			&pb.TransferHouseholdOwnershipRequest{UserId: member.User.Id, HouseholdId: household.Id, MemberId: member.User.Id},
+			&pb.HouseholdResponse{
+				Status: http.StatusForbidden,
+				Error:  "not-household-owner",
+			},
+		},
+		{
+			"Owner to Self",
+			&pb.TransferHouseholdOwnershipRequest{UserId: owner.User.Id, HouseholdId: household.Id, MemberId: owner.User.Id},
+			&pb.HouseholdResponse{
+				Status: http.StatusBadRequest,
+				Error:  "already-household-owner",
+			},
+		},
+		{
+			"Owner to Outsider",
+			&pb.TransferHouseholdOwnershipRequest{UserId: owner.User.Id, HouseholdId: household.Id, MemberId: outsider.User.Id},
+			&pb.HouseholdResponse{
+				Status: http.StatusNotFound,
+				Error:  "member-not-found",
+			},
+		},
+		{
+			"OK",
+			&pb.TransferHouseholdOwnershipRequest{UserId: owner.User.Id, HouseholdId: household.Id, MemberId: member.User.Id},
+			&pb.HouseholdResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.TransferHouseholdOwnership(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusOK {
+				require.Equal(t, member.User.Id, response.Household.OwnerId)
+				require.Equal(t, "member", response.Household.Role)
+			}
+		})
+	}
+
+	// the old owner can leave now
+	leave, err := client.RemoveHouseholdMember(ctx, &pb.RemoveHouseholdMemberRequest{
+		UserId:      owner.User.Id,
+		HouseholdId: household.Id,
+		MemberId:    owner.User.Id,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), leave.Status)
+}
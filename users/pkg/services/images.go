@@ -0,0 +1,225 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+const (
+	avatarChunkSize = 64 * 1024
+	// generatedAvatarSize is the size of the initials avatar of a user
+	// without a photo when no thumbnail size is asked for
+	generatedAvatarSize = 256
+)
+
+// thumbnailSizes are the square sizes in pixels the uploaded photos are
+// resized to, smallest first.
+var thumbnailSizes = []int{64, 256}
+
+// thumbnailFileName is the file name of the thumbnail of a size of an image,
+// e.g. <id>_64.jpg.
+func thumbnailFileName(fileName string, size int) string {
+	imageType := filepath.Ext(fileName)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(fileName, imageType), size, utils.ThumbnailType(imageType))
+}
+
+// deleteImage removes an image and its thumbnails from the store, the image
+// isn't used anymore so failures are only logged.
+func (s *Server) deleteImage(fileName string) {
+	fileNames := []string{fileName}
+	for _, size := range thumbnailSizes {
+		fileNames = append(fileNames, thumbnailFileName(fileName, size))
+	}
+
+	for _, fileName := range fileNames {
+		if err := s.ImageStore.Delete(fileName); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func validAvatarSize(size int32) bool {
+	if size == 0 {
+		return true
+	}
+	for _, thumbnailSize := range thumbnailSizes {
+		if int(size) == thumbnailSize {
+			return true
+		}
+	}
+
+	return false
+}
+
+// etagMatches tells if an etag is in the value of an If-None-Match header.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// openAvatar opens a photo at a size, the thumbnails missing for photos
+// uploaded before they were made are rendered and saved on the way.
+func (s *Server) openAvatar(userID int32, photo string, size int) (io.ReadCloser, error) {
+	if size == 0 {
+		return s.ImageStore.Get(photo)
+	}
+
+	image, err := s.ImageStore.Get(thumbnailFileName(photo, size))
+	if !errors.Is(err, os.ErrNotExist) {
+		return image, err
+	}
+
+	original, err := s.ImageStore.Get(photo)
+	if err != nil {
+		return nil, err
+	}
+	defer original.Close()
+
+	data, err := io.ReadAll(original)
+	if err != nil {
+		return nil, err
+	}
+
+	processed, err := utils.ProcessImage(data, thumbnailSizes)
+	if err != nil {
+		// nothing to resize, the photo itself is better than no photo
+		log.Printf("avatar of user %d: cannot make thumbnails of %s: %v", userID, photo, err)
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	for thumbnailSize, thumbnail := range processed.Thumbnails {
+		if err := s.ImageStore.Save(userID, thumbnailFileName(photo, thumbnailSize), *bytes.NewBuffer(thumbnail)); err != nil {
+			log.Println(err)
+		}
+	}
+
+	return io.NopCloser(bytes.NewReader(processed.Thumbnails[size])), nil
+}
+
+func (s *Server) GetAvatar(req *pb.GetAvatarRequest, stream pb.UserService_GetAvatarServer) error {
+	sendInfo := func(info *pb.AvatarInfo) error {
+		return stream.Send(&pb.GetAvatarResponse{
+			Data: &pb.GetAvatarResponse_Info{Info: info},
+		})
+	}
+	sendError := func(statusCode int, errorMessage string) error {
+		return sendInfo(&pb.AvatarInfo{Status: int32(statusCode), Error: errorMessage})
+	}
+
+	if req.UserId <= 0 {
+		return sendError(http.StatusBadRequest, "invalid-user-id")
+	}
+	if !validAvatarSize(req.Size) {
+		return sendError(http.StatusBadRequest, "invalid-size")
+	}
+
+	var name, photo string
+	q := `SELECT name, COALESCE(photo, '') FROM users WHERE id = $1`
+	err := s.DB.QueryRowContext(stream.Context(), q, req.UserId).Scan(&name, &photo)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return sendError(http.StatusNotFound, "user-not-found")
+		}
+		return sendError(http.StatusInternalServerError, err.Error())
+	}
+
+	info := &pb.AvatarInfo{
+		Status: int32(http.StatusOK),
+		Error:  "",
+	}
+
+	var image io.ReadCloser
+	if photo != "" {
+		// a photo is never changed under its file name, a new upload gets a new one
+		fileName := photo
+		if req.Size > 0 {
+			fileName = thumbnailFileName(photo, int(req.Size))
+		}
+		info.Etag = fmt.Sprintf(`"%s"`, fileName)
+		if etagMatches(req.IfNoneMatch, info.Etag) {
+			info.Status = int32(http.StatusNotModified)
+			return sendInfo(info)
+		}
+
+		image, err = s.openAvatar(req.UserId, photo, int(req.Size))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			log.Printf("avatar of user %d: photo %s not in the image store", req.UserId, photo)
+		case err != nil:
+			log.Println(err)
+			return sendError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	if image == nil {
+		size := int(req.Size)
+		if size == 0 {
+			size = generatedAvatarSize
+		}
+
+		key := fmt.Sprint(req.UserId)
+		sum := sha256.Sum256([]byte(key + "/" + utils.Initials(name)))
+		info.Generated = true
+		info.Etag = fmt.Sprintf(`"initials-%x-%d"`, sum[:8], size)
+		if etagMatches(req.IfNoneMatch, info.Etag) {
+			info.Status = int32(http.StatusNotModified)
+			return sendInfo(info)
+		}
+
+		data, err := utils.GenerateAvatar(name, key, size)
+		if err != nil {
+			log.Println(err)
+			return sendError(http.StatusInternalServerError, err.Error())
+		}
+		image = io.NopCloser(bytes.NewReader(data))
+	}
+	defer image.Close()
+
+	reader := bufio.NewReaderSize(image, avatarChunkSize)
+	head, _ := reader.Peek(512)
+	info.ContentType = http.DetectContentType(head)
+	if err = sendInfo(info); err != nil {
+		return err
+	}
+
+	buffer := make([]byte, avatarChunkSize)
+	for {
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			err := stream.Send(&pb.GetAvatarResponse{
+				Data: &pb.GetAvatarResponse_ChunkData{ChunkData: buffer[:n]},
+			})
+			if err != nil {
+				log.Println("Cannot send chunk data: ", err)
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+}
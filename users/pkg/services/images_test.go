@@ -0,0 +1,176 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadImage uploads a photo for a user.
+func uploadImage(t *testing.T, ctx context.Context, client pb.UserServiceClient, userId int32, data []byte) *pb.UploadImageResponse {
+	stream, err := client.UploadImage(ctx)
+	require.NoError(t, err)
+
+	err = stream.Send(&pb.UploadImageRequest{
+		Data: &pb.UploadImageRequest_Info{
+			Info: &pb.ImageInfo{UserId: userId, ImageType: ".jpg"},
+		},
+	})
+	require.NoError(t, err)
+
+	err = stream.Send(&pb.UploadImageRequest{
+		Data: &pb.UploadImageRequest_ChunkData{ChunkData: data},
+	})
+	require.NoError(t, err)
+
+	res, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+
+	return res
+}
+
+// getAvatar returns the info message of an avatar and the image.
+func getAvatar(t *testing.T, ctx context.Context, client pb.UserServiceClient, req *pb.GetAvatarRequest) (*pb.AvatarInfo, []byte) {
+	stream, err := client.GetAvatar(ctx, req)
+	require.NoError(t, err)
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	info := res.GetInfo()
+	require.NotNil(t, info)
+
+	var data bytes.Buffer
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data.Write(res.GetChunkData())
+	}
+
+	return info, data.Bytes()
+}
+
+func TestGetAvatar(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	// without a photo the avatar shows the initials
+	info, data := getAvatar(t, ctx, client, &pb.GetAvatarRequest{UserId: login.User.Id, Size: 64})
+	require.Equal(t, int32(http.StatusOK), info.Status)
+	require.True(t, info.Generated)
+	require.Equal(t, "image/png", info.ContentType)
+	require.NotEmpty(t, info.Etag)
+
+	generated, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 64, generated.Bounds().Dx())
+
+	notModified, data := getAvatar(t, ctx, client, &pb.GetAvatarRequest{UserId: login.User.Id, Size: 64, IfNoneMatch: info.Etag})
+	require.Equal(t, int32(http.StatusNotModified), notModified.Status)
+	require.Empty(t, data)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	for x := 0; x < 300; x++ {
+		img.Set(x, 100, color.NRGBA{R: 255, A: 255})
+	}
+	var photo bytes.Buffer
+	require.NoError(t, jpeg.Encode(&photo, img, nil))
+	uploaded := uploadImage(t, ctx, client, login.User.Id, photo.Bytes())
+
+	info, data = getAvatar(t, ctx, client, &pb.GetAvatarRequest{UserId: login.User.Id})
+	require.Equal(t, int32(http.StatusOK), info.Status)
+	require.False(t, info.Generated)
+	require.Equal(t, "image/jpeg", info.ContentType)
+	require.Equal(t, `"`+uploaded.Id+`.jpg"`, info.Etag)
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 300, config.Width)
+
+	info, data = getAvatar(t, ctx, client, &pb.GetAvatarRequest{UserId: login.User.Id, Size: 256})
+	require.Equal(t, int32(http.StatusOK), info.Status)
+	require.Equal(t, `"`+uploaded.Id+`_256.jpg"`, info.Etag)
+
+	config, err = jpeg.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 256, config.Width)
+	require.Equal(t, 256, config.Height)
+
+	// the etag of the initials doesn't match the photo
+	info, _ = getAvatar(t, ctx, client, &pb.GetAvatarRequest{UserId: login.User.Id, Size: 64, IfNoneMatch: notModified.Etag})
+	require.Equal(t, int32(http.StatusOK), info.Status)
+}
+
+func TestGetAvatarInvalid(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+
+	testCases := []struct {
+		name string
+		req  *pb.GetAvatarRequest
+		resp *pb.AvatarInfo
+	}{
+		{
+			"Invalid User ID",
+			&pb.GetAvatarRequest{UserId: 0},
+			&pb.AvatarInfo{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Invalid Size",
+			&pb.GetAvatarRequest{UserId: 1, Size: 100},
+			&pb.AvatarInfo{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-size",
+			},
+		},
+		{
+			"User not found",
+			&pb.GetAvatarRequest{UserId: 999999},
+			&pb.AvatarInfo{
+				Status: http.StatusNotFound,
+				Error:  "user-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			info, data := getAvatar(t, ctx, client, tc.req)
+
+			require.Equal(t, tc.resp.Status, info.Status)
+			require.Equal(t, tc.resp.Error, info.Error)
+			require.Empty(t, data)
+		})
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	require.True(t, etagMatches(`"a.jpg"`, `"a.jpg"`))
+	require.True(t, etagMatches(`"b.jpg", W/"a.jpg"`, `"a.jpg"`))
+	require.True(t, etagMatches(`*`, `"a.jpg"`))
+	require.False(t, etagMatches(``, `"a.jpg"`))
+	require.False(t, etagMatches(`"b.jpg"`, `"a.jpg"`))
+}
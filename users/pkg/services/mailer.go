@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+func message(from string, mail Mail) []byte {
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(mail.Body)
+
+	return msg.Bytes()
+}
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(mail Mail) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%s", m.Host, m.Port)
+	if err := smtp.SendMail(addr, auth, m.From, []string{mail.To}, message(m.From, mail)); err != nil {
+		return fmt.Errorf("Cannot send mail: %w", err)
+	}
+
+	return nil
+}
+
+// FileMailer writes every mail as an .eml file, for local development.
+type FileMailer struct {
+	mutex      sync.Mutex
+	mailFolder string
+	from       string
+}
+
+func NewFileMailer(mailFolder string, from string) *FileMailer {
+	return &FileMailer{
+		mailFolder: mailFolder,
+		from:       from,
+	}
+}
+
+func (m *FileMailer) Send(mail Mail) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := os.MkdirAll(m.mailFolder, 0755); err != nil {
+		return fmt.Errorf("Cannot create mail folder: %w", err)
+	}
+
+	mailPath := filepath.Join(m.mailFolder, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+	if err := os.WriteFile(mailPath, message(m.from, mail), 0644); err != nil {
+		return fmt.Errorf("Cannot write mail to file: %w", err)
+	}
+
+	return nil
+}
+
+// MemoryMailer keeps the sent mails, tests read them back with Last.
+type MemoryMailer struct {
+	mutex sync.RWMutex
+	mails []Mail
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(mail Mail) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.mails = append(m.mails, mail)
+	return nil
+}
+
+// Last returns the last mail sent to an address.
+func (m *MemoryMailer) Last(to string) (Mail, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for i := len(m.mails) - 1; i >= 0; i-- {
+		if m.mails[i].To == to {
+			return m.mails[i], true
+		}
+	}
+
+	return Mail{}, false
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	mailFolder := t.TempDir()
+	mailer := NewFileMailer(mailFolder, "Keuanganku <no-reply@keuanganku.test>")
+
+	err := mailer.Send(Mail{
+		To:      "user1@gmail.com",
+		Subject: "Reset your password",
+		Body:    "hello",
+	})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(mailFolder, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(data), "From: Keuanganku <no-reply@keuanganku.test>\r\n"))
+	require.True(t, strings.Contains(string(data), "To: user1@gmail.com\r\n"))
+	require.True(t, strings.Contains(string(data), "Subject: Reset your password\r\n"))
+	require.True(t, strings.HasSuffix(string(data), "\r\n\r\nhello"))
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+
+	_, ok := mailer.Last("user1@gmail.com")
+	require.False(t, ok)
+
+	require.NoError(t, mailer.Send(Mail{To: "user1@gmail.com", Body: "first"}))
+	require.NoError(t, mailer.Send(Mail{To: "user2@gmail.com", Body: "other"}))
+	require.NoError(t, mailer.Send(Mail{To: "user1@gmail.com", Body: "second"}))
+
+	mail, ok := mailer.Last("user1@gmail.com")
+	require.True(t, ok)
+	require.Equal(t, "second", mail.Body)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/maslow123/users/pkg/client"
 	"github.com/maslow123/users/pkg/utils"
@@ -12,7 +13,39 @@ type Server struct {
 	DB             *sql.DB
 	BalanceService client.BalanceServiceClient
 	ImageStore     ImageStore
-	IsTesting      bool
+	// DefaultPosTemplate is the pos_templates code applied to new users, empty disables it
+	DefaultPosTemplate string
+	// RefreshTokenTTL is how long a refresh token can be used, each use rotates it
+	RefreshTokenTTL time.Duration
+	Mailer          Mailer
+	// ResetPasswordUrl is the page of the client the reset token is appended to
+	ResetPasswordUrl string
+	ResetTokenTTL    time.Duration
+	// VerifyEmailUrl is the page of the client the verification token is appended to
+	VerifyEmailUrl string
+	VerifyTokenTTL time.Duration
+	// TOTPIssuer is the name authenticator apps show for the account
+	TOTPIssuer    string
+	LoginThrottle LoginThrottle
+	// Hasher hashes new passwords, hashes of other algorithms or parameters are
+	// replaced on login
+	Hasher         utils.PasswordHasher
+	PasswordPolicy utils.PasswordPolicy
+	// ExportFolder keeps the data export zips until ExportTTL passes
+	ExportFolder string
+	ExportTTL    time.Duration
+	// HouseholdInvitationUrl is the page of the client the invitation token is appended to
+	HouseholdInvitationUrl string
+	InvitationTTL          time.Duration
+	// SigningAlgorithm is what new signing keys are generated for, RS256 or EdDSA
+	SigningAlgorithm string
+	// SigningKeySecret encrypts the private signing keys in the database, only
+	// the users service has it
+	SigningKeySecret    string
+	KeyRotationInterval time.Duration
+	KeyPublishDelay     time.Duration
+	// AdminEmail is made an administrator at startup while there is none yet
+	AdminEmail string
 }
 
 // func NewUserServer(userStore UserStore, imageStore ImageStore) *Server {
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net"
 	"testing"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/maslow123/users/pkg/config"
@@ -18,6 +19,11 @@ import (
 	"github.com/maslow123/users/pkg/client"
 )
 
+// testMailer receives the mails sent by the server under test
+var testMailer = NewMemoryMailer()
+
+const testAvatarBaseUrl = "https://images.keuanganku.local/users/"
+
 func dialer(t *testing.T) func(context.Context, string) (net.Conn, error) {
 	c, err := config.LoadConfig("../config/envs", "test")
 	require.NoError(t, err)
@@ -25,9 +31,9 @@ func dialer(t *testing.T) func(context.Context, string) (net.Conn, error) {
 	listener := bufconn.Listen(1024 * 1024)
 
 	jwt := utils.JwtWrapper{
-		SecretKey:       c.JWTSecretKey,
-		Issuer:          "user-service",
-		ExpirationHours: 24 * 365,
+		Issuer:            "user-service",
+		ExpirationMinutes: 60 * 24 * 365,
+		Keys:              &utils.KeyRing{},
 	}
 
 	db, err := sql.Open("postgres", c.DBUrl)
@@ -36,15 +42,58 @@ func dialer(t *testing.T) func(context.Context, string) (net.Conn, error) {
 	}
 
 	balanceService := client.InitBalanceServiceClient(c.BalanceServiceUrl)
-	imageStore := NewDiskImageStore("../tmp")
+	imageStore := NewDiskImageStore("../tmp", testAvatarBaseUrl)
+	hasher, err := utils.PasswordHasher{
+		Algorithm:     c.PasswordHashAlgorithm,
+		BcryptCost:    c.BcryptCost,
+		Argon2Time:    c.Argon2Time,
+		Argon2Memory:  c.Argon2MemoryKB,
+		Argon2Threads: c.Argon2Threads,
+	}.WithDefaults()
+	require.NoError(t, err)
+
 	s := Server{
-		DB:             db,
-		Jwt:            jwt,
-		BalanceService: balanceService,
-		ImageStore:     imageStore,
-		IsTesting:      true,
+		DB:                 db,
+		Jwt:                jwt,
+		BalanceService:     balanceService,
+		ImageStore:         imageStore,
+		DefaultPosTemplate: c.DefaultPosTemplate,
+		RefreshTokenTTL:    time.Hour * time.Duration(c.RefreshTokenHours),
+		Mailer:             testMailer,
+		ResetPasswordUrl:   c.ResetPasswordUrl,
+		ResetTokenTTL:      time.Minute * time.Duration(c.ResetTokenMinutes),
+		VerifyEmailUrl:     c.VerifyEmailUrl,
+		VerifyTokenTTL:     time.Hour * time.Duration(c.VerifyTokenHours),
+		TOTPIssuer:         c.TOTPIssuer,
+		LoginThrottle: LoginThrottle{
+			MaxAttempts:      c.LoginMaxAttempts,
+			MaxAttemptsPerIp: c.LoginMaxAttemptsPerIp,
+			Lockout:          time.Second * time.Duration(c.LoginLockoutSeconds),
+			MaxLockout:       time.Second * time.Duration(c.LoginMaxLockoutSeconds),
+			Window:           time.Minute * time.Duration(c.LoginWindowMinutes),
+		},
+		Hasher: hasher,
+		PasswordPolicy: utils.PasswordPolicy{
+			MinLength: c.PasswordMinLength,
+			MaxLength: c.PasswordMaxLength,
+		},
+		ExportFolder:           t.TempDir(),
+		ExportTTL:              time.Hour * time.Duration(c.ExportHours),
+		HouseholdInvitationUrl: c.HouseholdInvitationUrl,
+		InvitationTTL:          time.Hour * time.Duration(c.InvitationHours),
+		SigningAlgorithm:       c.JWTSigningAlgorithm,
+		SigningKeySecret:       c.JWTSigningKeySecret,
+		KeyRotationInterval:    time.Hour * time.Duration(c.JWTKeyRotationHours),
+		KeyPublishDelay:        time.Minute * time.Duration(c.JWTKeyPublishMinutes),
+		AdminEmail:             c.AdminEmail,
 	}
 
+	err = s.BootstrapAdmin(context.Background())
+	require.NoError(t, err)
+
+	err = s.RotateSigningKeys(context.Background())
+	require.NoError(t, err)
+
 	server := grpc.NewServer()
 	pb.RegisterUserServiceServer(server, &s)
 	go func() {
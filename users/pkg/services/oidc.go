@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+// createOidcUser registers the user of an identity seen for the first time,
+// the provider verified their address. They get a random password nobody
+// knows, ForgotPassword sets a real one.
+func (s *Server) createOidcUser(ctx context.Context, tx *sql.Tx, req *pb.OidcLoginRequest) (int32, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = strings.Split(req.Email, "@")[0]
+	}
+
+	password, err := utils.NewOpaqueToken()
+	if err != nil {
+		return 0, err
+	}
+	hashedPassword, err := s.Hasher.Hash(password)
+	if err != nil {
+		return 0, err
+	}
+
+	q := `
+		INSERT INTO users (name, email, password, email_verified)
+		VALUES ($1, $2, $3, true)
+		RETURNING id
+	`
+
+	var userId int32
+	row := tx.QueryRowContext(ctx, q, truncate(name, 100), req.Email, hashedPassword)
+	if err = row.Scan(&userId); err != nil {
+		return 0, err
+	}
+
+	if err = s.createUserDefaults(ctx, tx, userId); err != nil {
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+// OidcLogin finds or links the user of an OpenID Connect identity and logs them
+// in like Login, including the second factor when the user enabled it.
+func (s *Server) OidcLogin(ctx context.Context, req *pb.OidcLoginRequest) (*pb.LoginResponse, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
+
+	if req.Issuer == "" || req.Subject == "" {
+		return genericLoginResponse(http.StatusBadRequest, "invalid-identity")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	var userId int32
+	q := `
+		UPDATE user_identities SET last_login_at = now()
+		WHERE issuer = $1 AND subject = $2
+		RETURNING user_id
+	`
+
+	err = tx.QueryRowContext(ctx, q, req.Issuer, req.Subject).Scan(&userId)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err == sql.ErrNoRows {
+		if !utils.ValidEmail(req.Email) {
+			return genericLoginResponse(http.StatusBadRequest, "invalid-email")
+		}
+		// an address the provider doesn't vouch for could belong to anyone,
+		// it neither creates an account nor links to one
+		if !req.EmailVerified {
+			return genericLoginResponse(http.StatusForbidden, "email-not-verified")
+		}
+
+		var emailVerified bool
+		q = `SELECT id, email_verified FROM users WHERE email = $1`
+
+		err = tx.QueryRowContext(ctx, q, req.Email).Scan(&userId, &emailVerified)
+		switch {
+		case err == sql.ErrNoRows:
+			userId, err = s.createOidcUser(ctx, tx, req)
+			if err != nil {
+				log.Println(err)
+				return genericLoginResponse(http.StatusInternalServerError, err.Error())
+			}
+		case err != nil:
+			log.Println(err)
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		case !emailVerified:
+			// linking needs both sides to vouch for the address, otherwise
+			// whoever claimed it first could take over the other account
+			return genericLoginResponse(http.StatusConflict, "email-already-exists")
+		}
+
+		q = `
+			INSERT INTO user_identities (user_id, issuer, subject, email)
+			VALUES ($1, $2, $3, $4)
+		`
+		if _, err = tx.ExecContext(ctx, q, userId, req.Issuer, req.Subject, req.Email); err != nil {
+			log.Println(err)
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	var user pb.User
+	var tokenVersion int32
+	var totpEnabled, disabled bool
+	q = `
+		SELECT
+			id, name, email, COALESCE(photo, ''), token_version, email_verified,
+			COALESCE(pending_email, ''), totp_enabled, role, disabled_at IS NOT NULL
+		FROM users
+		WHERE id = $1
+	`
+
+	row := tx.QueryRowContext(ctx, q, userId)
+	err = row.Scan(
+		&user.Id,
+		&user.Name,
+		&user.Email,
+		&user.Photo,
+		&tokenVersion,
+		&user.EmailVerified,
+		&user.PendingEmail,
+		&totpEnabled,
+		&user.Role,
+		&disabled,
+	)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if disabled {
+		return genericLoginResponse(http.StatusForbidden, "account-disabled")
+	}
+
+	device := &pb.LoginRequest{
+		Email:      user.Email,
+		DeviceName: req.DeviceName,
+		UserAgent:  req.UserAgent,
+		Ip:         req.Ip,
+	}
+
+	if totpEnabled {
+		// the identity stays linked even though the login isn't finished
+		if err = tx.Commit(); err != nil {
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+		return s.createTwoFactorChallenge(ctx, user.Id, device)
+	}
+
+	token, refreshToken, err := s.startSession(ctx, tx, user.Id, tokenVersion, user.Role, device)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.LoginResponse{
+		Status:       http.StatusOK,
+		Error:        "",
+		User:         &user,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
+
+	return resp, nil
+}
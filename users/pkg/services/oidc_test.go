@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+const testIssuer = "https://accounts.keuanganku.local"
+
+func TestOidcLogin(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	verified, _ := verifiedUser(t, ctx, client)
+	unverified, _ := createUser(t, ctx, client)
+	newEmail := utils.RandomEmail()
+
+	testCases := []struct {
+		name   string
+		req    *pb.OidcLoginRequest
+		resp   *pb.LoginResponse
+		userId int32
+	}{
+		{
+			"New User",
+			&pb.OidcLoginRequest{Issuer: testIssuer, Subject: utils.RandomString(20), Email: newEmail, EmailVerified: true, Name: "Budi"},
+			&pb.LoginResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+			0,
+		},
+		{
+			"Link Verified Email",
+			&pb.OidcLoginRequest{Issuer: testIssuer, Subject: utils.RandomString(20), Email: verified.User.Email, EmailVerified: true},
+			&pb.LoginResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+			verified.User.Id,
+		},
+		{
+			"Provider didn't verify the Email",
+			&pb.OidcLoginRequest{Issuer: testIssuer, Subject: utils.RandomString(20), Email: verified.User.Email, EmailVerified: false},
+			&pb.LoginResponse{
+				Status: http.StatusForbidden,
+				Error:  "email-not-verified",
+			},
+			0,
+		},
+		{
+			"Provider didn't verify a new Email",
+			&pb.OidcLoginRequest{Issuer: testIssuer, Subject: utils.RandomString(20), Email: utils.RandomEmail(), EmailVerified: false},
+			&pb.LoginResponse{
+				Status: http.StatusForbidden,
+				Error:  "email-not-verified",
+			},
+			0,
+		},
+		{
+			"Local Email not verified",
+			&pb.OidcLoginRequest{Issuer: testIssuer, Subject: utils.RandomString(20), Email: unverified.User.Email, EmailVerified: true},
+			&pb.LoginResponse{
+				Status: http.StatusConflict,
+				Error:  "email-already-exists",
+			},
+			0,
+		},
+		{
+			"Invalid Identity",
+			&pb.OidcLoginRequest{Issuer: testIssuer, Subject: "", Email: newEmail, EmailVerified: true},
+			&pb.LoginResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-identity",
+			},
+			0,
+		},
+		{
+			"Invalid Email",
+			&pb.OidcLoginRequest{Issuer: testIssuer, Subject: utils.RandomString(20), Email: "", EmailVerified: true},
+			&pb.LoginResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-email",
+			},
+			0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.OidcLogin(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusOK {
+				require.NotEmpty(t, response.Token)
+				require.NotEmpty(t, response.RefreshToken)
+				require.Equal(t, tc.req.Email, response.User.Email)
+				require.True(t, response.User.EmailVerified)
+				if tc.userId != 0 {
+					require.Equal(t, tc.userId, response.User.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestOidcLoginLinkedIdentity(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	req := &pb.OidcLoginRequest{
+		Issuer:        testIssuer,
+		Subject:       utils.RandomString(20),
+		Email:         utils.RandomEmail(),
+		EmailVerified: true,
+		Name:          "Budi",
+	}
+
+	first, err := client.OidcLogin(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), first.Status)
+	require.True(t, first.User.EmailVerified)
+
+	// the identity is known now, the address it reports no longer matters
+	req.Email = utils.RandomEmail()
+	req.EmailVerified = false
+	second, err := client.OidcLogin(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), second.Status)
+	require.Equal(t, first.User.Id, second.User.Id)
+
+	// the same subject at another provider is another account
+	req.Issuer = "https://login.example.com"
+	req.EmailVerified = true
+	third, err := client.OidcLogin(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), third.Status)
+	require.NotEqual(t, first.User.Id, third.User.Id)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+const resetPasswordSubject = "Reset your password"
+
+// ForgotPassword answers the same whether the email has an account or not. The
+// link is created and mailed in the background, so neither the response nor
+// how long it takes tells which emails are registered, failures are logged.
+func (s *Server) ForgotPassword(ctx context.Context, req *pb.ForgotPasswordRequest) (*pb.ForgotPasswordResponse, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
+
+	if req.Email == "" {
+		return genericForgotPasswordResponse(http.StatusBadRequest, "invalid-email")
+	}
+
+	go func(email string) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := s.sendPasswordReset(ctx, email); err != nil {
+			log.Println(err)
+		}
+	}(req.Email)
+
+	return genericForgotPasswordResponse(http.StatusOK, "")
+}
+
+// sendPasswordReset mails a reset link to the user with the email, if any.
+func (s *Server) sendPasswordReset(ctx context.Context, email string) error {
+	var userId int32
+	q := `SELECT id FROM users WHERE email = $1 LIMIT 1`
+
+	row := s.DB.QueryRowContext(ctx, q, email)
+	err := row.Scan(&userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// only the last requested link works
+	q = `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`
+	if _, err = tx.ExecContext(ctx, q, userId); err != nil {
+		return err
+	}
+
+	q = `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`
+	_, err = tx.ExecContext(ctx, q, userId, utils.HashToken(token), time.Now().Add(s.ResetTokenTTL))
+	if err != nil {
+		return err
+	}
+
+	// the token is only stored once the mail is sent, a failed send can be retried
+	err = s.Mailer.Send(Mail{
+		To:      email,
+		Subject: resetPasswordSubject,
+		Body: fmt.Sprintf(
+			"Open the link below to choose a new password, it can be used once and expires in %d minutes.\r\n\r\n%s?token=%s\r\n\r\nIgnore this mail if you didn't ask to reset your password.\r\n",
+			int(s.ResetTokenTTL.Minutes()),
+			s.ResetPasswordUrl,
+			url.QueryEscape(token),
+		),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Server) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	if req.Token == "" {
+		return genericResetPasswordResponse(http.StatusBadRequest, "invalid-token")
+	}
+	if req.Password == "" {
+		return genericResetPasswordResponse(http.StatusBadRequest, "invalid-password")
+	}
+	if req.ConfirmPassword == "" {
+		return genericResetPasswordResponse(http.StatusBadRequest, "invalid-confirm-password")
+	}
+	if req.Password != req.ConfirmPassword {
+		return genericResetPasswordResponse(http.StatusBadRequest, "password-does'nt-match-with-confirm-password")
+	}
+	if errMessage := s.PasswordPolicy.Validate(req.Password); errMessage != "" {
+		return genericResetPasswordResponse(http.StatusBadRequest, errMessage)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericResetPasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `
+		SELECT id, user_id, expires_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL
+		FOR UPDATE
+	`
+
+	var id, userId int32
+	var expiresAt time.Time
+
+	row := tx.QueryRowContext(ctx, q, utils.HashToken(req.Token))
+	err = row.Scan(&id, &userId, &expiresAt)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericResetPasswordResponse(http.StatusBadRequest, "invalid-token")
+		}
+		return genericResetPasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if expiresAt.Before(time.Now()) {
+		return genericResetPasswordResponse(http.StatusBadRequest, "token-expired")
+	}
+
+	q = `UPDATE password_reset_tokens SET used_at = now() WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, id); err != nil {
+		log.Println(err)
+		return genericResetPasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	hashedPassword, err := s.Hasher.Hash(req.Password)
+	if err != nil {
+		log.Println(err)
+		return genericResetPasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `UPDATE users SET password = $2 WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, userId, hashedPassword); err != nil {
+		log.Println(err)
+		return genericResetPasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// whoever knew the old password is logged out
+	if err = revokeAllTokens(ctx, tx, userId); err != nil {
+		log.Println(err)
+		return genericResetPasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericResetPasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericResetPasswordResponse(http.StatusOK, "")
+}
+
+// rehashPassword replaces the stored hash of a password with one made with
+// the configured algorithm and parameters.
+func (s *Server) rehashPassword(ctx context.Context, userId int32, password string) error {
+	hashedPassword, err := s.Hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.ExecContext(ctx, `UPDATE users SET password = $2 WHERE id = $1`, userId, hashedPassword)
+
+	return err
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenFromMail reads the token of the link in the last mail sent to email.
+func tokenFromMail(t *testing.T, email string) string {
+	mail, ok := testMailer.Last(email)
+	require.True(t, ok)
+
+	return linkToken(t, mail)
+}
+
+// resetMailSent tells whether a reset link was mailed to email, it is sent in
+// the background so this waits for it a little.
+func resetMailSent(email string) bool {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if mail, ok := testMailer.Last(email); ok && mail.Subject == resetPasswordSubject {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
+
+func linkToken(t *testing.T, mail Mail) string {
+	for _, line := range strings.Split(mail.Body, "\r\n") {
+		if strings.Contains(line, "?token=") {
+			link, err := url.Parse(line)
+			require.NoError(t, err)
+			return link.Query().Get("token")
+		}
+	}
+
+	t.Fatal("link not found")
+	return ""
+}
+
+func TestForgotPassword(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+	unknownEmail := utils.RandomEmail()
+
+	testCases := []struct {
+		name     string
+		req      *pb.ForgotPasswordRequest
+		resp     *pb.ForgotPasswordResponse
+		mailSent bool
+	}{
+		{
+			"OK",
+			&pb.ForgotPasswordRequest{Email: login.User.Email},
+			&pb.ForgotPasswordResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+			true,
+		},
+		{
+			"Unknown Email",
+			&pb.ForgotPasswordRequest{Email: unknownEmail},
+			&pb.ForgotPasswordResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+			false,
+		},
+		{
+			"Invalid Email",
+			&pb.ForgotPasswordRequest{Email: ""},
+			&pb.ForgotPasswordResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-email",
+			},
+			false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ForgotPassword(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+
+			require.Equal(t, tc.mailSent, resetMailSent(tc.req.Email))
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	response, err := client.ForgotPassword(ctx, &pb.ForgotPasswordRequest{Email: login.User.Email})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), response.Status)
+
+	require.True(t, resetMailSent(login.User.Email))
+	token := tokenFromMail(t, login.User.Email)
+	require.NotEmpty(t, token)
+
+	testCases := []struct {
+		name string
+		req  *pb.ResetPasswordRequest
+		resp *pb.ResetPasswordResponse
+	}{
+		{
+			"Confirm Password not match",
+			&pb.ResetPasswordRequest{
+				Token:           token,
+				Password:        "new password",
+				ConfirmPassword: "not match",
+			},
+			&pb.ResetPasswordResponse{
+				Status: http.StatusBadRequest,
+				Error:  "password-does'nt-match-with-confirm-password",
+			},
+		},
+		{
+			"Password too short",
+			&pb.ResetPasswordRequest{
+				Token:           token,
+				Password:        "short",
+				ConfirmPassword: "short",
+			},
+			&pb.ResetPasswordResponse{
+				Status: http.StatusBadRequest,
+				Error:  "password-too-short",
+			},
+		},
+		{
+			"OK",
+			&pb.ResetPasswordRequest{
+				Token:           token,
+				Password:        "new password",
+				ConfirmPassword: "new password",
+			},
+			&pb.ResetPasswordResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Used Token",
+			&pb.ResetPasswordRequest{
+				Token:           token,
+				Password:        "other password",
+				ConfirmPassword: "other password",
+			},
+			&pb.ResetPasswordResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-token",
+			},
+		},
+		{
+			"Invalid Token",
+			&pb.ResetPasswordRequest{
+				Token:           "",
+				Password:        "new password",
+				ConfirmPassword: "new password",
+			},
+			&pb.ResetPasswordResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-token",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ResetPassword(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	relogin, err := client.Login(ctx, &pb.LoginRequest{
+		Email:    login.User.Email,
+		Password: "new password",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), relogin.Status)
+
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: login.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+// defaultPreferences are used until a user saves their own, the transactions
+// service falls back to the same ones.
+func defaultPreferences() *pb.Preferences {
+	return &pb.Preferences{
+		Currency:           "IDR",
+		Locale:             "id-ID",
+		Timezone:           "Asia/Jakarta",
+		WeekStart:          int32(time.Monday),
+		DefaultBalanceType: 0,
+	}
+}
+
+// validatePreferences returns the error code of the first invalid preference,
+// or an empty string.
+func validatePreferences(p *pb.Preferences) string {
+	if !utils.SupportedCurrencies[p.Currency] {
+		return "invalid-currency"
+	}
+	if !utils.SupportedLocales[p.Locale] {
+		return "invalid-locale"
+	}
+	// an empty name and "Local" load the location of the server
+	if p.Timezone == "" || p.Timezone == "Local" {
+		return "invalid-timezone"
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return "invalid-timezone"
+	}
+	if p.WeekStart < int32(time.Sunday) || p.WeekStart > int32(time.Saturday) {
+		return "invalid-week-start"
+	}
+	if p.DefaultBalanceType != 0 && p.DefaultBalanceType != 1 {
+		return "invalid-default-balance-type"
+	}
+
+	return ""
+}
+
+// getPreferences returns the preferences of a user, sql.ErrNoRows means the
+// user doesn't exist.
+func getPreferences(ctx context.Context, db queryRower, userId int32) (*pb.Preferences, error) {
+	q := `
+		SELECT
+			p.user_id IS NOT NULL,
+			COALESCE(p.currency, ''), COALESCE(p.locale, ''), COALESCE(p.timezone, ''),
+			COALESCE(p.week_start, 0), COALESCE(p.default_balance_type, 0)
+		FROM users u
+		LEFT JOIN user_preferences p ON p.user_id = u.id
+		WHERE u.id = $1
+	`
+
+	var saved bool
+	var preferences pb.Preferences
+
+	row := db.QueryRowContext(ctx, q, userId)
+	err := row.Scan(
+		&saved,
+		&preferences.Currency,
+		&preferences.Locale,
+		&preferences.Timezone,
+		&preferences.WeekStart,
+		&preferences.DefaultBalanceType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return defaultPreferences(), nil
+	}
+
+	return &preferences, nil
+}
+
+func (s *Server) GetPreferences(ctx context.Context, req *pb.GetPreferencesRequest) (*pb.PreferencesResponse, error) {
+	if req.UserId <= 0 {
+		return genericPreferencesResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	preferences, err := getPreferences(ctx, s.DB, req.UserId)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericPreferencesResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericPreferencesResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.PreferencesResponse{
+		Status:      int32(http.StatusOK),
+		Error:       "",
+		Preferences: preferences,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) UpdatePreferences(ctx context.Context, req *pb.UpdatePreferencesRequest) (*pb.PreferencesResponse, error) {
+	if req.UserId <= 0 {
+		return genericPreferencesResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Preferences == nil {
+		return genericPreferencesResponse(http.StatusBadRequest, "invalid-preferences")
+	}
+	if errMessage := validatePreferences(req.Preferences); errMessage != "" {
+		return genericPreferencesResponse(http.StatusBadRequest, errMessage)
+	}
+
+	q := `
+		INSERT INTO user_preferences (user_id, currency, locale, timezone, week_start, default_balance_type)
+		SELECT id, $2, $3, $4, $5, $6 FROM users WHERE id = $1
+		ON CONFLICT (user_id) DO UPDATE SET
+			currency = EXCLUDED.currency,
+			locale = EXCLUDED.locale,
+			timezone = EXCLUDED.timezone,
+			week_start = EXCLUDED.week_start,
+			default_balance_type = EXCLUDED.default_balance_type,
+			updated_at = now()
+	`
+
+	p := req.Preferences
+	res, err := s.DB.ExecContext(ctx, q, req.UserId, p.Currency, p.Locale, p.Timezone, p.WeekStart, p.DefaultBalanceType)
+	if err != nil {
+		log.Println(err)
+		return genericPreferencesResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		log.Println(err)
+		return genericPreferencesResponse(http.StatusInternalServerError, err.Error())
+	}
+	if affected == 0 {
+		return genericPreferencesResponse(http.StatusNotFound, "user-not-found")
+	}
+
+	resp := &pb.PreferencesResponse{
+		Status:      int32(http.StatusOK),
+		Error:       "",
+		Preferences: p,
+	}
+
+	return resp, nil
+}
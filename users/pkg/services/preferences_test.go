@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPreferences(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.GetPreferencesRequest
+		resp *pb.PreferencesResponse
+	}{
+		{
+			"OK",
+			&pb.GetPreferencesRequest{UserId: login.User.Id},
+			&pb.PreferencesResponse{
+				Status:      http.StatusOK,
+				Error:       "",
+				Preferences: defaultPreferences(),
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.GetPreferencesRequest{UserId: 0},
+			&pb.PreferencesResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"User not found",
+			&pb.GetPreferencesRequest{UserId: 999999},
+			&pb.PreferencesResponse{
+				Status: http.StatusNotFound,
+				Error:  "user-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.GetPreferences(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == http.StatusOK {
+				require.Equal(t, tc.resp.Preferences.Currency, response.Preferences.Currency)
+				require.Equal(t, tc.resp.Preferences.Locale, response.Preferences.Locale)
+				require.Equal(t, tc.resp.Preferences.Timezone, response.Preferences.Timezone)
+				require.Equal(t, tc.resp.Preferences.WeekStart, response.Preferences.WeekStart)
+				require.Equal(t, tc.resp.Preferences.DefaultBalanceType, response.Preferences.DefaultBalanceType)
+			}
+		})
+	}
+}
+
+func TestUpdatePreferences(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	preferences := func(edit func(p *pb.Preferences)) *pb.Preferences {
+		p := &pb.Preferences{
+			Currency:           "USD",
+			Locale:             "en-US",
+			Timezone:           "America/New_York",
+			WeekStart:          0,
+			DefaultBalanceType: 1,
+		}
+		if edit != nil {
+			edit(p)
+		}
+		return p
+	}
+
+	testCases := []struct {
+		name string
+		req  *pb.UpdatePreferencesRequest
+		resp *pb.PreferencesResponse
+	}{
+		{
+			"OK",
+			&pb.UpdatePreferencesRequest{UserId: login.User.Id, Preferences: preferences(nil)},
+			&pb.PreferencesResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"OK update again",
+			&pb.UpdatePreferencesRequest{UserId: login.User.Id, Preferences: preferences(func(p *pb.Preferences) {
+				p.Currency = "EUR"
+				p.WeekStart = 1
+			})},
+			&pb.PreferencesResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.UpdatePreferencesRequest{UserId: 0, Preferences: preferences(nil)},
+			&pb.PreferencesResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"Invalid Preferences",
+			&pb.UpdatePreferencesRequest{UserId: login.User.Id},
+			&pb.PreferencesResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-preferences",
+			},
+		},
+		{
+			"Invalid Currency",
+			&pb.UpdatePreferencesRequest{UserId: login.User.Id, Preferences: preferences(func(p *pb.Preferences) {
+				p.Currency = "XXX"
+			})},
+			&pb.PreferencesResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-currency",
+			},
+		},
+		{
+			"Invalid Locale",
+			&pb.UpdatePreferencesRequest{UserId: login.User.Id, Preferences: preferences(func(p *pb.Preferences) {
+				p.Locale = "xx-XX"
+			})},
+			&pb.PreferencesResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-locale",
+			},
+		},
+		{
+			"Invalid Timezone",
+			&pb.UpdatePreferencesRequest{UserId: login.User.Id, Preferences: preferences(func(p *pb.Preferences) {
+				p.Timezone = "Mars/Olympus"
+			})},
+			&pb.PreferencesResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-timezone",
+			},
+		},
+		{
+			"Invalid Week Start",
+			&pb.UpdatePreferencesRequest{UserId: login.User.Id, Preferences: preferences(func(p *pb.Preferences) {
+				p.WeekStart = 7
+			})},
+			&pb.PreferencesResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-week-start",
+			},
+		},
+		{
+			"Invalid Default Balance Type",
+			&pb.UpdatePreferencesRequest{UserId: login.User.Id, Preferences: preferences(func(p *pb.Preferences) {
+				p.DefaultBalanceType = 2
+			})},
+			&pb.PreferencesResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-default-balance-type",
+			},
+		},
+		{
+			"User not found",
+			&pb.UpdatePreferencesRequest{UserId: 999999, Preferences: preferences(nil)},
+			&pb.PreferencesResponse{
+				Status: http.StatusNotFound,
+				Error:  "user-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.UpdatePreferences(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	// the last saved preferences are returned
+	response, err := client.GetPreferences(ctx, &pb.GetPreferencesRequest{UserId: login.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), response.Status)
+	require.Equal(t, "EUR", response.Preferences.Currency)
+	require.Equal(t, "en-US", response.Preferences.Locale)
+	require.Equal(t, "America/New_York", response.Preferences.Timezone)
+	require.Equal(t, int32(1), response.Preferences.WeekStart)
+	require.Equal(t, int32(1), response.Preferences.DefaultBalanceType)
+}
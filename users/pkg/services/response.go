@@ -20,6 +20,34 @@ func genericLoginResponse(statusCode int, errorMessage string) (*pb.LoginRespons
 	}, nil
 }
 
+func genericRefreshTokenResponse(statusCode int, errorMessage string) (*pb.RefreshTokenResponse, error) {
+	return &pb.RefreshTokenResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericLogoutResponse(statusCode int, errorMessage string) (*pb.LogoutResponse, error) {
+	return &pb.LogoutResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericListSessionsResponse(statusCode int, errorMessage string) (*pb.ListSessionsResponse, error) {
+	return &pb.ListSessionsResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericRevokeSessionResponse(statusCode int, errorMessage string) (*pb.RevokeSessionResponse, error) {
+	return &pb.RevokeSessionResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
 func genericUpdateProfileResponse(statusCode int, errorMessage string) (*pb.UpdateProfileResponse, error) {
 	return &pb.UpdateProfileResponse{
 		Status: int32(statusCode),
@@ -37,3 +65,178 @@ func genericChangePasswordResponse(statusCode int, errorMessage string) (*pb.Cha
 func genericUploadImageResponse(errorMessage string) error {
 	return errors.New(errorMessage)
 }
+
+func genericForgotPasswordResponse(statusCode int, errorMessage string) (*pb.ForgotPasswordResponse, error) {
+	return &pb.ForgotPasswordResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericResetPasswordResponse(statusCode int, errorMessage string) (*pb.ResetPasswordResponse, error) {
+	return &pb.ResetPasswordResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericVerifyEmailResponse(statusCode int, errorMessage string) (*pb.VerifyEmailResponse, error) {
+	return &pb.VerifyEmailResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericResendVerificationResponse(statusCode int, errorMessage string) (*pb.ResendVerificationResponse, error) {
+	return &pb.ResendVerificationResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericEnrollTwoFactorResponse(statusCode int, errorMessage string) (*pb.EnrollTwoFactorResponse, error) {
+	return &pb.EnrollTwoFactorResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericConfirmTwoFactorResponse(statusCode int, errorMessage string) (*pb.ConfirmTwoFactorResponse, error) {
+	return &pb.ConfirmTwoFactorResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericDisableTwoFactorResponse(statusCode int, errorMessage string) (*pb.DisableTwoFactorResponse, error) {
+	return &pb.DisableTwoFactorResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericGetProfileResponse(statusCode int, errorMessage string) (*pb.GetProfileResponse, error) {
+	return &pb.GetProfileResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericDeleteAccountResponse(statusCode int, errorMessage string) (*pb.DeleteAccountResponse, error) {
+	return &pb.DeleteAccountResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericDataExportResponse(statusCode int, errorMessage string) (*pb.DataExportResponse, error) {
+	return &pb.DataExportResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericPreferencesResponse(statusCode int, errorMessage string) (*pb.PreferencesResponse, error) {
+	return &pb.PreferencesResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericHouseholdResponse(statusCode int, errorMessage string) (*pb.HouseholdResponse, error) {
+	return &pb.HouseholdResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericGetHouseholdsResponse(statusCode int, errorMessage string) (*pb.GetHouseholdsResponse, error) {
+	return &pb.GetHouseholdsResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericDeleteHouseholdResponse(statusCode int, errorMessage string) (*pb.DeleteHouseholdResponse, error) {
+	return &pb.DeleteHouseholdResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericInviteHouseholdMemberResponse(statusCode int, errorMessage string) (*pb.InviteHouseholdMemberResponse, error) {
+	return &pb.InviteHouseholdMemberResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericDeclineHouseholdInvitationResponse(statusCode int, errorMessage string) (*pb.DeclineHouseholdInvitationResponse, error) {
+	return &pb.DeclineHouseholdInvitationResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericRemoveHouseholdMemberResponse(statusCode int, errorMessage string) (*pb.RemoveHouseholdMemberResponse, error) {
+	return &pb.RemoveHouseholdMemberResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericListUsersResponse(statusCode int, errorMessage string) (*pb.ListUsersResponse, error) {
+	return &pb.ListUsersResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericSetUserDisabledResponse(statusCode int, errorMessage string) (*pb.SetUserDisabledResponse, error) {
+	return &pb.SetUserDisabledResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericSetUserRoleResponse(statusCode int, errorMessage string) (*pb.SetUserRoleResponse, error) {
+	return &pb.SetUserRoleResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericGetSystemStatsResponse(statusCode int, errorMessage string) (*pb.GetSystemStatsResponse, error) {
+	return &pb.GetSystemStatsResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericCreateAccessTokenResponse(statusCode int, errorMessage string) (*pb.CreateAccessTokenResponse, error) {
+	return &pb.CreateAccessTokenResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericListAccessTokensResponse(statusCode int, errorMessage string) (*pb.ListAccessTokensResponse, error) {
+	return &pb.ListAccessTokensResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericRevokeAccessTokenResponse(statusCode int, errorMessage string) (*pb.RevokeAccessTokenResponse, error) {
+	return &pb.RevokeAccessTokenResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
+
+func genericGetJwksResponse(statusCode int, errorMessage string) (*pb.GetJwksResponse, error) {
+	return &pb.GetJwksResponse{
+		Status: int32(statusCode),
+		Error:  errorMessage,
+	}, nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+)
+
+// createSession records the device a user logged in from. The values come from
+// the client, they are cut to the column sizes instead of failing the login.
+func createSession(ctx context.Context, db queryRower, userId int32, req *pb.LoginRequest) (int32, error) {
+	q := `
+		INSERT INTO sessions (user_id, device_name, user_agent, ip)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	var id int32
+	row := db.QueryRowContext(ctx, q,
+		userId,
+		truncate(req.DeviceName, 100),
+		truncate(req.UserAgent, 255),
+		truncate(req.Ip, 45),
+	)
+	err := row.Scan(&id)
+
+	return id, err
+}
+
+// startSession creates the session of a login and issues its access and
+// refresh token.
+func (s *Server) startSession(ctx context.Context, tx *sql.Tx, userId int32, tokenVersion int32, role string, req *pb.LoginRequest) (token string, refreshToken string, err error) {
+	sessionId, err := createSession(ctx, tx, userId, req)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, _, err = s.issueRefreshToken(ctx, tx, userId, sessionId)
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err = s.Jwt.GenerateToken(userId, sessionId, tokenVersion, role)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+// revokeSession revokes a session of a user and every refresh token issued for
+// it, access tokens of the session are rejected by Validate.
+func revokeSession(ctx context.Context, tx *sql.Tx, userId int32, sessionId int32) (bool, error) {
+	q := `
+		UPDATE sessions SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	res, err := tx.ExecContext(ctx, q, sessionId, userId)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+
+	q = `UPDATE refresh_tokens SET revoked_at = now() WHERE session_id = $1 AND revoked_at IS NULL`
+	if _, err = tx.ExecContext(ctx, q, sessionId); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// touchSession updates when a session was last used, at most once a minute so
+// that validating every request doesn't write every time.
+func (s *Server) touchSession(ctx context.Context, sessionId int32) error {
+	q := `
+		UPDATE sessions SET last_seen_at = now()
+		WHERE id = $1 AND last_seen_at < now() - interval '1 minute'
+	`
+	_, err := s.DB.ExecContext(ctx, q, sessionId)
+
+	return err
+}
+
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+
+	return string(runes[:max])
+}
+
+func (s *Server) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	if req.UserId == 0 {
+		return genericListSessionsResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	q := `
+		SELECT id, device_name, user_agent, ip, created_at, last_seen_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC, id DESC
+	`
+
+	rows, err := s.DB.QueryContext(ctx, q, req.UserId)
+	if err != nil {
+		log.Println(err)
+		return genericListSessionsResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer rows.Close()
+
+	var sessions []*pb.Session
+	for rows.Next() {
+		var session pb.Session
+		var createdAt, lastSeenAt time.Time
+
+		if err := rows.Scan(
+			&session.Id,
+			&session.DeviceName,
+			&session.UserAgent,
+			&session.Ip,
+			&createdAt,
+			&lastSeenAt,
+		); err != nil {
+			log.Println(err)
+			return genericListSessionsResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		session.CreatedAt = int32(createdAt.Unix())
+		session.LastSeenAt = int32(lastSeenAt.Unix())
+		session.Current = session.Id == req.CurrentSessionId
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Println(err)
+		return genericListSessionsResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.ListSessionsResponse{
+		Status:   int32(http.StatusOK),
+		Error:    "",
+		Sessions: sessions,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	if req.UserId == 0 {
+		return genericRevokeSessionResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Id == 0 {
+		return genericRevokeSessionResponse(http.StatusBadRequest, "invalid-session-id")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericRevokeSessionResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	revoked, err := revokeSession(ctx, tx, req.UserId, req.Id)
+	if err != nil {
+		log.Println(err)
+		return genericRevokeSessionResponse(http.StatusInternalServerError, err.Error())
+	}
+	if !revoked {
+		return genericRevokeSessionResponse(http.StatusNotFound, "session-not-found")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericRevokeSessionResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericRevokeSessionResponse(http.StatusOK, "")
+}
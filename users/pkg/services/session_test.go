@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSessions(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+
+	deviceName := utils.RandomString(10)
+	second, err := client.Login(ctx, &pb.LoginRequest{
+		Email:      login.User.Email,
+		Password:   password,
+		DeviceName: deviceName,
+		UserAgent:  "Mozilla/5.0",
+		Ip:         "127.0.0.1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), second.Status)
+
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: second.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), validate.Status)
+	require.NotZero(t, validate.SessionId)
+
+	testCases := []struct {
+		name string
+		req  *pb.ListSessionsRequest
+		resp *pb.ListSessionsResponse
+	}{
+		{
+			"OK",
+			&pb.ListSessionsRequest{
+				UserId:           login.User.Id,
+				CurrentSessionId: validate.SessionId,
+			},
+			&pb.ListSessionsResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.ListSessionsRequest{UserId: 0},
+			&pb.ListSessionsResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ListSessions(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+
+			if response.Status == int32(http.StatusOK) {
+				require.Len(t, response.Sessions, 2)
+
+				var current *pb.Session
+				for _, session := range response.Sessions {
+					if session.Current {
+						current = session
+					}
+				}
+				require.NotNil(t, current)
+				require.Equal(t, validate.SessionId, current.Id)
+				require.Equal(t, deviceName, current.DeviceName)
+				require.Equal(t, "Mozilla/5.0", current.UserAgent)
+				require.Equal(t, "127.0.0.1", current.Ip)
+				require.NotZero(t, current.LastSeenAt)
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+	other, _ := createUser(t, ctx, client)
+
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: login.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), validate.Status)
+
+	testCases := []struct {
+		name string
+		req  *pb.RevokeSessionRequest
+		resp *pb.RevokeSessionResponse
+	}{
+		{
+			"Other User Session",
+			&pb.RevokeSessionRequest{UserId: other.User.Id, Id: validate.SessionId},
+			&pb.RevokeSessionResponse{
+				Status: http.StatusNotFound,
+				Error:  "session-not-found",
+			},
+		},
+		{
+			"OK",
+			&pb.RevokeSessionRequest{UserId: login.User.Id, Id: validate.SessionId},
+			&pb.RevokeSessionResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Already Revoked",
+			&pb.RevokeSessionRequest{UserId: login.User.Id, Id: validate.SessionId},
+			&pb.RevokeSessionResponse{
+				Status: http.StatusNotFound,
+				Error:  "session-not-found",
+			},
+		},
+		{
+			"Invalid Session ID",
+			&pb.RevokeSessionRequest{UserId: login.User.Id, Id: 0},
+			&pb.RevokeSessionResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-session-id",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.RevokeSession(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	revoked, err := client.Validate(ctx, &pb.ValidateRequest{Token: login.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), revoked.Status)
+	require.Equal(t, "token-revoked", revoked.Error)
+
+	refresh, err := client.Refresh(ctx, &pb.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), refresh.Status)
+
+	// the other user's session is untouched
+	validate, err = client.Validate(ctx, &pb.ValidateRequest{Token: other.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), validate.Status)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+// KeyRefreshInterval is how often every instance reloads the signing keys and
+// checks whether they are due for rotation. KeyPublishDelay has to be longer
+// than it plus the time the gateway lets clients cache the JWKS, so all
+// instances and verifiers know a key before it signs.
+const KeyRefreshInterval = time.Minute
+
+// RotateSigningKeys creates the first signing key, and a new one when the
+// newest is older than KeyRotationInterval, then reloads the keys. The new key
+// is published right away but only signs after KeyPublishDelay, the keys it
+// replaces stay published until the tokens they signed expired.
+func (s *Server) RotateSigningKeys(ctx context.Context) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// every instance rotates on its own timer, only one of them at a time
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('signing_keys'))`); err != nil {
+		return err
+	}
+
+	var newest sql.NullTime
+	q := `SELECT max(activated_at) FROM signing_keys`
+	if err = tx.QueryRowContext(ctx, q).Scan(&newest); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	switch {
+	case !newest.Valid:
+		// no tokens to verify yet, so the first key signs right away
+		err = s.createSigningKey(ctx, tx, now)
+	case s.KeyRotationInterval > 0 && !newest.Time.Add(s.KeyRotationInterval).After(now):
+		activatedAt := now.Add(s.KeyPublishDelay)
+		tokenTTL := time.Minute * time.Duration(s.Jwt.ExpirationMinutes)
+
+		q = `UPDATE signing_keys SET expires_at = $1 WHERE expires_at IS NULL`
+		if _, err = tx.ExecContext(ctx, q, activatedAt.Add(tokenTTL)); err != nil {
+			return err
+		}
+		err = s.createSigningKey(ctx, tx, activatedAt)
+	}
+	if err != nil {
+		return err
+	}
+
+	q = `DELETE FROM signing_keys WHERE expires_at < now()`
+	if _, err = tx.ExecContext(ctx, q); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return s.LoadSigningKeys(ctx)
+}
+
+func (s *Server) createSigningKey(ctx context.Context, tx *sql.Tx, activatedAt time.Time) error {
+	key, err := utils.NewSigningKey(s.SigningAlgorithm, activatedAt)
+	if err != nil {
+		return err
+	}
+
+	private, err := key.EncryptPrivateKey(s.SigningKeySecret)
+	if err != nil {
+		return err
+	}
+
+	q := `
+		INSERT INTO signing_keys (kid, algorithm, private_key, activated_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.ExecContext(ctx, q, key.Kid, key.Algorithm, private, key.ActivatedAt)
+
+	return err
+}
+
+// LoadSigningKeys replaces the key ring with the published keys.
+func (s *Server) LoadSigningKeys(ctx context.Context) error {
+	q := `
+		SELECT kid, algorithm, private_key, activated_at
+		FROM signing_keys
+		WHERE expires_at IS NULL OR expires_at > now()
+	`
+
+	rows, err := s.DB.QueryContext(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keys := []*utils.SigningKey{}
+	for rows.Next() {
+		var kid, algorithm, private string
+		var activatedAt time.Time
+		if err = rows.Scan(&kid, &algorithm, &private, &activatedAt); err != nil {
+			return err
+		}
+
+		key, err := utils.DecryptSigningKey(algorithm, kid, private, s.SigningKeySecret, activatedAt)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	s.Jwt.Keys.Set(keys)
+
+	return nil
+}
+
+// RunKeyRotation rotates and reloads the signing keys every KeyRefreshInterval
+// until the context is done.
+func (s *Server) RunKeyRotation(ctx context.Context) {
+	ticker := time.NewTicker(KeyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.RotateSigningKeys(ctx); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// GetJwks publishes the keys of the ring RunKeyRotation reloads, a new key is
+// published before it signs so every instance knows it by then.
+func (s *Server) GetJwks(ctx context.Context, req *pb.GetJwksRequest) (*pb.GetJwksResponse, error) {
+	keys := []*pb.Jwk{}
+	for _, key := range s.Jwt.Keys.Keys() {
+		jwk := key.JWK()
+		keys = append(keys, &pb.Jwk{
+			Kty: jwk.Kty,
+			Kid: jwk.Kid,
+			Alg: jwk.Alg,
+			Use: jwk.Use,
+			N:   jwk.N,
+			E:   jwk.E,
+			Crv: jwk.Crv,
+			X:   jwk.X,
+		})
+	}
+
+	resp := &pb.GetJwksResponse{
+		Status: http.StatusOK,
+		Error:  "",
+		Keys:   keys,
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maslow123/users/pkg/config"
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func tokenKid(t *testing.T, token string) string {
+	header, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	require.NoError(t, err)
+
+	var h struct {
+		Kid string `json:"kid"`
+	}
+	require.NoError(t, json.Unmarshal(header, &h))
+
+	return h.Kid
+}
+
+func jwksKids(t *testing.T, response *pb.GetJwksResponse) []string {
+	require.Equal(t, int32(http.StatusOK), response.Status)
+
+	kids := []string{}
+	for _, key := range response.Keys {
+		require.Equal(t, "sig", key.Use)
+		kids = append(kids, key.Kid)
+	}
+
+	return kids
+}
+
+func TestGetJwks(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	kid := tokenKid(t, login.Token)
+	require.NotEmpty(t, kid)
+	response, err := client.GetJwks(ctx, &pb.GetJwksRequest{})
+	require.NoError(t, err)
+	require.Contains(t, jwksKids(t, response), kid)
+}
+
+func TestRotateSigningKeys(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	before, _ := createUser(t, ctx, client)
+
+	// a server that is due for rotation, its next key waits an hour to sign
+	s := Server{
+		DB:                  db,
+		Jwt:                 utils.JwtWrapper{Issuer: "user-service", ExpirationMinutes: 15, Keys: &utils.KeyRing{}},
+		SigningAlgorithm:    utils.AlgorithmRS256,
+		SigningKeySecret:    c.JWTSigningKeySecret,
+		KeyRotationInterval: time.Nanosecond,
+		KeyPublishDelay:     time.Hour,
+	}
+	require.NoError(t, s.RotateSigningKeys(ctx))
+
+	next := s.Jwt.Keys.Keys()[0]
+	require.True(t, next.ActivatedAt.After(time.Now()))
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM signing_keys WHERE kid = $1`, next.Kid)
+		db.Exec(`UPDATE signing_keys SET expires_at = NULL WHERE expires_at = $1`, next.ActivatedAt.Add(15*time.Minute))
+	})
+
+	// the next key is published, tokens are still signed with the current one
+	response, err := s.GetJwks(ctx, &pb.GetJwksRequest{})
+	require.NoError(t, err)
+	require.Contains(t, jwksKids(t, response), next.Kid)
+	require.NotEqual(t, next.Kid, s.Jwt.Keys.Current().Kid)
+
+	after, _ := createUser(t, ctx, client)
+	require.Equal(t, tokenKid(t, before.Token), tokenKid(t, after.Token))
+
+	// rotating again before the next key signs changes nothing
+	require.NoError(t, s.RotateSigningKeys(ctx))
+	require.Equal(t, next.Kid, s.Jwt.Keys.Keys()[0].Kid)
+}
@@ -3,19 +3,30 @@ package services
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sync"
-
-	"github.com/google/uuid"
 )
 
+// ImageStore keeps the uploaded photos by file name, the image id followed
+// by its type.
 type ImageStore interface {
-	Save(userID int32, imageType string, imageData bytes.Buffer) (string, error)
+	Save(userID int32, fileName string, imageData bytes.Buffer) error
+	// Get opens a saved image by its file name, a missing image is an
+	// os.ErrNotExist error
+	Get(fileName string) (io.ReadCloser, error)
+	// Delete removes a saved image by its file name, a missing image isn't an error
+	Delete(fileName string) error
+	// URL is where clients load a saved image from, empty when the store
+	// doesn't serve its images
+	URL(fileName string) string
 }
 
 type DiskImageStore struct {
 	mutex       sync.RWMutex
 	imageFolder string
+	baseUrl     string
 	images      map[string]*ImageInfo
 }
 
@@ -25,39 +36,72 @@ type ImageInfo struct {
 	Path   string
 }
 
-func NewDiskImageStore(imageFolder string) *DiskImageStore {
+// NewDiskImageStore saves the images in imageFolder, baseUrl is where a
+// server in front of the folder serves them from.
+func NewDiskImageStore(imageFolder string, baseUrl string) *DiskImageStore {
 	return &DiskImageStore{
 		imageFolder: imageFolder,
+		baseUrl:     baseUrl,
 		images:      make(map[string]*ImageInfo),
 	}
 }
 
-func (store *DiskImageStore) Save(userID int32, imageType string, imageData bytes.Buffer) (string, error) {
-	imageID, err := uuid.NewRandom()
-	if err != nil {
-		return "", fmt.Errorf("Cannot generate image id: %w", err)
+func (store *DiskImageStore) Save(userID int32, fileName string, imageData bytes.Buffer) error {
+	if err := os.MkdirAll(store.imageFolder, 0755); err != nil {
+		return fmt.Errorf("Cannot create image folder: %w", err)
 	}
 
-	imagePath := fmt.Sprintf("%s/%s%s", store.imageFolder, imageID, imageType)
+	imagePath := fmt.Sprintf("%s/%s", store.imageFolder, fileName)
 	file, err := os.Create(imagePath)
 	if err != nil {
-		return "", fmt.Errorf("Cannot create image file: %w", err)
+		return fmt.Errorf("Cannot create image file: %w", err)
 	}
+	defer file.Close()
 
 	_, err = imageData.WriteTo(file)
 	if err != nil {
-		return "", fmt.Errorf("Cannot write image to file: %w", err)
+		return fmt.Errorf("Cannot write image to file: %w", err)
 	}
 
 	store.mutex.Lock()
 	defer store.mutex.Unlock()
 
-	store.images[imageID.String()] = &ImageInfo{
+	store.images[fileName] = &ImageInfo{
 		UserID: userID,
-		Type:   imageType,
+		Type:   filepath.Ext(fileName),
 		Path:   imagePath,
 	}
 
-	// fileName := fmt.Sprintf("%s.%s", imageID.String(), imageType)
-	return imageID.String(), nil
+	return nil
+}
+
+func (store *DiskImageStore) Get(fileName string) (io.ReadCloser, error) {
+	file, err := os.Open(fmt.Sprintf("%s/%s", store.imageFolder, fileName))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open image file: %w", err)
+	}
+
+	return file, nil
+}
+
+func (store *DiskImageStore) Delete(fileName string) error {
+	imagePath := fmt.Sprintf("%s/%s", store.imageFolder, fileName)
+	if err := os.Remove(imagePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Cannot delete image file: %w", err)
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.images, fileName)
+
+	return nil
+}
+
+func (store *DiskImageStore) URL(fileName string) string {
+	if store.baseUrl == "" {
+		return ""
+	}
+
+	return store.baseUrl + fileName
 }
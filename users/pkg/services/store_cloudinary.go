@@ -0,0 +1,93 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cloudinary "github.com/cloudinary/cloudinary-go"
+	"github.com/cloudinary/cloudinary-go/api/uploader"
+)
+
+// CloudinaryImageStore uploads the images to cloudinary, they're served from
+// its CDN.
+type CloudinaryImageStore struct {
+	cld       *cloudinary.Cloudinary
+	cloudName string
+	folder    string
+}
+
+// NewCloudinaryImageStore keeps the images in folder of a cloudinary account.
+func NewCloudinaryImageStore(cloudName, apiKey, apiSecret, folder string) (*CloudinaryImageStore, error) {
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create cloudinary client: %w", err)
+	}
+
+	return &CloudinaryImageStore{
+		cld:       cld,
+		cloudName: cloudName,
+		folder:    folder,
+	}, nil
+}
+
+// publicID is the cloudinary id of an image, the file name without its type.
+func (store *CloudinaryImageStore) publicID(fileName string) string {
+	return fmt.Sprintf("%s/%s", store.folder, strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+}
+
+func (store *CloudinaryImageStore) Save(userID int32, fileName string, imageData bytes.Buffer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := store.cld.Upload.Upload(ctx, bytes.NewReader(imageData.Bytes()), uploader.UploadParams{
+		PublicID: store.publicID(fileName),
+	})
+	if err != nil {
+		return fmt.Errorf("Cannot upload image: %w", err)
+	}
+
+	return nil
+}
+
+func (store *CloudinaryImageStore) Get(fileName string) (io.ReadCloser, error) {
+	res, err := http.Get(store.URL(fileName))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot download image: %w", err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res.Body, nil
+	case http.StatusNotFound:
+		res.Body.Close()
+		return nil, fmt.Errorf("Cannot download image: %w", os.ErrNotExist)
+	default:
+		res.Body.Close()
+		return nil, fmt.Errorf("Cannot download image: %s", res.Status)
+	}
+}
+
+func (store *CloudinaryImageStore) Delete(fileName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := store.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID: store.publicID(fileName),
+	})
+	if err != nil {
+		return fmt.Errorf("Cannot delete image: %w", err)
+	}
+
+	return nil
+}
+
+func (store *CloudinaryImageStore) URL(fileName string) string {
+	return fmt.Sprintf("https://res.cloudinary.com/%s/image/upload/%s/%s", store.cloudName, store.folder, fileName)
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3ImageStore keeps the images in a bucket of an S3 compatible object
+// storage (AWS S3, MinIO, ...), objects are addressed path style so any
+// endpoint works without bucket DNS names.
+type S3ImageStore struct {
+	// Endpoint is the scheme and host of the storage, e.g. http://localhost:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Prefix is prepended to the file names to make the object keys
+	Prefix string
+	// PublicUrl is where the images are served from, the file name is
+	// appended to it. Empty serves them from the bucket itself.
+	PublicUrl string
+	Client    *http.Client
+}
+
+func (store *S3ImageStore) objectUrl(fileName string) string {
+	return fmt.Sprintf("%s/%s/%s%s", strings.TrimSuffix(store.Endpoint, "/"), store.Bucket, store.Prefix, fileName)
+}
+
+func (store *S3ImageStore) do(method string, fileName string, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, store.objectUrl(fileName), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	store.sign(req, body, time.Now())
+
+	client := store.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return client.Do(req)
+}
+
+// sign adds an AWS signature version 4 to a request.
+func (store *S3ImageStore) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + req.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, store.Region)
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+store.SecretKey), date)
+	key = hmacSHA256(key, store.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		store.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func (store *S3ImageStore) Save(userID int32, fileName string, imageData bytes.Buffer) error {
+	body := imageData.Bytes()
+	res, err := store.do(http.MethodPut, fileName, body, http.DetectContentType(body))
+	if err != nil {
+		return fmt.Errorf("Cannot upload image: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Cannot upload image: %s", res.Status)
+	}
+
+	return nil
+}
+
+func (store *S3ImageStore) Get(fileName string) (io.ReadCloser, error) {
+	res, err := store.do(http.MethodGet, fileName, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("Cannot download image: %w", err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res.Body, nil
+	case http.StatusNotFound:
+		res.Body.Close()
+		return nil, fmt.Errorf("Cannot download image: %w", os.ErrNotExist)
+	default:
+		res.Body.Close()
+		return nil, fmt.Errorf("Cannot download image: %s", res.Status)
+	}
+}
+
+func (store *S3ImageStore) Delete(fileName string) error {
+	res, err := store.do(http.MethodDelete, fileName, nil, "")
+	if err != nil {
+		return fmt.Errorf("Cannot delete image: %w", err)
+	}
+	defer res.Body.Close()
+
+	// S3 answers 204 for missing objects too
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("Cannot delete image: %s", res.Status)
+	}
+
+	return nil
+}
+
+func (store *S3ImageStore) URL(fileName string) string {
+	if store.PublicUrl != "" {
+		return store.PublicUrl + fileName
+	}
+
+	return store.objectUrl(fileName)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is an in-process S3 bucket, it only checks that requests are signed
+// with the expected access key.
+type fakeS3 struct {
+	mutex     sync.Mutex
+	accessKey string
+	objects   map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	credential := "AWS4-HMAC-SHA256 Credential=" + f.accessKey + "/"
+	if !strings.HasPrefix(r.Header.Get("Authorization"), credential) || r.Header.Get("X-Amz-Date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func testImageStore(t *testing.T, store ImageStore) {
+	image := []byte("\x89PNG\r\n\x1a\nnot really a png")
+
+	fileName := "avatar.png"
+	err := store.Save(1, fileName, *bytes.NewBuffer(image))
+	require.NoError(t, err)
+
+	file, err := store.Get(fileName)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.Equal(t, image, data)
+
+	require.NoError(t, store.Delete(fileName))
+	// deleting twice is fine
+	require.NoError(t, store.Delete(fileName))
+
+	_, err = store.Get(fileName)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDiskImageStore(t *testing.T) {
+	store := NewDiskImageStore(t.TempDir(), testAvatarBaseUrl)
+	testImageStore(t, store)
+
+	require.Equal(t, testAvatarBaseUrl+"avatar.png", store.URL("avatar.png"))
+	require.Empty(t, NewDiskImageStore(t.TempDir(), "").URL("avatar.png"))
+}
+
+func TestS3ImageStore(t *testing.T) {
+	fake := &fakeS3{accessKey: "minio", objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := &S3ImageStore{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "keuanganku",
+		AccessKey: "minio",
+		SecretKey: "minio123",
+		Prefix:    "users/",
+		Client:    server.Client(),
+	}
+	testImageStore(t, store)
+
+	err := store.Save(1, "avatar.jpg", *bytes.NewBufferString("image"))
+	require.NoError(t, err)
+	require.Contains(t, fake.objects, "/keuanganku/users/avatar.jpg")
+
+	require.Equal(t, server.URL+"/keuanganku/users/avatar.png", store.URL("avatar.png"))
+	store.PublicUrl = "https://cdn.keuanganku.local/users/"
+	require.Equal(t, "https://cdn.keuanganku.local/users/avatar.png", store.URL("avatar.png"))
+
+	store.AccessKey = "someone-else"
+	err = store.Save(1, "avatar.jpg", *bytes.NewBufferString("image"))
+	require.Error(t, err)
+}
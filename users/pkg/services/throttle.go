@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+)
+
+// LoginThrottle limits failed logins per account and per client IP. A zero
+// limit disables that key.
+type LoginThrottle struct {
+	MaxAttempts      int32
+	MaxAttemptsPerIp int32
+	// Lockout is the first lock once a limit is reached, it doubles with every
+	// further failure up to MaxLockout
+	Lockout    time.Duration
+	MaxLockout time.Duration
+	// Window is how long failures are remembered after the last one or the end
+	// of the last lock
+	Window time.Duration
+}
+
+type throttleKey struct {
+	key   string
+	limit int32
+}
+
+func (t LoginThrottle) keys(email string, ip string) []throttleKey {
+	var keys []throttleKey
+	if t.MaxAttempts > 0 {
+		keys = append(keys, throttleKey{"email:" + email, t.MaxAttempts})
+	}
+	if t.MaxAttemptsPerIp > 0 && ip != "" {
+		keys = append(keys, throttleKey{"ip:" + ip, t.MaxAttemptsPerIp})
+	}
+
+	return keys
+}
+
+// lockout returns how long a key is locked after its nth failure.
+func (t LoginThrottle) lockout(failures int32, limit int32) time.Duration {
+	if failures < limit {
+		return 0
+	}
+
+	lock := float64(t.Lockout) * math.Pow(2, float64(failures-limit))
+	if lock > float64(t.MaxLockout) {
+		return t.MaxLockout
+	}
+
+	return time.Duration(lock)
+}
+
+// loginLockedFor returns how long the account or the client is still locked,
+// zero when the login can be tried.
+func (s *Server) loginLockedFor(ctx context.Context, email string, ip string) (time.Duration, error) {
+	var lockedFor time.Duration
+
+	q := `SELECT COALESCE(EXTRACT(EPOCH FROM locked_until - now()), 0) FROM login_attempts WHERE key = $1`
+	for _, k := range s.LoginThrottle.keys(email, ip) {
+		var seconds float64
+		err := s.DB.QueryRowContext(ctx, q, k.key).Scan(&seconds)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return 0, err
+		}
+
+		if remaining := time.Duration(seconds * float64(time.Second)); remaining > lockedFor {
+			lockedFor = remaining
+		}
+	}
+
+	return lockedFor, nil
+}
+
+// recordLoginFailure counts a failed login for the account and the client and
+// locks the ones that reached their limit.
+func (s *Server) recordLoginFailure(ctx context.Context, email string, ip string) error {
+	window := int64(s.LoginThrottle.Window.Seconds())
+
+	q := `
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES ($1, 1, now())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN GREATEST(login_attempts.last_failure_at, COALESCE(login_attempts.locked_until, login_attempts.last_failure_at))
+					< now() - make_interval(secs => $2)
+				THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = now()
+		RETURNING failures
+	`
+
+	for _, k := range s.LoginThrottle.keys(email, ip) {
+		var failures int32
+		if err := s.DB.QueryRowContext(ctx, q, k.key, window).Scan(&failures); err != nil {
+			return err
+		}
+
+		lock := s.LoginThrottle.lockout(failures, k.limit)
+		if lock == 0 {
+			continue
+		}
+
+		_, err := s.DB.ExecContext(ctx,
+			`UPDATE login_attempts SET locked_until = now() + make_interval(secs => $2) WHERE key = $1`,
+			k.key, lock.Seconds(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetLoginFailures forgets the failures of an account after a successful
+// login. Failures of the client are kept, logging in to one account must not
+// clear the guesses made against others.
+func (s *Server) resetLoginFailures(ctx context.Context, email string) error {
+	if s.LoginThrottle.MaxAttempts == 0 {
+		return nil
+	}
+
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = $1`, "email:"+email)
+
+	return err
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginThrottleLockout(t *testing.T) {
+	throttle := LoginThrottle{
+		Lockout:    30 * time.Second,
+		MaxLockout: 5 * time.Minute,
+	}
+
+	testCases := []struct {
+		failures int32
+		lockout  time.Duration
+	}{
+		{1, 0},
+		{4, 0},
+		{5, 30 * time.Second},
+		{6, time.Minute},
+		{8, 4 * time.Minute},
+		{9, 5 * time.Minute},
+		{100, 5 * time.Minute},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(fmt.Sprint(tc.failures), func(t *testing.T) {
+			require.Equal(t, tc.lockout, throttle.lockout(tc.failures, 5))
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+
+	// the test config locks an account after 5 failures
+	for i := 0; i < 5; i++ {
+		response, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: "wrong password"})
+		require.NoError(t, err)
+		require.Equal(t, int32(http.StatusUnauthorized), response.Status)
+		require.Equal(t, "invalid-credentials", response.Error)
+	}
+
+	// even the right password is refused while locked
+	response, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusTooManyRequests), response.Status)
+	require.Equal(t, "too-many-attempts", response.Error)
+	require.Greater(t, response.RetryAfter, int32(0))
+
+	// other accounts are not affected
+	other, _ := createUser(t, ctx, client)
+	require.NotEmpty(t, other.Token)
+}
+
+func TestLoginLockoutPerIp(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	ip := fmt.Sprintf("10.%d.%d.%d", utils.RandomInt(0, 255), utils.RandomInt(0, 255), utils.RandomInt(0, 255))
+
+	// the test config locks a client after 10 failures, spread over accounts
+	for i := 0; i < 10; i++ {
+		response, err := client.Login(ctx, &pb.LoginRequest{
+			Email:    utils.RandomEmail(),
+			Password: "wrong password",
+			Ip:       ip,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(http.StatusUnauthorized), response.Status)
+	}
+
+	response, err := client.Login(ctx, &pb.LoginRequest{
+		Email:    utils.RandomEmail(),
+		Password: "wrong password",
+		Ip:       ip,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusTooManyRequests), response.Status)
+}
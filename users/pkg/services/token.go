@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// issueRefreshToken stores the hash of a new refresh token for a session.
+func (s *Server) issueRefreshToken(ctx context.Context, db queryRower, userId int32, sessionId int32) (token string, id int32, err error) {
+	token, err = utils.NewOpaqueToken()
+	if err != nil {
+		return "", 0, err
+	}
+
+	q := `
+		INSERT INTO refresh_tokens (user_id, token_hash, session_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	row := db.QueryRowContext(ctx, q, userId, utils.HashToken(token), sessionId, time.Now().Add(s.RefreshTokenTTL))
+	err = row.Scan(&id)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return token, id, nil
+}
+
+// revokeAllTokens logs a user out everywhere: every session, refresh token and
+// personal access token is revoked and bumping the token version makes Validate
+// reject all issued access tokens.
+func revokeAllTokens(ctx context.Context, tx *sql.Tx, userId int32) error {
+	q := `UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, q, userId); err != nil {
+		return err
+	}
+
+	q = `UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, q, userId); err != nil {
+		return err
+	}
+
+	q = `UPDATE personal_access_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, q, userId); err != nil {
+		return err
+	}
+
+	q = `UPDATE users SET token_version = token_version + 1 WHERE id = $1`
+	_, err := tx.ExecContext(ctx, q, userId)
+
+	return err
+}
+
+// isTokenRevoked reports whether an access token was logged out, its session
+// was revoked or it was issued before the user's tokens were revoked.
+func (s *Server) isTokenRevoked(ctx context.Context, userId int32, sessionId int32, jti string, tokenVersion int32) (bool, error) {
+	q := `
+		SELECT
+			EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $2)
+			OR EXISTS (SELECT 1 FROM sessions WHERE id = $3 AND revoked_at IS NOT NULL),
+			token_version
+		FROM users
+		WHERE id = $1
+	`
+
+	var revoked bool
+	var currentVersion int32
+	row := s.DB.QueryRowContext(ctx, q, userId, jti, sessionId)
+	err := row.Scan(&revoked, &currentVersion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// the user was deleted
+			return true, nil
+		}
+		return false, err
+	}
+
+	return revoked || tokenVersion != currentVersion, nil
+}
+
+func (s *Server) Refresh(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	if req.RefreshToken == "" {
+		return genericRefreshTokenResponse(http.StatusBadRequest, "invalid-refresh-token")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `
+		SELECT r.id, r.user_id, r.session_id, r.expires_at, r.revoked_at IS NOT NULL, u.token_version, u.role
+		FROM refresh_tokens r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.token_hash = $1
+		FOR UPDATE OF r
+	`
+
+	var id, userId, sessionId, tokenVersion int32
+	var expiresAt time.Time
+	var revoked bool
+	var role string
+
+	row := tx.QueryRowContext(ctx, q, utils.HashToken(req.RefreshToken))
+	err = row.Scan(&id, &userId, &sessionId, &expiresAt, &revoked, &tokenVersion, &role)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericRefreshTokenResponse(http.StatusUnauthorized, "invalid-refresh-token")
+		}
+		return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// A rotated token being used again means it leaked, revoke the session it
+	// was issued for.
+	if revoked {
+		if _, err = revokeSession(ctx, tx, userId, sessionId); err != nil {
+			log.Println(err)
+			return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+		}
+		if err = tx.Commit(); err != nil {
+			return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+		}
+		return genericRefreshTokenResponse(http.StatusUnauthorized, "refresh-token-reused")
+	}
+
+	if expiresAt.Before(time.Now()) {
+		return genericRefreshTokenResponse(http.StatusUnauthorized, "refresh-token-expired")
+	}
+
+	refreshToken, newId, err := s.issueRefreshToken(ctx, tx, userId, sessionId)
+	if err != nil {
+		log.Println(err)
+		return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `UPDATE refresh_tokens SET revoked_at = now(), replaced_by = $2 WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, id, newId); err != nil {
+		log.Println(err)
+		return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `UPDATE sessions SET last_seen_at = now() WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, sessionId); err != nil {
+		log.Println(err)
+		return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	token, err := s.Jwt.GenerateToken(userId, sessionId, tokenVersion, role)
+	if err != nil {
+		log.Println(err)
+		return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericRefreshTokenResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.RefreshTokenResponse{
+		Status:       http.StatusOK,
+		Error:        "",
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	claims, err := s.Jwt.ValidateToken(req.Token)
+	if err != nil {
+		return genericLogoutResponse(http.StatusUnauthorized, "invalid-token")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericLogoutResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, to_timestamp($3))
+		ON CONFLICT (jti) DO NOTHING
+	`
+	_, err = tx.ExecContext(ctx, q, claims.StandardClaims.Id, claims.UserId, claims.ExpiresAt)
+	if err != nil {
+		log.Println(err)
+		return genericLogoutResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if _, err = revokeSession(ctx, tx, claims.UserId, claims.SessionId); err != nil {
+		log.Println(err)
+		return genericLogoutResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// revoked access tokens only need to be kept until they expire anyway
+	q = `DELETE FROM revoked_tokens WHERE expires_at < now()`
+	if _, err = tx.ExecContext(ctx, q); err != nil {
+		log.Println(err)
+		return genericLogoutResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericLogoutResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericLogoutResponse(http.StatusOK, "")
+}
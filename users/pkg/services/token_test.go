@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// createUser registers a new user and logs in, so tests that revoke tokens or
+// change the password don't affect the seeded users.
+func createUser(t *testing.T, ctx context.Context, client pb.UserServiceClient) (*pb.LoginResponse, string) {
+	email := utils.RandomEmail()
+	password := utils.RandomString(10)
+
+	register, err := client.Register(ctx, &pb.RegisterRequest{
+		Name:            utils.RandomString(10),
+		Email:           email,
+		Password:        password,
+		ConfirmPassword: password,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusCreated), register.Status)
+
+	login, err := client.Login(ctx, &pb.LoginRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), login.Status)
+	require.NotEmpty(t, login.RefreshToken)
+
+	return login, password
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.RefreshTokenRequest
+		resp *pb.RefreshTokenResponse
+	}{
+		{
+			"OK",
+			&pb.RefreshTokenRequest{RefreshToken: login.RefreshToken},
+			&pb.RefreshTokenResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Reused Refresh Token",
+			&pb.RefreshTokenRequest{RefreshToken: login.RefreshToken},
+			&pb.RefreshTokenResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "refresh-token-reused",
+			},
+		},
+		{
+			"Invalid Refresh Token",
+			&pb.RefreshTokenRequest{RefreshToken: ""},
+			&pb.RefreshTokenResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-refresh-token",
+			},
+		},
+		{
+			"Unknown Refresh Token",
+			&pb.RefreshTokenRequest{RefreshToken: utils.RandomString(43)},
+			&pb.RefreshTokenResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "invalid-refresh-token",
+			},
+		},
+	}
+
+	var rotated string
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.Refresh(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+			if response.Status == int32(http.StatusOK) {
+				require.NotEmpty(t, response.Token)
+				require.NotEqual(t, tc.req.RefreshToken, response.RefreshToken)
+				rotated = response.RefreshToken
+			}
+		})
+	}
+
+	// reusing the first token revoked the session, including the rotated one
+	response, err := client.Refresh(ctx, &pb.RefreshTokenRequest{RefreshToken: rotated})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), response.Status)
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	testCases := []struct {
+		name string
+		req  *pb.LogoutRequest
+		resp *pb.LogoutResponse
+	}{
+		{
+			"OK",
+			&pb.LogoutRequest{Token: login.Token, RefreshToken: login.RefreshToken},
+			&pb.LogoutResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Invalid Token",
+			&pb.LogoutRequest{Token: "invalid token"},
+			&pb.LogoutResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "invalid-token",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.Logout(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: login.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+	require.Equal(t, "token-revoked", validate.Error)
+
+	refresh, err := client.Refresh(ctx, &pb.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), refresh.Status)
+}
+
+func TestChangePasswordRevokesTokens(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+
+	response, err := client.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		Id:              login.User.Id,
+		OldPassword:     password,
+		Password:        "new password",
+		ConfirmPassword: "new password",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), response.Status)
+
+	validate, err := client.Validate(ctx, &pb.ValidateRequest{Token: login.Token})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), validate.Status)
+
+	refresh, err := client.Refresh(ctx, &pb.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), refresh.Status)
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+const (
+	twoFactorChallengeTTL  = 5 * time.Minute
+	maxTwoFactorAttempts   = 5
+	recoveryCodesGenerated = 10
+)
+
+// createTwoFactorChallenge answers a login with a correct password for a user
+// with two factor authentication, the tokens are only issued by VerifyTwoFactor.
+func (s *Server) createTwoFactorChallenge(ctx context.Context, userId int32, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q := `
+		INSERT INTO two_factor_challenges (user_id, token_hash, device_name, user_agent, ip, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = s.DB.ExecContext(ctx, q,
+		userId,
+		utils.HashToken(token),
+		truncate(req.DeviceName, 100),
+		truncate(req.UserAgent, 255),
+		truncate(req.Ip, 45),
+		time.Now().Add(twoFactorChallengeTTL),
+	)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.LoginResponse{
+		Status:            http.StatusOK,
+		Error:             "",
+		TwoFactorRequired: true,
+		TwoFactorToken:    token,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) EnrollTwoFactor(ctx context.Context, req *pb.EnrollTwoFactorRequest) (*pb.EnrollTwoFactorResponse, error) {
+	if req.UserId == 0 {
+		return genericEnrollTwoFactorResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	var email string
+	var enabled bool
+	q := `SELECT email, totp_enabled FROM users WHERE id = $1`
+
+	row := s.DB.QueryRowContext(ctx, q, req.UserId)
+	err := row.Scan(&email, &enabled)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericEnrollTwoFactorResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericEnrollTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if enabled {
+		return genericEnrollTwoFactorResponse(http.StatusBadRequest, "two-factor-already-enabled")
+	}
+
+	// enrolling again replaces a secret that was never confirmed
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		log.Println(err)
+		return genericEnrollTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `UPDATE users SET totp_secret = $2, totp_last_step = 0 WHERE id = $1`
+	if _, err = s.DB.ExecContext(ctx, q, req.UserId, secret); err != nil {
+		log.Println(err)
+		return genericEnrollTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.EnrollTwoFactorResponse{
+		Status: http.StatusOK,
+		Error:  "",
+		Secret: secret,
+		Uri:    utils.TOTPURI(s.TOTPIssuer, email, secret),
+	}
+
+	return resp, nil
+}
+
+// ConfirmTwoFactor enables two factor authentication once the user proves the
+// authenticator works, and returns the recovery codes. They are only shown here.
+func (s *Server) ConfirmTwoFactor(ctx context.Context, req *pb.ConfirmTwoFactorRequest) (*pb.ConfirmTwoFactorResponse, error) {
+	if req.UserId == 0 {
+		return genericConfirmTwoFactorResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Code == "" {
+		return genericConfirmTwoFactorResponse(http.StatusBadRequest, "invalid-code")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericConfirmTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	var secret string
+	var enabled bool
+	q := `SELECT COALESCE(totp_secret, ''), totp_enabled FROM users WHERE id = $1 FOR UPDATE`
+
+	row := tx.QueryRowContext(ctx, q, req.UserId)
+	err = row.Scan(&secret, &enabled)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericConfirmTwoFactorResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericConfirmTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if enabled {
+		return genericConfirmTwoFactorResponse(http.StatusBadRequest, "two-factor-already-enabled")
+	}
+	if secret == "" {
+		return genericConfirmTwoFactorResponse(http.StatusBadRequest, "two-factor-not-enrolled")
+	}
+
+	step, ok := utils.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		return genericConfirmTwoFactorResponse(http.StatusBadRequest, "invalid-code")
+	}
+
+	q = `UPDATE users SET totp_enabled = true, totp_last_step = $2 WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, req.UserId, step); err != nil {
+		log.Println(err)
+		return genericConfirmTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `DELETE FROM totp_recovery_codes WHERE user_id = $1`
+	if _, err = tx.ExecContext(ctx, q, req.UserId); err != nil {
+		log.Println(err)
+		return genericConfirmTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	codes := make([]string, 0, recoveryCodesGenerated)
+	q = `INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	for i := 0; i < recoveryCodesGenerated; i++ {
+		code, err := utils.NewRecoveryCode()
+		if err != nil {
+			log.Println(err)
+			return genericConfirmTwoFactorResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		_, err = tx.ExecContext(ctx, q, req.UserId, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+		if err != nil {
+			log.Println(err)
+			return genericConfirmTwoFactorResponse(http.StatusInternalServerError, err.Error())
+		}
+		codes = append(codes, code)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericConfirmTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.ConfirmTwoFactorResponse{
+		Status:        http.StatusOK,
+		Error:         "",
+		RecoveryCodes: codes,
+	}
+
+	return resp, nil
+}
+
+func (s *Server) DisableTwoFactor(ctx context.Context, req *pb.DisableTwoFactorRequest) (*pb.DisableTwoFactorResponse, error) {
+	if req.UserId == 0 {
+		return genericDisableTwoFactorResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+	if req.Password == "" {
+		return genericDisableTwoFactorResponse(http.StatusBadRequest, "invalid-password")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericDisableTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	var userPass string
+	var enabled bool
+	q := `SELECT password, totp_enabled FROM users WHERE id = $1 FOR UPDATE`
+
+	row := tx.QueryRowContext(ctx, q, req.UserId)
+	err = row.Scan(&userPass, &enabled)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericDisableTwoFactorResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericDisableTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	match, _, err := s.Hasher.Verify(req.Password, userPass)
+	if err != nil {
+		log.Println(err)
+		return genericDisableTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+	if !match {
+		return genericDisableTwoFactorResponse(http.StatusBadRequest, "password-not-match")
+	}
+	if !enabled {
+		return genericDisableTwoFactorResponse(http.StatusBadRequest, "two-factor-not-enabled")
+	}
+
+	queries := []string{
+		`UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_last_step = 0 WHERE id = $1`,
+		`DELETE FROM totp_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM two_factor_challenges WHERE user_id = $1`,
+	}
+	for _, q := range queries {
+		if _, err = tx.ExecContext(ctx, q, req.UserId); err != nil {
+			log.Println(err)
+			return genericDisableTwoFactorResponse(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericDisableTwoFactorResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericDisableTwoFactorResponse(http.StatusOK, "")
+}
+
+// VerifyTwoFactor is the second step of a login, it issues the tokens once the
+// code of the authenticator or an unused recovery code matches.
+func (s *Server) VerifyTwoFactor(ctx context.Context, req *pb.VerifyTwoFactorRequest) (*pb.LoginResponse, error) {
+	if req.TwoFactorToken == "" {
+		return genericLoginResponse(http.StatusUnauthorized, "invalid-two-factor-token")
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return genericLoginResponse(http.StatusBadRequest, "invalid-code")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `
+		SELECT
+			c.id, c.device_name, c.user_agent, c.ip, c.attempts, c.expires_at,
+			u.id, u.name, u.email, COALESCE(u.photo, ''), u.email_verified, COALESCE(u.pending_email, ''),
+			u.role, u.token_version, COALESCE(u.totp_secret, ''), u.totp_last_step
+		FROM two_factor_challenges c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.token_hash = $1
+		FOR UPDATE
+	`
+
+	var challengeId, attempts, tokenVersion int32
+	var device pb.LoginRequest
+	var expiresAt time.Time
+	var user pb.User
+	var secret string
+	var lastStep int64
+
+	row := tx.QueryRowContext(ctx, q, utils.HashToken(req.TwoFactorToken))
+	err = row.Scan(
+		&challengeId,
+		&device.DeviceName,
+		&device.UserAgent,
+		&device.Ip,
+		&attempts,
+		&expiresAt,
+		&user.Id,
+		&user.Name,
+		&user.Email,
+		&user.Photo,
+		&user.EmailVerified,
+		&user.PendingEmail,
+		&user.Role,
+		&tokenVersion,
+		&secret,
+		&lastStep,
+	)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericLoginResponse(http.StatusUnauthorized, "invalid-two-factor-token")
+		}
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if expiresAt.Before(time.Now()) {
+		return genericLoginResponse(http.StatusUnauthorized, "two-factor-token-expired")
+	}
+
+	// codes are throttled like passwords, per account and per client of the login
+	lockedFor, err := s.loginLockedFor(ctx, user.Email, device.Ip)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+	if lockedFor > 0 {
+		return &pb.LoginResponse{
+			Status:     http.StatusTooManyRequests,
+			Error:      "too-many-attempts",
+			RetryAfter: int32(math.Ceil(lockedFor.Seconds())),
+		}, nil
+	}
+
+	var verified bool
+	if req.Code != "" {
+		// a code is accepted once, a later step than the last accepted one is required
+		step, ok := utils.ValidateTOTP(secret, req.Code, time.Now())
+		if ok && step > lastStep {
+			q = `UPDATE users SET totp_last_step = $2 WHERE id = $1`
+			if _, err = tx.ExecContext(ctx, q, user.Id, step); err != nil {
+				log.Println(err)
+				return genericLoginResponse(http.StatusInternalServerError, err.Error())
+			}
+			verified = true
+		}
+	} else {
+		q = `
+			UPDATE totp_recovery_codes SET used_at = now()
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+		`
+		res, err := tx.ExecContext(ctx, q, user.Id, utils.HashToken(utils.NormalizeRecoveryCode(req.RecoveryCode)))
+		if err != nil {
+			log.Println(err)
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+		used, err := res.RowsAffected()
+		if err != nil {
+			log.Println(err)
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+		verified = used > 0
+	}
+
+	if !verified {
+		if err = s.recordLoginFailure(ctx, user.Email, device.Ip); err != nil {
+			log.Println(err)
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		attempts++
+
+		// too many wrong codes and the login has to start over with the password
+		if attempts >= maxTwoFactorAttempts {
+			q = `DELETE FROM two_factor_challenges WHERE id = $1`
+			_, err = tx.ExecContext(ctx, q, challengeId)
+		} else {
+			q = `UPDATE two_factor_challenges SET attempts = $2 WHERE id = $1`
+			_, err = tx.ExecContext(ctx, q, challengeId, attempts)
+		}
+		if err != nil {
+			log.Println(err)
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+		if err = tx.Commit(); err != nil {
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+
+		if attempts >= maxTwoFactorAttempts {
+			return genericLoginResponse(http.StatusTooManyRequests, "too-many-attempts")
+		}
+		return genericLoginResponse(http.StatusUnauthorized, "invalid-code")
+	}
+
+	q = `DELETE FROM two_factor_challenges WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, challengeId); err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = s.resetLoginFailures(ctx, user.Email); err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	token, refreshToken, err := s.startSession(ctx, tx, user.Id, tokenVersion, user.Role, &device)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.LoginResponse{
+		Status:       http.StatusOK,
+		Error:        "",
+		User:         &user,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now())+offset)
+	require.NoError(t, err)
+
+	return code
+}
+
+// enableTwoFactor enrolls and confirms two factor authentication for a user,
+// confirming with the code of the previous step so the current one is unused.
+func enableTwoFactor(t *testing.T, ctx context.Context, client pb.UserServiceClient, userId int32) (string, []string) {
+	enroll, err := client.EnrollTwoFactor(ctx, &pb.EnrollTwoFactorRequest{UserId: userId})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), enroll.Status)
+	require.NotEmpty(t, enroll.Secret)
+	require.Contains(t, enroll.Uri, "secret="+enroll.Secret)
+
+	confirm, err := client.ConfirmTwoFactor(ctx, &pb.ConfirmTwoFactorRequest{
+		UserId: userId,
+		Code:   totpCode(t, enroll.Secret, -1),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), confirm.Status)
+	require.Len(t, confirm.RecoveryCodes, 10)
+
+	return enroll.Secret, confirm.RecoveryCodes
+}
+
+func TestEnrollTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+
+	enroll, err := client.EnrollTwoFactor(ctx, &pb.EnrollTwoFactorRequest{UserId: login.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), enroll.Status)
+
+	testCases := []struct {
+		name string
+		req  *pb.ConfirmTwoFactorRequest
+		resp *pb.ConfirmTwoFactorResponse
+	}{
+		{
+			"Wrong Code",
+			&pb.ConfirmTwoFactorRequest{UserId: login.User.Id, Code: totpCode(t, enroll.Secret, -5)},
+			&pb.ConfirmTwoFactorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-code",
+			},
+		},
+		{
+			"Invalid Code",
+			&pb.ConfirmTwoFactorRequest{UserId: login.User.Id, Code: ""},
+			&pb.ConfirmTwoFactorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-code",
+			},
+		},
+		{
+			"OK",
+			&pb.ConfirmTwoFactorRequest{UserId: login.User.Id, Code: totpCode(t, enroll.Secret, 0)},
+			&pb.ConfirmTwoFactorResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Already Enabled",
+			&pb.ConfirmTwoFactorRequest{UserId: login.User.Id, Code: totpCode(t, enroll.Secret, 0)},
+			&pb.ConfirmTwoFactorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "two-factor-already-enabled",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ConfirmTwoFactor(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	again, err := client.EnrollTwoFactor(ctx, &pb.EnrollTwoFactorRequest{UserId: login.User.Id})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusBadRequest), again.Status)
+	require.Equal(t, "two-factor-already-enabled", again.Error)
+}
+
+func TestVerifyTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+	secret, recoveryCodes := enableTwoFactor(t, ctx, client, login.User.Id)
+
+	challenge := func() string {
+		response, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+		require.NoError(t, err)
+		require.Equal(t, int32(http.StatusOK), response.Status)
+		require.True(t, response.TwoFactorRequired)
+		require.Empty(t, response.Token)
+		require.Empty(t, response.RefreshToken)
+
+		return response.TwoFactorToken
+	}
+
+	first := challenge()
+	second := challenge()
+
+	testCases := []struct {
+		name string
+		req  *pb.VerifyTwoFactorRequest
+		resp *pb.LoginResponse
+	}{
+		{
+			"Wrong Code",
+			&pb.VerifyTwoFactorRequest{TwoFactorToken: first, Code: totpCode(t, secret, -5)},
+			&pb.LoginResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "invalid-code",
+			},
+		},
+		{
+			"Code Used To Confirm",
+			&pb.VerifyTwoFactorRequest{TwoFactorToken: first, Code: totpCode(t, secret, -1)},
+			&pb.LoginResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "invalid-code",
+			},
+		},
+		{
+			"OK",
+			&pb.VerifyTwoFactorRequest{TwoFactorToken: first, Code: totpCode(t, secret, 0)},
+			&pb.LoginResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Used Two Factor Token",
+			&pb.VerifyTwoFactorRequest{TwoFactorToken: first, Code: totpCode(t, secret, 0)},
+			&pb.LoginResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "invalid-two-factor-token",
+			},
+		},
+		{
+			"Replayed Code",
+			&pb.VerifyTwoFactorRequest{TwoFactorToken: second, Code: totpCode(t, secret, 0)},
+			&pb.LoginResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "invalid-code",
+			},
+		},
+		{
+			"OK Recovery Code",
+			&pb.VerifyTwoFactorRequest{TwoFactorToken: second, RecoveryCode: recoveryCodes[0]},
+			&pb.LoginResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Used Recovery Code",
+			&pb.VerifyTwoFactorRequest{TwoFactorToken: challenge(), RecoveryCode: recoveryCodes[0]},
+			&pb.LoginResponse{
+				Status: http.StatusUnauthorized,
+				Error:  "invalid-code",
+			},
+		},
+		{
+			"Invalid Code",
+			&pb.VerifyTwoFactorRequest{TwoFactorToken: challenge()},
+			&pb.LoginResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-code",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.VerifyTwoFactor(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+
+			if response.Status == int32(http.StatusOK) {
+				require.NotEmpty(t, response.Token)
+				require.NotEmpty(t, response.RefreshToken)
+				require.Equal(t, login.User.Id, response.User.Id)
+			}
+		})
+	}
+}
+
+func TestVerifyTwoFactorAttempts(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+	secret, _ := enableTwoFactor(t, ctx, client, login.User.Id)
+
+	response, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+	require.NoError(t, err)
+	require.True(t, response.TwoFactorRequired)
+
+	wrong := &pb.VerifyTwoFactorRequest{TwoFactorToken: response.TwoFactorToken, Code: totpCode(t, secret, -5)}
+	for i := 1; i < maxTwoFactorAttempts; i++ {
+		verify, err := client.VerifyTwoFactor(ctx, wrong)
+		require.NoError(t, err)
+		require.Equal(t, int32(http.StatusUnauthorized), verify.Status)
+	}
+
+	verify, err := client.VerifyTwoFactor(ctx, wrong)
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusTooManyRequests), verify.Status)
+	require.Equal(t, "too-many-attempts", verify.Error)
+
+	// the login has to start over, even with the right code
+	verify, err = client.VerifyTwoFactor(ctx, &pb.VerifyTwoFactorRequest{
+		TwoFactorToken: response.TwoFactorToken,
+		Code:           totpCode(t, secret, 0),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), verify.Status)
+	require.Equal(t, "invalid-two-factor-token", verify.Error)
+}
+
+func TestVerifyTwoFactorLockout(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+	secret, _ := enableTwoFactor(t, ctx, client, login.User.Id)
+
+	challenge := func() string {
+		response, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+		require.NoError(t, err)
+		require.Equal(t, int32(http.StatusOK), response.Status)
+		require.True(t, response.TwoFactorRequired)
+
+		return response.TwoFactorToken
+	}
+	wrongCode := func(token string) *pb.LoginResponse {
+		verify, err := client.VerifyTwoFactor(ctx, &pb.VerifyTwoFactorRequest{TwoFactorToken: token, Code: totpCode(t, secret, -5)})
+		require.NoError(t, err)
+
+		return verify
+	}
+
+	// the test config locks an account after 5 failures, starting the login
+	// over with the right password doesn't forget the wrong codes
+	for i := 1; i < maxTwoFactorAttempts; i++ {
+		require.Equal(t, int32(http.StatusUnauthorized), wrongCode(challenge()).Status)
+	}
+	token := challenge()
+	require.Equal(t, int32(http.StatusUnauthorized), wrongCode(token).Status)
+
+	verify, err := client.VerifyTwoFactor(ctx, &pb.VerifyTwoFactorRequest{TwoFactorToken: token, Code: totpCode(t, secret, 0)})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusTooManyRequests), verify.Status)
+	require.Equal(t, "too-many-attempts", verify.Error)
+	require.Greater(t, verify.RetryAfter, int32(0))
+
+	response, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusTooManyRequests), response.Status)
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+	enableTwoFactor(t, ctx, client, login.User.Id)
+
+	testCases := []struct {
+		name string
+		req  *pb.DisableTwoFactorRequest
+		resp *pb.DisableTwoFactorResponse
+	}{
+		{
+			"Password not match",
+			&pb.DisableTwoFactorRequest{UserId: login.User.Id, Password: "wrong password"},
+			&pb.DisableTwoFactorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "password-not-match",
+			},
+		},
+		{
+			"OK",
+			&pb.DisableTwoFactorRequest{UserId: login.User.Id, Password: password},
+			&pb.DisableTwoFactorResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Not Enabled",
+			&pb.DisableTwoFactorRequest{UserId: login.User.Id, Password: password},
+			&pb.DisableTwoFactorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "two-factor-not-enabled",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.DisableTwoFactor(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	relogin, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), relogin.Status)
+	require.False(t, relogin.TwoFactorRequired)
+	require.NotEmpty(t, relogin.Token)
+}
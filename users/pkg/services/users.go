@@ -7,21 +7,73 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"strings"
 	"time"
 
-	cloudinary "github.com/cloudinary/cloudinary-go"
-	"github.com/cloudinary/cloudinary-go/api/uploader"
-	"github.com/maslow123/users/pkg/config"
+	"github.com/google/uuid"
 	"github.com/maslow123/users/pkg/pb"
 	"github.com/maslow123/users/pkg/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// CheckDefaultPosTemplate makes sure the default template exists, otherwise
+// new users would silently get no pos.
+func (s *Server) CheckDefaultPosTemplate(ctx context.Context) error {
+	if s.DefaultPosTemplate == "" {
+		return nil
+	}
+
+	var id int32
+	row := s.DB.QueryRowContext(ctx, `SELECT id FROM pos_templates WHERE code = $1`, s.DefaultPosTemplate)
+	if err := row.Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("unknown pos template %q", s.DefaultPosTemplate)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// createUserDefaults gives a new user the pos of the default template and
+// empty balances.
+func (s *Server) createUserDefaults(ctx context.Context, tx *sql.Tx, userId int32) error {
+	// a new user owns no pos yet so unlike applyPosTemplateQuery in the pos
+	// service there is nothing to skip or append to
+	if s.DefaultPosTemplate != "" {
+		q := `
+			INSERT INTO pos (user_id, name, type, color, icon, sort_order)
+			SELECT $1, i.name, i.type, i.color, i.icon, i.sort_order
+			FROM pos_template_items i
+			JOIN pos_templates t ON t.id = i.template_id
+			WHERE t.code = $2
+		`
+		if _, err := tx.ExecContext(ctx, q, userId, s.DefaultPosTemplate); err != nil {
+			return err
+		}
+	}
+
+	transactionTypes := []int{0, 1} // 0: Cash, 1: Transfer
+
+	for txType := range transactionTypes {
+		if _, err := s.BalanceService.UpsertBalance(userId, int32(txType), 0, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
+
 	if req.Name == "" {
 		return genericRegisterResponse(http.StatusBadRequest, "invalid-name")
 	}
-	if req.Email == "" {
+	if !utils.ValidEmail(req.Email) {
 		return genericRegisterResponse(http.StatusBadRequest, "invalid-email")
 	}
 	if req.Password == "" {
@@ -33,8 +85,15 @@ func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.Reg
 	if req.Password != req.ConfirmPassword {
 		return genericRegisterResponse(http.StatusBadRequest, "password-not-match")
 	}
+	if errMessage := s.PasswordPolicy.Validate(req.Password); errMessage != "" {
+		return genericRegisterResponse(http.StatusBadRequest, errMessage)
+	}
 
-	hashedPassword := utils.HashPassword(req.Password)
+	hashedPassword, err := s.Hasher.Hash(req.Password)
+	if err != nil {
+		log.Println(err)
+		return genericRegisterResponse(http.StatusInternalServerError, err.Error())
+	}
 
 	// Check email already exists
 	q := `SELECT email FROM users where email = $1`
@@ -75,15 +134,15 @@ func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.Reg
 		return genericRegisterResponse(http.StatusInternalServerError, err.Error())
 	}
 
-	// create new balance
-	transactionTypes := []int{0, 1} // 0: Cash, 1: Transfer
+	if err = s.createUserDefaults(ctx, tx, lastInsertedId); err != nil {
+		log.Println(err)
+		return genericRegisterResponse(http.StatusInternalServerError, err.Error())
+	}
 
-	for txType := range transactionTypes {
-		_, err = s.BalanceService.UpsertBalance(lastInsertedId, int32(txType), 0, 0)
-		if err != nil {
-			log.Println(err)
-			return genericRegisterResponse(http.StatusInternalServerError, err.Error())
-		}
+	// a mail that can't be sent doesn't fail the registration, the user can ask
+	// for a new one with ResendVerification
+	if err = s.sendVerificationMail(ctx, tx, lastInsertedId, req.Email); err != nil {
+		log.Println(err)
 	}
 
 	// Commit the transaction.
@@ -95,6 +154,8 @@ func (s *Server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.Reg
 }
 
 func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
+
 	if req.Email == "" {
 		return genericLoginResponse(http.StatusBadRequest, "invalid-email")
 	}
@@ -102,49 +163,130 @@ func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResp
 		return genericLoginResponse(http.StatusBadRequest, "invalid-password")
 	}
 
+	lockedFor, err := s.loginLockedFor(ctx, req.Email, req.Ip)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+	if lockedFor > 0 {
+		return &pb.LoginResponse{
+			Status:     http.StatusTooManyRequests,
+			Error:      "too-many-attempts",
+			RetryAfter: int32(math.Ceil(lockedFor.Seconds())),
+		}, nil
+	}
+
 	var user pb.User
 	var userPass string
+	var tokenVersion int32
+	var totpEnabled, disabled bool
 	q := `
-		SELECT id, name, email, password, COALESCE(photo, '') photo
+		SELECT
+			id, name, email, password, COALESCE(photo, '') photo, token_version,
+			email_verified, COALESCE(pending_email, ''), totp_enabled, role,
+			disabled_at IS NOT NULL
 		FROM users
 		WHERE email = $1
 		LIMIT 1
 	`
 	row := s.DB.QueryRowContext(ctx, q, req.Email)
 
-	err := row.Scan(
+	err = row.Scan(
 		&user.Id,
 		&user.Name,
 		&user.Email,
 		&userPass,
 		&user.Photo,
+		&tokenVersion,
+		&user.EmailVerified,
+		&user.PendingEmail,
+		&totpEnabled,
+		&user.Role,
+		&disabled,
 	)
 
-	if err != nil {
+	if err != nil && err != sql.ErrNoRows {
 		log.Println(err)
-		if err == sql.ErrNoRows {
-			return genericLoginResponse(http.StatusNotFound, "user-not-found")
-		}
 		return genericLoginResponse(http.StatusInternalServerError, err.Error())
 	}
 
-	match := utils.CheckPasswordHash(req.Password, userPass)
+	// unknown emails and wrong passwords look the same, and hashing the
+	// password takes about as long as checking it
+	var match, needsRehash bool
+	if err == sql.ErrNoRows {
+		_, _ = s.Hasher.Hash(req.Password)
+	} else {
+		match, needsRehash, err = s.Hasher.Verify(req.Password, userPass)
+		if err != nil {
+			log.Println(err)
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+	}
 	if !match {
-		return genericLoginResponse(http.StatusUnauthorized, "password-not-match")
+		if err = s.recordLoginFailure(ctx, req.Email, req.Ip); err != nil {
+			log.Println(err)
+			return genericLoginResponse(http.StatusInternalServerError, err.Error())
+		}
+		return genericLoginResponse(http.StatusUnauthorized, "invalid-credentials")
+	}
+
+	// upgrade the hash to the configured algorithm and parameters while the
+	// plain password is known, a failure only means trying again next time
+	if needsRehash {
+		if err = s.rehashPassword(ctx, user.Id, req.Password); err != nil {
+			log.Println(err)
+		}
+	}
+
+	// only told once the password is known to be right
+	if disabled {
+		return genericLoginResponse(http.StatusForbidden, "account-disabled")
+	}
+
+	// the failures are only forgotten once the second factor is verified too,
+	// wrong codes count against the same limit as wrong passwords
+	if totpEnabled {
+		return s.createTwoFactorChallenge(ctx, user.Id, req)
+	}
+
+	if err = s.resetLoginFailures(ctx, req.Email); err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	token, refreshToken, err := s.startSession(ctx, tx, user.Id, tokenVersion, user.Role, req)
+	if err != nil {
+		log.Println(err)
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericLoginResponse(http.StatusInternalServerError, err.Error())
 	}
 
-	token, _ := s.Jwt.GenerateToken(user.Id)
 	resp := &pb.LoginResponse{
-		Status: http.StatusOK,
-		Error:  "",
-		User:   &user,
-		Token:  token,
+		Status:       http.StatusOK,
+		Error:        "",
+		User:         &user,
+		Token:        token,
+		RefreshToken: refreshToken,
 	}
 
 	return resp, nil
 }
 
 func (s *Server) Validate(ctx context.Context, req *pb.ValidateRequest) (*pb.ValidateResponse, error) {
+	if strings.HasPrefix(req.Token, accessTokenPrefix) {
+		return s.validateAccessToken(ctx, req.Token)
+	}
+
 	claims, err := s.Jwt.ValidateToken(req.Token)
 
 	if err != nil {
@@ -154,39 +296,119 @@ func (s *Server) Validate(ctx context.Context, req *pb.ValidateRequest) (*pb.Val
 		}, nil
 	}
 
+	revoked, err := s.isTokenRevoked(ctx, claims.UserId, claims.SessionId, claims.StandardClaims.Id, claims.TokenVersion)
+	if err != nil {
+		log.Println(err)
+		return &pb.ValidateResponse{
+			Status: http.StatusInternalServerError,
+			Error:  err.Error(),
+		}, nil
+	}
+	if revoked {
+		return &pb.ValidateResponse{
+			Status: http.StatusUnauthorized,
+			Error:  "token-revoked",
+		}, nil
+	}
+
+	if err = s.touchSession(ctx, claims.SessionId); err != nil {
+		// the token is valid, a stale last seen time doesn't fail the request
+		log.Println(err)
+	}
+
+	// tokens issued before roles existed belong to regular users
+	role := claims.Role
+	if role == "" {
+		role = roleUser
+	}
+
 	return &pb.ValidateResponse{
-		Status: http.StatusOK,
-		UserId: claims.UserId,
+		Status:    http.StatusOK,
+		UserId:    claims.UserId,
+		SessionId: claims.SessionId,
+		Role:      role,
 	}, nil
 }
 
+// UpdateProfile changes the name right away, a new email is kept as pending
+// and only replaces the current one once it is verified.
 func (s *Server) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
+	req.Email = utils.NormalizeEmail(req.Email)
+
 	if req.Id == 0 {
 		return genericUpdateProfileResponse(http.StatusBadRequest, "invalid-user-id")
 	}
 	if req.Name == "" {
 		return genericUpdateProfileResponse(http.StatusBadRequest, "invalid-name")
 	}
-	if req.Email == "" {
+	if !utils.ValidEmail(req.Email) {
 		return genericUpdateProfileResponse(http.StatusBadRequest, "invalid-email")
 	}
 
-	q := `
-		UPDATE users SET email = $2, name = $3
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericUpdateProfileResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	var email string
+	q := `SELECT email FROM users WHERE id = $1 FOR UPDATE`
+
+	row := tx.QueryRowContext(ctx, q, req.Id)
+	err = row.Scan(&email)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericUpdateProfileResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericUpdateProfileResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	// changing back to the current email cancels a pending change
+	var pendingEmail sql.NullString
+	if req.Email != email {
+		taken, err := emailTaken(ctx, tx, req.Id, req.Email)
+		if err != nil {
+			log.Println(err)
+			return genericUpdateProfileResponse(http.StatusInternalServerError, err.Error())
+		}
+		if taken {
+			return genericUpdateProfileResponse(http.StatusBadRequest, "email-already-exists")
+		}
+
+		pendingEmail = sql.NullString{String: req.Email, Valid: true}
+	}
+
+	q = `
+		UPDATE users SET name = $2, pending_email = $3
 		WHERE id = $1
 	`
 
-	res, err := s.DB.ExecContext(ctx, q, req.Id, req.Email, req.Name)
+	_, err = tx.ExecContext(ctx, q, req.Id, req.Name, pendingEmail)
 	if err != nil {
 		log.Println(err)
 		return genericUpdateProfileResponse(http.StatusInternalServerError, err.Error())
 	}
-	count, err := res.RowsAffected()
-	if err == nil && count == 0 {
-		return genericUpdateProfileResponse(http.StatusNotFound, "user-not-found")
+
+	if pendingEmail.Valid {
+		// the change stays pending when the mail fails, ResendVerification sends it again
+		if err = s.sendVerificationMail(ctx, tx, req.Id, req.Email); err != nil {
+			log.Println(err)
+		}
 	}
 
-	return genericUpdateProfileResponse(http.StatusOK, "")
+	if err = tx.Commit(); err != nil {
+		return genericUpdateProfileResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := &pb.UpdateProfileResponse{
+		Status:       http.StatusOK,
+		Error:        "",
+		PendingEmail: pendingEmail.String,
+	}
+
+	return resp, nil
 }
 
 func (s *Server) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
@@ -221,21 +443,50 @@ func (s *Server) ChangePassword(ctx context.Context, req *pb.ChangePasswordReque
 	}
 
 	// check old password is match or not
-	match := utils.CheckPasswordHash(req.OldPassword, userPass)
+	match, _, err := s.Hasher.Verify(req.OldPassword, userPass)
+	if err != nil {
+		log.Println(err)
+		return genericChangePasswordResponse(http.StatusInternalServerError, err.Error())
+	}
 	if !match {
 		return genericChangePasswordResponse(http.StatusBadRequest, "password-not-match")
 	}
 
+	if errMessage := s.PasswordPolicy.Validate(req.Password); errMessage != "" {
+		return genericChangePasswordResponse(http.StatusBadRequest, errMessage)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericChangePasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
 	// update password
-	hashedPassword := utils.HashPassword(req.Password)
+	hashedPassword, err := s.Hasher.Hash(req.Password)
+	if err != nil {
+		log.Println(err)
+		return genericChangePasswordResponse(http.StatusInternalServerError, err.Error())
+	}
 	q = `UPDATE users SET password = $2 WHERE id = $1`
 
-	_, err = s.DB.ExecContext(ctx, q, req.Id, hashedPassword)
+	_, err = tx.ExecContext(ctx, q, req.Id, hashedPassword)
 	if err != nil {
 		log.Println(err)
 		return genericChangePasswordResponse(http.StatusInternalServerError, err.Error())
 	}
 
+	// log out every session, including the one that changed the password
+	if err = revokeAllTokens(ctx, tx, req.Id); err != nil {
+		log.Println(err)
+		return genericChangePasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericChangePasswordResponse(http.StatusInternalServerError, err.Error())
+	}
+
 	return genericChangePasswordResponse(http.StatusOK, "")
 }
 
@@ -248,24 +499,26 @@ func (s *Server) UploadImage(stream pb.UserService_UploadImageServer) error {
 	}
 
 	userID := req.GetInfo().GetUserId()
-	imageType := req.GetInfo().GetImageType()
-	log.Printf("receive an upload-image request for user %d with image type %s", userID, imageType)
+	log.Printf("receive an upload-image request for user %d with image type %s", userID, req.GetInfo().GetImageType())
+	if userID <= 0 {
+		return status.Error(codes.InvalidArgument, "invalid-user-id")
+	}
 
 	// check user id image already exists or no
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	q := `SELECT COUNT(1) as count FROM users WHERE id = $1`
-	var count int
+	q := `SELECT COALESCE(photo, '') FROM users WHERE id = $1`
+	var previousPhoto string
 	row := s.DB.QueryRowContext(ctx, q, userID)
-	err = row.Scan(&count)
+	err = row.Scan(&previousPhoto)
 
 	if err != nil {
 		log.Println(err)
 		if err == sql.ErrNoRows {
-			genericUploadImageResponse("user-not-found")
+			return status.Error(codes.NotFound, "user-not-found")
 		}
-		genericUploadImageResponse(err.Error())
+		return status.Error(codes.Internal, err.Error())
 	}
 
 	imageData := bytes.Buffer{}
@@ -291,6 +544,7 @@ func (s *Server) UploadImage(stream pb.UserService_UploadImageServer) error {
 
 		if imageSize > maxImageSize {
 			log.Printf("Image is too large: %d > %d", imageSize, maxImageSize)
+			return status.Error(codes.ResourceExhausted, "image-too-large")
 		}
 		_, err = imageData.Write(chunk)
 		if err != nil {
@@ -299,49 +553,54 @@ func (s *Server) UploadImage(stream pb.UserService_UploadImageServer) error {
 		}
 	}
 
-	imageID, err := s.ImageStore.Save(userID, imageType, imageData)
+	// the type comes from the content, the extension the client sent isn't trusted
+	image, err := utils.ProcessImage(imageData.Bytes(), thumbnailSizes)
+	if err != nil {
+		log.Println("Cannot process image: ", err)
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	imageID, err := uuid.NewRandom()
 	if err != nil {
+		log.Println(err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	fileName := fmt.Sprintf("%s%s", imageID, image.Type)
+	if err = s.ImageStore.Save(userID, fileName, *bytes.NewBuffer(image.Data)); err != nil {
 		log.Println("Cannot save image to the store: ", err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	var thumbnails []string
+	for _, size := range thumbnailSizes {
+		thumbnail := thumbnailFileName(fileName, size)
+		if err = s.ImageStore.Save(userID, thumbnail, *bytes.NewBuffer(image.Thumbnails[size])); err != nil {
+			log.Println("Cannot save thumbnail to the store: ", err)
+			s.deleteImage(fileName)
+			return status.Error(codes.Internal, err.Error())
+		}
+		thumbnails = append(thumbnails, thumbnail)
 	}
 
 	// Update user photo
-	fileName := fmt.Sprintf("%s%s", imageID, imageType)
 	q = `UPDATE users SET photo = $2 WHERE id = $1`
 	_, err = s.DB.ExecContext(ctx, q, userID, fileName)
 	if err != nil {
 		log.Println(err)
-		return genericUploadImageResponse(err.Error())
+		s.deleteImage(fileName)
+		return status.Error(codes.Internal, err.Error())
 	}
 
-	// add to cloudinary
-	if !s.IsTesting {
-		c, err := config.LoadConfig("./pkg/config/envs", "dev")
-		if err != nil {
-			log.Println(err)
-			return genericUploadImageResponse(err.Error())
-		}
-
-		cld, err := cloudinary.NewFromParams(c.CloudinaryCloudName, c.CloudinaryApiKey, c.CloudinaryApiSecretKey)
-		if err != nil {
-			log.Println(err)
-			return genericUploadImageResponse(err.Error())
-		}
-		imagePath := fmt.Sprintf("%s/%s%s", "img/", imageID, imageType)
-		resp, err := cld.Upload.Upload(ctx, imagePath, uploader.UploadParams{
-			PublicID: fmt.Sprintf("keuanganku/users/%s", imageID),
-		})
-		if err != nil {
-			log.Println(err)
-			return genericUploadImageResponse(err.Error())
-		}
-
-		log.Println("Cloudinary URL image: ", resp.SecureURL)
+	if previousPhoto != "" {
+		s.deleteImage(previousPhoto)
 	}
 
 	res := &pb.UploadImageResponse{
-		Id:   imageID,
-		Size: uint32(imageSize),
-		Type: imageType,
+		Id:         imageID.String(),
+		Size:       uint32(imageSize),
+		Type:       image.Type,
+		Thumbnails: thumbnails,
 	}
 
 	err = stream.SendAndClose(res)
@@ -3,6 +3,7 @@ package services
 import (
 	"bufio"
 	"context"
+	"database/sql"
 	"fmt"
 	"io"
 	"log"
@@ -11,15 +12,18 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/maslow123/users/pkg/config"
 	"github.com/maslow123/users/pkg/pb"
 	"github.com/maslow123/users/pkg/utils"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var randUser, randPass string
 
 func TestRegister(t *testing.T) {
-	randUser = utils.RandomString(10)
+	randUser = utils.RandomEmail()
 	randPass = utils.RandomString(10)
 
 	testCases := []struct {
@@ -66,6 +70,19 @@ func TestRegister(t *testing.T) {
 				Error:  "invalid-email",
 			},
 		},
+		{
+			"Invalid Email Format",
+			&pb.RegisterRequest{
+				Name:            utils.RandomString(10),
+				Email:           utils.RandomString(10),
+				Password:        randPass,
+				ConfirmPassword: randPass,
+			},
+			&pb.RegisterResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "invalid-email",
+			},
+		},
 		{
 			"Invalid Password",
 			&pb.RegisterRequest{
@@ -105,6 +122,32 @@ func TestRegister(t *testing.T) {
 				Error:  "password-not-match",
 			},
 		},
+		{
+			"Password Too Short",
+			&pb.RegisterRequest{
+				Name:            utils.RandomString(10),
+				Email:           utils.RandomEmail(),
+				Password:        "abc123",
+				ConfirmPassword: "abc123",
+			},
+			&pb.RegisterResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "password-too-short",
+			},
+		},
+		{
+			"Password Too Common",
+			&pb.RegisterRequest{
+				Name:            utils.RandomString(10),
+				Email:           utils.RandomEmail(),
+				Password:        "Password123",
+				ConfirmPassword: "Password123",
+			},
+			&pb.RegisterResponse{
+				Status: int32(http.StatusBadRequest),
+				Error:  "password-too-common",
+			},
+		},
 		{
 			"Existing user",
 			&pb.RegisterRequest{
@@ -189,18 +232,18 @@ func TestLogin(t *testing.T) {
 			},
 			&pb.LoginResponse{
 				Status: int32(http.StatusUnauthorized),
-				Error:  "password-not-match",
+				Error:  "invalid-credentials",
 			},
 		},
 		{
 			"User Not Found",
 			&pb.LoginRequest{
-				Email:    "xxxx",
+				Email:    utils.RandomEmail(),
 				Password: "xxxx",
 			},
 			&pb.LoginResponse{
-				Status: int32(http.StatusNotFound),
-				Error:  "user-not-found",
+				Status: int32(http.StatusUnauthorized),
+				Error:  "invalid-credentials",
 			},
 		},
 	}
@@ -283,6 +326,30 @@ func TestUpdateProfile(t *testing.T) {
 				Error:  "invalid-email",
 			},
 		},
+		{
+			"Invalid Email Format",
+			&pb.UpdateProfileRequest{
+				Id:    2,
+				Name:  "User Updated",
+				Email: "user2",
+			},
+			&pb.UpdateProfileResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-email",
+			},
+		},
+		{
+			"Email Already Exists",
+			&pb.UpdateProfileRequest{
+				Id:    2,
+				Name:  "User Updated",
+				Email: "User1@gmail.com",
+			},
+			&pb.UpdateProfileResponse{
+				Status: http.StatusBadRequest,
+				Error:  "email-already-exists",
+			},
+		},
 		{
 			"Invalid User Not Found",
 			&pb.UpdateProfileRequest{
@@ -317,18 +384,41 @@ func TestUpdateProfile(t *testing.T) {
 }
 
 func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+
+	// changing the password logs the user out everywhere, so don't use a seeded user
+	login, password := createUser(t, ctx, client)
+	newPassword := utils.RandomString(12)
+
 	testCases := []struct {
 		name string
 		req  *pb.ChangePasswordRequest
 		resp *pb.ChangePasswordResponse
 	}{
+		{
+			"Password too common",
+			&pb.ChangePasswordRequest{
+				Id:              login.User.Id,
+				OldPassword:     password,
+				Password:        "qwerty123",
+				ConfirmPassword: "qwerty123",
+			},
+			&pb.ChangePasswordResponse{
+				Status: http.StatusBadRequest,
+				Error:  "password-too-common",
+			},
+		},
 		{
 			"OK",
 			&pb.ChangePasswordRequest{
-				Id:              1,
-				OldPassword:     "111111",
-				Password:        "111111",
-				ConfirmPassword: "111111",
+				Id:              login.User.Id,
+				OldPassword:     password,
+				Password:        newPassword,
+				ConfirmPassword: newPassword,
 			},
 			&pb.ChangePasswordResponse{
 				Status: http.StatusOK,
@@ -428,12 +518,6 @@ func TestChangePassword(t *testing.T) {
 		},
 	}
 
-	ctx := context.Background()
-	conn := checkConnection(ctx, t)
-	defer conn.Close()
-
-	client := pb.NewUserServiceClient(conn)
-
 	for i := range testCases {
 		tc := testCases[i]
 
@@ -504,6 +588,11 @@ func TestUploadImage(t *testing.T) {
 	require.NoError(t, err)
 	require.NotZero(t, res.GetId())
 	require.EqualValues(t, size, res.GetSize())
+	require.Equal(t, ".jpg", res.GetType())
+	require.Len(t, res.GetThumbnails(), len(thumbnailSizes))
+	for _, thumbnail := range res.GetThumbnails() {
+		require.FileExists(t, fmt.Sprintf("%s/%s", testImageFolder, thumbnail))
+	}
 
 	savedImagePath := fmt.Sprintf("%s/%s%s", testImageFolder, res.GetId(), imageType)
 	require.FileExists(t, savedImagePath)
@@ -519,3 +608,117 @@ func TestUploadImage(t *testing.T) {
 	// require.NoError(t, err)
 
 }
+
+func TestUploadImageInvalid(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+
+	testCases := []struct {
+		name   string
+		userId int32
+		data   []byte
+		code   codes.Code
+		error  string
+	}{
+		{
+			"Too large",
+			1,
+			append([]byte("\xff\xd8\xff"), make([]byte, 1<<20)...),
+			codes.ResourceExhausted,
+			"image-too-large",
+		},
+		{
+			"Unsupported type",
+			1,
+			[]byte("GIF89a\x01\x00\x01\x00"),
+			codes.InvalidArgument,
+			"unsupported-image-type",
+		},
+		{
+			"Invalid image",
+			1,
+			[]byte("\xff\xd8\xffnot a jpeg"),
+			codes.InvalidArgument,
+			"invalid-image",
+		},
+		{
+			"User not found",
+			999999,
+			[]byte("\xff\xd8\xff"),
+			codes.NotFound,
+			"user-not-found",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			stream, err := client.UploadImage(ctx)
+			require.NoError(t, err)
+
+			err = stream.Send(&pb.UploadImageRequest{
+				Data: &pb.UploadImageRequest_Info{
+					Info: &pb.ImageInfo{UserId: tc.userId, ImageType: ".jpg"},
+				},
+			})
+			require.NoError(t, err)
+
+			for chunk := tc.data; len(chunk) > 0; {
+				n := 1024
+				if len(chunk) < n {
+					n = len(chunk)
+				}
+				// the server stops reading once it fails
+				if err = stream.Send(&pb.UploadImageRequest{
+					Data: &pb.UploadImageRequest_ChunkData{ChunkData: chunk[:n]},
+				}); err != nil {
+					break
+				}
+				chunk = chunk[n:]
+			}
+
+			_, err = stream.CloseAndRecv()
+			require.Error(t, err)
+			require.Equal(t, tc.code, status.Code(err))
+			require.Equal(t, tc.error, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestCheckDefaultPosTemplate(t *testing.T) {
+	c, err := config.LoadConfig("../config/envs", "test")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", c.DBUrl)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		template string
+		ok       bool
+	}{
+		{"OK", "id-household", true},
+		{"Disabled", "", true},
+		{"Unknown Template", "no-such-template", false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			s := Server{DB: db, DefaultPosTemplate: tc.template}
+			err := s.CheckDefaultPosTemplate(ctx)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+)
+
+// sendVerificationMail stores a token confirming email for a user and mails
+// the link to that address. Earlier unused tokens of the user stop working.
+func (s *Server) sendVerificationMail(ctx context.Context, tx *sql.Tx, userId int32, email string) error {
+	token, err := utils.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	q := `DELETE FROM email_verification_tokens WHERE user_id = $1 AND used_at IS NULL`
+	if _, err = tx.ExecContext(ctx, q, userId); err != nil {
+		return err
+	}
+
+	q = `
+		INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.ExecContext(ctx, q, userId, email, utils.HashToken(token), time.Now().Add(s.VerifyTokenTTL))
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(Mail{
+		To:      email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Open the link below to verify your email, it expires in %d hours.\r\n\r\n%s?token=%s\r\n",
+			int(s.VerifyTokenTTL.Hours()),
+			s.VerifyEmailUrl,
+			url.QueryEscape(token),
+		),
+	})
+}
+
+// emailTaken reports whether another user already uses an address.
+func emailTaken(ctx context.Context, db queryRower, userId int32, email string) (bool, error) {
+	q := `SELECT EXISTS (SELECT 1 FROM users WHERE email = $1 AND id <> $2)`
+
+	var taken bool
+	err := db.QueryRowContext(ctx, q, email, userId).Scan(&taken)
+
+	return taken, err
+}
+
+func (s *Server) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if req.Token == "" {
+		return genericVerifyEmailResponse(http.StatusBadRequest, "invalid-token")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericVerifyEmailResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `
+		SELECT t.id, t.user_id, t.email, t.expires_at, u.email, COALESCE(u.pending_email, '')
+		FROM email_verification_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.used_at IS NULL
+		FOR UPDATE
+	`
+
+	var id, userId int32
+	var tokenEmail, email, pendingEmail string
+	var expiresAt time.Time
+
+	row := tx.QueryRowContext(ctx, q, utils.HashToken(req.Token))
+	err = row.Scan(&id, &userId, &tokenEmail, &expiresAt, &email, &pendingEmail)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericVerifyEmailResponse(http.StatusBadRequest, "invalid-token")
+		}
+		return genericVerifyEmailResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if expiresAt.Before(time.Now()) {
+		return genericVerifyEmailResponse(http.StatusBadRequest, "token-expired")
+	}
+
+	switch tokenEmail {
+	case pendingEmail:
+		// the address could have been registered by someone else meanwhile
+		taken, err := emailTaken(ctx, tx, userId, pendingEmail)
+		if err != nil {
+			log.Println(err)
+			return genericVerifyEmailResponse(http.StatusInternalServerError, err.Error())
+		}
+		if taken {
+			return genericVerifyEmailResponse(http.StatusBadRequest, "email-already-exists")
+		}
+
+		q = `UPDATE users SET email = pending_email, pending_email = NULL, email_verified = true WHERE id = $1`
+	case email:
+		q = `UPDATE users SET email_verified = true WHERE id = $1`
+	default:
+		// the user changed the address again after this mail was sent
+		return genericVerifyEmailResponse(http.StatusBadRequest, "invalid-token")
+	}
+
+	if _, err = tx.ExecContext(ctx, q, userId); err != nil {
+		log.Println(err)
+		return genericVerifyEmailResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	q = `UPDATE email_verification_tokens SET used_at = now() WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, q, id); err != nil {
+		log.Println(err)
+		return genericVerifyEmailResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericVerifyEmailResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericVerifyEmailResponse(http.StatusOK, "")
+}
+
+// ResendVerification mails a new link for the pending address, or for the
+// current one while it isn't verified.
+func (s *Server) ResendVerification(ctx context.Context, req *pb.ResendVerificationRequest) (*pb.ResendVerificationResponse, error) {
+	if req.UserId == 0 {
+		return genericResendVerificationResponse(http.StatusBadRequest, "invalid-user-id")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println(err)
+		return genericResendVerificationResponse(http.StatusInternalServerError, err.Error())
+	}
+	defer tx.Rollback()
+
+	q := `SELECT email, email_verified, COALESCE(pending_email, '') FROM users WHERE id = $1 FOR UPDATE`
+
+	var email, pendingEmail string
+	var verified bool
+
+	row := tx.QueryRowContext(ctx, q, req.UserId)
+	err = row.Scan(&email, &verified, &pendingEmail)
+	if err != nil {
+		log.Println(err)
+		if err == sql.ErrNoRows {
+			return genericResendVerificationResponse(http.StatusNotFound, "user-not-found")
+		}
+		return genericResendVerificationResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	if pendingEmail != "" {
+		email = pendingEmail
+	} else if verified {
+		return genericResendVerificationResponse(http.StatusBadRequest, "email-already-verified")
+	}
+
+	if err = s.sendVerificationMail(ctx, tx, req.UserId, email); err != nil {
+		log.Println(err)
+		return genericResendVerificationResponse(http.StatusInternalServerError, "send-mail-failed")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return genericResendVerificationResponse(http.StatusInternalServerError, err.Error())
+	}
+
+	return genericResendVerificationResponse(http.StatusOK, "")
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maslow123/users/pkg/pb"
+	"github.com/maslow123/users/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+	require.False(t, login.User.EmailVerified)
+
+	// the registration mail confirms the current address
+	token := tokenFromMail(t, login.User.Email)
+
+	testCases := []struct {
+		name string
+		req  *pb.VerifyEmailRequest
+		resp *pb.VerifyEmailResponse
+	}{
+		{
+			"OK",
+			&pb.VerifyEmailRequest{Token: token},
+			&pb.VerifyEmailResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Used Token",
+			&pb.VerifyEmailRequest{Token: token},
+			&pb.VerifyEmailResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-token",
+			},
+		},
+		{
+			"Invalid Token",
+			&pb.VerifyEmailRequest{Token: ""},
+			&pb.VerifyEmailResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-token",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.VerifyEmail(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+
+	relogin, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), relogin.Status)
+	require.True(t, relogin.User.EmailVerified)
+}
+
+func TestChangeEmail(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, password := createUser(t, ctx, client)
+	newEmail := utils.RandomEmail()
+
+	update, err := client.UpdateProfile(ctx, &pb.UpdateProfileRequest{
+		Id:    login.User.Id,
+		Name:  login.User.Name,
+		Email: newEmail,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), update.Status)
+	require.Equal(t, newEmail, update.PendingEmail)
+
+	// the current address keeps working until the new one is verified
+	pending, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), pending.Status)
+	require.Equal(t, newEmail, pending.User.PendingEmail)
+
+	verify, err := client.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: tokenFromMail(t, newEmail)})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), verify.Status)
+
+	changed, err := client.Login(ctx, &pb.LoginRequest{Email: newEmail, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), changed.Status)
+	require.True(t, changed.User.EmailVerified)
+	require.Empty(t, changed.User.PendingEmail)
+
+	old, err := client.Login(ctx, &pb.LoginRequest{Email: login.User.Email, Password: password})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusUnauthorized), old.Status)
+}
+
+func TestResendVerification(t *testing.T) {
+	ctx := context.Background()
+	conn := checkConnection(ctx, t)
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+	login, _ := createUser(t, ctx, client)
+	verified, _ := createUser(t, ctx, client)
+
+	verify, err := client.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: tokenFromMail(t, verified.User.Email)})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), verify.Status)
+
+	testCases := []struct {
+		name string
+		req  *pb.ResendVerificationRequest
+		resp *pb.ResendVerificationResponse
+	}{
+		{
+			"OK",
+			&pb.ResendVerificationRequest{UserId: login.User.Id},
+			&pb.ResendVerificationResponse{
+				Status: http.StatusOK,
+				Error:  "",
+			},
+		},
+		{
+			"Already Verified",
+			&pb.ResendVerificationRequest{UserId: verified.User.Id},
+			&pb.ResendVerificationResponse{
+				Status: http.StatusBadRequest,
+				Error:  "email-already-verified",
+			},
+		},
+		{
+			"Invalid User ID",
+			&pb.ResendVerificationRequest{UserId: 0},
+			&pb.ResendVerificationResponse{
+				Status: http.StatusBadRequest,
+				Error:  "invalid-user-id",
+			},
+		},
+		{
+			"User Not Found",
+			&pb.ResendVerificationRequest{UserId: 9999},
+			&pb.ResendVerificationResponse{
+				Status: http.StatusNotFound,
+				Error:  "user-not-found",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := client.ResendVerification(ctx, tc.req)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.resp.Status, response.Status)
+			require.Equal(t, tc.resp.Error, response.Error)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// avatarColors are the backgrounds of the generated avatars, white text is
+// readable on all of them.
+var avatarColors = []color.RGBA{
+	{0xe5, 0x39, 0x35, 0xff},
+	{0xd8, 0x1b, 0x60, 0xff},
+	{0x8e, 0x24, 0xaa, 0xff},
+	{0x5e, 0x35, 0xb1, 0xff},
+	{0x39, 0x49, 0xab, 0xff},
+	{0x1e, 0x88, 0xe5, 0xff},
+	{0x00, 0x89, 0x7b, 0xff},
+	{0x43, 0xa0, 0x47, 0xff},
+	{0xf4, 0x51, 0x1e, 0xff},
+	{0x6d, 0x4c, 0x41, 0xff},
+}
+
+var (
+	avatarFontOnce sync.Once
+	avatarFont     *opentype.Font
+	avatarFontErr  error
+)
+
+// Initials are the first letters of the first and last words of a name, ?
+// when it has no letters.
+func Initials(name string) string {
+	var letters []rune
+	for _, word := range strings.Fields(name) {
+		for _, r := range word {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				letters = append(letters, unicode.ToUpper(r))
+				break
+			}
+		}
+	}
+
+	switch len(letters) {
+	case 0:
+		return "?"
+	case 1:
+		return string(letters[0])
+	default:
+		return string([]rune{letters[0], letters[len(letters)-1]})
+	}
+}
+
+// AvatarColor picks the background of the avatar of a key, the same key
+// always gets the same color.
+func AvatarColor(key string) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return avatarColors[h.Sum32()%uint32(len(avatarColors))]
+}
+
+// GenerateAvatar renders a square PNG of the initials of a name on the color
+// of the key.
+func GenerateAvatar(name string, key string, size int) ([]byte, error) {
+	avatarFontOnce.Do(func() {
+		avatarFont, avatarFontErr = opentype.Parse(gobold.TTF)
+	})
+	if avatarFontErr != nil {
+		return nil, avatarFontErr
+	}
+
+	face, err := opentype.NewFace(avatarFont, &opentype.FaceOptions{
+		Size:    float64(size) * 0.4,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{AvatarColor(key)}, image.Point{}, draw.Src)
+
+	initials := Initials(name)
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.White,
+		Face: face,
+	}
+
+	// center the text on its bounds, the baseline is below the cap height
+	bounds, _ := drawer.BoundString(initials)
+	width := bounds.Max.X - bounds.Min.X
+	height := bounds.Max.Y - bounds.Min.Y
+	drawer.Dot = fixed.Point26_6{
+		X: (fixed.I(size)-width)/2 - bounds.Min.X,
+		Y: (fixed.I(size)-height)/2 - bounds.Min.Y,
+	}
+	drawer.DrawString(initials)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitials(t *testing.T) {
+	testCases := []struct {
+		name     string
+		initials string
+	}{
+		{"Budi Santoso", "BS"},
+		{"budi", "B"},
+		{"Siti Nur Aisyah", "SA"},
+		{"  ", "?"},
+		{"", "?"},
+		{"@budi (kerja)", "BK"},
+		{"élodie durand", "ÉD"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.initials, Initials(tc.name))
+		})
+	}
+}
+
+func TestGenerateAvatar(t *testing.T) {
+	require.Equal(t, AvatarColor("user-1"), AvatarColor("user-1"))
+
+	for _, size := range []int{64, 256} {
+		data, err := GenerateAvatar("Budi Santoso", "user-1", size)
+		require.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, size, img.Bounds().Dx())
+		require.Equal(t, size, img.Bounds().Dy())
+
+		// the corner is the background, the center has some of the initials
+		r, g, b, _ := img.At(0, 0).RGBA()
+		background := AvatarColor("user-1")
+		require.Equal(t, uint32(background.R), r>>8)
+		require.Equal(t, uint32(background.G), g>>8)
+		require.Equal(t, uint32(background.B), b>>8)
+
+		var text bool
+		for x := 0; x < size; x++ {
+			if img.At(x, size/2) != img.At(0, 0) {
+				text = true
+				break
+			}
+		}
+		require.True(t, text)
+	}
+}
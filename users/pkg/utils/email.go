@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// NormalizeEmail trims and lower cases an email, emails are stored and
+// compared in this form.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidEmail reports whether email is a bare address like name@example.com,
+// without a display name and with a dot in the domain.
+func ValidEmail(email string) bool {
+	if len(email) > 254 {
+		return false
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return false
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".")
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidEmail(t *testing.T) {
+	testCases := []struct {
+		email string
+		valid bool
+	}{
+		{"user1@gmail.com", true},
+		{"first.last+tag@mail.example.co.id", true},
+		{"", false},
+		{"user1", false},
+		{"user1@gmail", false},
+		{"user1@gmail.", false},
+		{"@gmail.com", false},
+		{"user 1@gmail.com", false},
+		{"User 1 <user1@gmail.com>", false},
+		{"user1@gmail.com, user2@gmail.com", false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.email, func(t *testing.T) {
+			require.Equal(t, tc.valid, ValidEmail(tc.email))
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	require.Equal(t, "user1@gmail.com", NormalizeEmail("  User1@Gmail.COM "))
+}
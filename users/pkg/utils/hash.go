@@ -1,15 +1,175 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
-func HashPassword(password string) string {
-	bytes, _ := bcrypt.GenerateFromPassword([]byte(password), 5)
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
 
-	return string(bytes)
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// PasswordHasher hashes new passwords with the configured algorithm and
+// verifies hashes made with any supported one, reporting when a hash was made
+// with other parameters so it can be replaced on the next login.
+type PasswordHasher struct {
+	// Algorithm is "argon2id" or "bcrypt"
+	Algorithm  string
+	BcryptCost int
+	// Argon2 parameters, memory is in KiB
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Defaults for the settings left empty, the argon2id ones are the minimum
+// OWASP recommends.
+const (
+	defaultHashAlgorithm = "argon2id"
+	defaultBcryptCost    = 12
+	defaultArgon2Time    = 2
+	defaultArgon2Memory  = 19 * 1024
+	defaultArgon2Threads = 1
+)
+
+// WithDefaults fills the settings left empty with safe defaults and rejects the
+// ones that can't hash, so a bad configuration fails at startup rather than
+// on the first login.
+func (h PasswordHasher) WithDefaults() (PasswordHasher, error) {
+	if h.Algorithm == "" {
+		h.Algorithm = defaultHashAlgorithm
+	}
+	if h.BcryptCost == 0 {
+		h.BcryptCost = defaultBcryptCost
+	}
+	if h.Argon2Time == 0 {
+		h.Argon2Time = defaultArgon2Time
+	}
+	if h.Argon2Memory == 0 {
+		h.Argon2Memory = defaultArgon2Memory
+	}
+	if h.Argon2Threads == 0 {
+		h.Argon2Threads = defaultArgon2Threads
+	}
+
+	switch h.Algorithm {
+	case "argon2id":
+		// argon2 needs at least 8 KiB per thread
+		if h.Argon2Memory < 8*uint32(h.Argon2Threads) {
+			return h, fmt.Errorf("argon2 memory of %d KiB is too low for %d threads", h.Argon2Memory, h.Argon2Threads)
+		}
+	case "bcrypt":
+		if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+			return h, fmt.Errorf("bcrypt cost %d is not between %d and %d", h.BcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return h, fmt.Errorf("unsupported password hash algorithm %q", h.Algorithm)
+	}
+
+	return h, nil
+}
+
+func (h PasswordHasher) Hash(password string) (string, error) {
+	if h.Algorithm == "bcrypt" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+	if h.Algorithm != "argon2id" {
+		return "", fmt.Errorf("unsupported password hash algorithm %q", h.Algorithm)
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Argon2Time, h.Argon2Memory, h.Argon2Threads, argon2KeyLength)
+
+	// the PHC string format, the same one other argon2 libraries read
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.Argon2Memory,
+		h.Argon2Time,
+		h.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify checks a password against a hash, needsRehash is set for a match when
+// the hash doesn't use the configured algorithm and parameters.
+func (h PasswordHasher) Verify(password string, hash string) (match bool, needsRehash bool, err error) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return h.verifyArgon2(password, hash)
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, false, err
+	}
+
+	return true, h.Algorithm != "bcrypt" || cost != h.BcryptCost, nil
 }
 
-func CheckPasswordHash(password string, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+func (h PasswordHasher) verifyArgon2(password string, hash string) (bool, bool, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrInvalidHash
+	}
+
+	var memory, time uint32
+	var threads uint8
+	// argon2 panics on zero parameters
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil || time == 0 || threads == 0 {
+		return false, false, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, ErrInvalidHash
+	}
+
+	other := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash := h.Algorithm != "argon2id" ||
+		memory != h.Argon2Memory ||
+		time != h.Argon2Time ||
+		threads != h.Argon2Threads
 
-	return err == nil
+	return true, needsRehash, nil
 }
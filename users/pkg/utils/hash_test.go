@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testHasher = PasswordHasher{
+	Algorithm:     "argon2id",
+	BcryptCost:    bcrypt.MinCost,
+	Argon2Time:    1,
+	Argon2Memory:  8 * 1024,
+	Argon2Threads: 1,
+}
+
+func TestPasswordHasher(t *testing.T) {
+	password := RandomString(12)
+
+	hash, err := testHasher.Hash(password)
+	require.NoError(t, err)
+	require.Regexp(t, `^\$argon2id\$v=19\$m=8192,t=1,p=1\$`, hash)
+
+	match, needsRehash, err := testHasher.Verify(password, hash)
+	require.NoError(t, err)
+	require.True(t, match)
+	require.False(t, needsRehash)
+
+	match, _, err = testHasher.Verify("wrong password", hash)
+	require.NoError(t, err)
+	require.False(t, match)
+
+	// salted, so the same password never hashes the same
+	other, err := testHasher.Hash(password)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other)
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	password := RandomString(12)
+
+	bcryptHasher := testHasher
+	bcryptHasher.Algorithm = "bcrypt"
+	bcryptHash, err := bcryptHasher.Hash(password)
+	require.NoError(t, err)
+
+	stronger := testHasher
+	stronger.Argon2Time = 2
+	argon2Hash, err := testHasher.Hash(password)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		hasher PasswordHasher
+		hash   string
+		rehash bool
+	}{
+		{"bcrypt to argon2id", testHasher, bcryptHash, true},
+		{"same bcrypt cost", bcryptHasher, bcryptHash, false},
+		{"argon2id to bcrypt", bcryptHasher, argon2Hash, true},
+		{"stronger argon2id", stronger, argon2Hash, true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			match, needsRehash, err := tc.hasher.Verify(password, tc.hash)
+			require.NoError(t, err)
+			require.True(t, match)
+			require.Equal(t, tc.rehash, needsRehash)
+		})
+	}
+}
+
+func TestPasswordHasherInvalidHash(t *testing.T) {
+	for _, hash := range []string{
+		"$argon2id$v=19$m=8192,t=1,p=1$salt",
+		"$argon2id$v=16$m=8192,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=8192,t=1,p=1$!!$a2V5",
+	} {
+		_, _, err := testHasher.Verify("password", hash)
+		require.ErrorIs(t, err, ErrInvalidHash)
+	}
+
+	_, _, err := testHasher.Verify("password", "not a hash")
+	require.Error(t, err)
+}
+
+func TestPasswordHasherWithDefaults(t *testing.T) {
+	hasher, err := PasswordHasher{}.WithDefaults()
+	require.NoError(t, err)
+	require.Equal(t, "argon2id", hasher.Algorithm)
+
+	hash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	require.Regexp(t, `^\$argon2id\$v=19\$m=19456,t=2,p=1\$`, hash)
+
+	testCases := []struct {
+		name   string
+		hasher PasswordHasher
+		ok     bool
+	}{
+		{"Bcrypt", PasswordHasher{Algorithm: "bcrypt", BcryptCost: bcrypt.MinCost}, true},
+		{"Unknown Algorithm", PasswordHasher{Algorithm: "md5"}, false},
+		{"Bcrypt Cost Too High", PasswordHasher{Algorithm: "bcrypt", BcryptCost: 40}, false},
+		{"Argon2 Memory Too Low", PasswordHasher{Algorithm: "argon2id", Argon2Memory: 8, Argon2Threads: 4}, false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.hasher.WithDefaults()
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+
+	// a hasher that skipped WithDefaults refuses instead of picking one
+	_, err = PasswordHasher{Algorithm: "md5"}.Hash("correct horse")
+	require.Error(t, err)
+
+	// zero parameters in a stored hash would make argon2 panic
+	_, _, err = hasher.Verify("correct horse", "$argon2id$v=19$m=19456,t=0,p=1$c2FsdA$a2V5")
+	require.ErrorIs(t, err, ErrInvalidHash)
+}
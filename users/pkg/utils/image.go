@@ -0,0 +1,288 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported-image-type")
+	ErrInvalidImage     = errors.New("invalid-image")
+)
+
+// MaxImagePixels bounds the size of a decoded image, a small file can
+// declare huge dimensions.
+const MaxImagePixels = 40_000_000
+
+// ProcessedImage is an uploaded image without its metadata, and its square
+// thumbnails by size.
+type ProcessedImage struct {
+	// Type is the file extension of the image, .jpg, .png or .webp
+	Type       string
+	Data       []byte
+	Thumbnails map[int][]byte
+}
+
+// ImageType sniffs the type of an image from its content, only JPEG, PNG and
+// WebP are supported.
+func ImageType(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return ".jpg", nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return ".png", nil
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return ".webp", nil
+	}
+
+	return "", ErrUnsupportedImage
+}
+
+// ThumbnailType is the file extension of the thumbnails of an image type,
+// WebP can't be encoded so its thumbnails are PNG.
+func ThumbnailType(imageType string) string {
+	if imageType == ".jpg" {
+		return ".jpg"
+	}
+
+	return ".png"
+}
+
+// ProcessImage validates an uploaded image, strips its metadata (EXIF, XMP,
+// text chunks) and renders a thumbnail for every size.
+func ProcessImage(data []byte, thumbnailSizes []int) (*ProcessedImage, error) {
+	imageType, err := ImageType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decodeConfig, decode := jpeg.DecodeConfig, jpeg.Decode
+	switch imageType {
+	case ".png":
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case ".webp":
+		decodeConfig, decode = webp.DecodeConfig, webp.Decode
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if config.Width*config.Height > MaxImagePixels {
+		return nil, ErrInvalidImage
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	processed := &ProcessedImage{
+		Type:       imageType,
+		Thumbnails: make(map[int][]byte),
+	}
+
+	// encoding the decoded image leaves the metadata behind, the orientation
+	// of a JPEG is applied first since it goes with the EXIF
+	switch imageType {
+	case ".jpg":
+		img = orient(img, jpegOrientation(data))
+		processed.Data, err = encodeImage(img, imageType)
+	case ".png":
+		processed.Data, err = encodeImage(img, imageType)
+	case ".webp":
+		processed.Data, err = stripWebPMetadata(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, size := range thumbnailSizes {
+		thumbnail, err := encodeImage(thumbnail(img, size), ThumbnailType(imageType))
+		if err != nil {
+			return nil, err
+		}
+		processed.Thumbnails[size] = thumbnail
+	}
+
+	return processed, nil
+}
+
+func encodeImage(img image.Image, imageType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if imageType == ".jpg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+
+	return buf.Bytes(), err
+}
+
+// thumbnail crops the center square of an image and scales it to size.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	square := image.Rect(x, y, x+side, y+side)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, square, draw.Src, nil)
+
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation of a JPEG, 1 (as stored) when
+// it has none.
+func jpegOrientation(data []byte) int {
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xff {
+		marker := data[i+1]
+		// start of scan, the metadata segments come before it
+		if marker == 0xda {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+
+		segment := data[i+4 : end]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i = end
+	}
+
+	return 1
+}
+
+// exifOrientation reads the orientation tag of the first IFD of a TIFF header.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+// orient turns an image the way its EXIF orientation says it's displayed.
+func orient(src image.Image, orientation int) image.Image {
+	if orientation <= 1 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
+
+// stripWebPMetadata drops the EXIF and XMP chunks of a WebP, the image
+// data is kept as is.
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	out := append([]byte{}, data[:12]...)
+
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		fourcc := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2
+		if size < 0 || end > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		switch fourcc {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[i:end]...)
+			if size > 0 {
+				// clear the EXIF and XMP flags
+				chunk[8] &^= 0x08 | 0x04
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+
+	return out, nil
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+// a 1x1 lossless WebP
+const testWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+func testImage(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 10), G: uint8(y * 10), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+// withExifOrientation adds an EXIF segment with an orientation after the SOI
+// marker of a JPEG.
+func withExifOrientation(data []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	// tag, type SHORT, count, value
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xff, 0xe1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(data[2:])
+
+	return out.Bytes()
+}
+
+func TestImageType(t *testing.T) {
+	var jpg, pngData bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpg, testImage(2, 2), nil))
+	require.NoError(t, png.Encode(&pngData, testImage(2, 2)))
+	webpData, err := base64.StdEncoding.DecodeString(testWebP)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		data      []byte
+		imageType string
+		err       error
+	}{
+		{"JPEG", jpg.Bytes(), ".jpg", nil},
+		{"PNG", pngData.Bytes(), ".png", nil},
+		{"WebP", webpData, ".webp", nil},
+		{"GIF", []byte("GIF89a\x01\x00\x01\x00"), "", ErrUnsupportedImage},
+		{"Text", []byte("<svg></svg>"), "", ErrUnsupportedImage},
+		{"Empty", nil, "", ErrUnsupportedImage},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			imageType, err := ImageType(tc.data)
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.imageType, imageType)
+		})
+	}
+}
+
+func TestProcessImage(t *testing.T) {
+	var jpg bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpg, testImage(40, 20), nil))
+	// rotated 90 degrees clockwise when displayed
+	data := withExifOrientation(jpg.Bytes(), 6)
+	require.Equal(t, 6, jpegOrientation(data))
+
+	processed, err := ProcessImage(data, []int{8, 16})
+	require.NoError(t, err)
+	require.Equal(t, ".jpg", processed.Type)
+	require.NotContains(t, string(processed.Data), "Exif")
+	require.Equal(t, 1, jpegOrientation(processed.Data))
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(processed.Data))
+	require.NoError(t, err)
+	require.Equal(t, 20, config.Width)
+	require.Equal(t, 40, config.Height)
+
+	require.Len(t, processed.Thumbnails, 2)
+	for _, size := range []int{8, 16} {
+		config, err := jpeg.DecodeConfig(bytes.NewReader(processed.Thumbnails[size]))
+		require.NoError(t, err)
+		require.Equal(t, size, config.Width)
+		require.Equal(t, size, config.Height)
+	}
+
+	var pngData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, testImage(10, 30)))
+	processed, err = ProcessImage(pngData.Bytes(), []int{8})
+	require.NoError(t, err)
+	require.Equal(t, ".png", processed.Type)
+
+	config, err = png.DecodeConfig(bytes.NewReader(processed.Thumbnails[8]))
+	require.NoError(t, err)
+	require.Equal(t, 8, config.Width)
+	require.Equal(t, 8, config.Height)
+
+	// a truncated image has the right magic bytes only
+	_, err = ProcessImage(pngData.Bytes()[:20], []int{8})
+	require.Equal(t, ErrInvalidImage, err)
+
+	_, err = ProcessImage([]byte("GIF89a"), []int{8})
+	require.Equal(t, ErrUnsupportedImage, err)
+}
+
+func TestProcessWebP(t *testing.T) {
+	lossless, err := base64.StdEncoding.DecodeString(testWebP)
+	require.NoError(t, err)
+
+	// wrap the image in an extended WebP with an EXIF chunk
+	vp8x := []byte("VP8X\x0a\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	exif := []byte("EXIF\x04\x00\x00\x00GPS!")
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), vp8x...)
+	data = append(data, lossless[12:]...)
+	data = append(data, exif...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	processed, err := ProcessImage(data, []int{4})
+	require.NoError(t, err)
+	require.Equal(t, ".webp", processed.Type)
+	require.NotContains(t, string(processed.Data), "GPS!")
+	require.Zero(t, processed.Data[20]&0x08)
+	require.EqualValues(t, len(processed.Data)-8, binary.LittleEndian.Uint32(processed.Data[4:]))
+
+	_, err = webp.Decode(bytes.NewReader(processed.Data))
+	require.NoError(t, err)
+
+	config, err := png.DecodeConfig(bytes.NewReader(processed.Thumbnails[4]))
+	require.NoError(t, err)
+	require.Equal(t, 4, config.Width)
+}
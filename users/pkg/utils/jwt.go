@@ -1,36 +1,313 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
+// Algorithms the access tokens can be signed with.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
 type JwtWrapper struct {
-	SecretKey       string
-	Issuer          string
-	ExpirationHours int32
+	Issuer            string
+	ExpirationMinutes int32
+	Keys              *KeyRing
 }
 
 type jwtClaims struct {
 	jwt.StandardClaims
-	Id     int32
-	UserId int32
+	Id           int32
+	UserId       int32
+	SessionId    int32
+	TokenVersion int32
+	Role         string
+}
+
+// SigningKey is a key of the ring, its Kid goes into the header of the tokens
+// it signs so verifiers know which public key to check them with.
+type SigningKey struct {
+	Kid       string
+	Algorithm string
+	Private   crypto.Signer
+	// ActivatedAt is when the key starts signing, keys are published a while
+	// before so every verifier knows them by then
+	ActivatedAt time.Time
+}
+
+// NewSigningKey generates a key for the algorithm.
+func NewSigningKey(algorithm string, activatedAt time.Time) (*SigningKey, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newSigningKey(algorithm, private, activatedAt)
 }
 
-func (w *JwtWrapper) GenerateToken(userId int32) (signedToken string, err error) {
+// DecryptSigningKey reads a key stored with EncryptPrivateKey under the kid.
+func DecryptSigningKey(algorithm string, kid string, encrypted string, secret string, activatedAt time.Time) (*SigningKey, error) {
+	aead, err := signingKeyCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("invalid encrypted signing key")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	der, err := aead.Open(nil, nonce, ciphertext, []byte(kid))
+	if err != nil {
+		return nil, errors.New("signing key can't be decrypted")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	private, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("signing key can't sign")
+	}
+
+	signingKey, err := newSigningKey(algorithm, private, activatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if signingKey.Kid != kid {
+		return nil, errors.New("signing key doesn't match its kid")
+	}
+
+	return signingKey, nil
+}
+
+func newSigningKey(algorithm string, private crypto.Signer, activatedAt time.Time) (*SigningKey, error) {
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != AlgorithmRS256 {
+			return nil, fmt.Errorf("rsa key for algorithm %q", algorithm)
+		}
+	case ed25519.PrivateKey:
+		if algorithm != AlgorithmEdDSA {
+			return nil, fmt.Errorf("ed25519 key for algorithm %q", algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing key %T", private)
+	}
+
+	// the kid is derived from the public key, so it's the same on every
+	// instance that loads the key
+	der, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+
+	return &SigningKey{
+		Kid:         base64.RawURLEncoding.EncodeToString(sum[:12]),
+		Algorithm:   algorithm,
+		Private:     private,
+		ActivatedAt: activatedAt,
+	}, nil
+}
+
+// EncryptPrivateKey encodes the private key as PKCS #8 and encrypts it with
+// AES-GCM under the secret, the database holding it is shared with the other
+// services. The kid is authenticated along, so a key only decrypts as its own.
+func (k *SigningKey) EncryptPrivateKey(secret string) (string, error) {
+	aead, err := signingKeyCipher(secret)
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, der, []byte(k.Kid))
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func signingKeyCipher(secret string) (cipher.AEAD, error) {
+	if secret == "" {
+		return nil, errors.New("missing signing key secret")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+
+	return jwt.SigningMethodRS256
+}
+
+// JWK is the public part of a signing key as a JSON Web Key.
+type JWK struct {
+	Kty string
+	Kid string
+	Alg string
+	Use string
+	// RSA keys
+	N string
+	E string
+	// Ed25519 keys
+	Crv string
+	X   string
+}
+
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{
+		Kid: k.Kid,
+		Alg: k.Algorithm,
+		Use: "sig",
+	}
+
+	switch public := k.Private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
+
+// KeyRing holds the published signing keys. It is shared by the copies of the
+// JwtWrapper and replaced as a whole when the keys are reloaded.
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys []*SigningKey
+}
+
+func (r *KeyRing) Set(keys []*SigningKey) {
+	sorted := append([]*SigningKey{}, keys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ActivatedAt.After(sorted[j].ActivatedAt)
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = sorted
+}
+
+// Keys returns the published keys, the newest first.
+func (r *KeyRing) Keys() []*SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]*SigningKey{}, r.keys...)
+}
+
+// Current returns the newest key that is active, nil when there is none.
+func (r *KeyRing) Current() *SigningKey {
+	now := time.Now()
+	for _, key := range r.Keys() {
+		if !key.ActivatedAt.After(now) {
+			return key
+		}
+	}
+
+	return nil
+}
+
+func (r *KeyRing) Key(kid string) *SigningKey {
+	for _, key := range r.Keys() {
+		if key.Kid == kid {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// GenerateToken issues a short lived access token. Every token gets a random
+// jti so it can be revoked on its own, and carries the user's token version so
+// that all tokens issued before a password change can be rejected, and the
+// session it was issued for so revoking the session rejects it too. The role
+// lets the gateway check permissions without asking for the user.
+func (w *JwtWrapper) GenerateToken(userId int32, sessionId int32, tokenVersion int32, role string) (signedToken string, err error) {
+	if w.Keys == nil || w.Keys.Current() == nil {
+		return "", errors.New("no active signing key")
+	}
+	key := w.Keys.Current()
+
+	jti, err := NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().Local()
 	claims := &jwtClaims{
-		UserId: userId,
+		UserId:       userId,
+		SessionId:    sessionId,
+		TokenVersion: tokenVersion,
+		Role:         role,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(w.ExpirationHours)).Unix(),
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Minute * time.Duration(w.ExpirationMinutes)).Unix(),
 			Issuer:    w.Issuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.Kid
 
-	signedToken, err = token.SignedString([]byte(w.SecretKey))
+	signedToken, err = token.SignedString(key.Private)
 
 	if err != nil {
 		return "", err
@@ -43,9 +320,7 @@ func (w *JwtWrapper) ValidateToken(signedToken string) (claims *jwtClaims, err e
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&jwtClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(w.SecretKey), nil
-		},
+		w.verificationKey,
 	)
 
 	if err != nil {
@@ -65,3 +340,25 @@ func (w *JwtWrapper) ValidateToken(signedToken string) (claims *jwtClaims, err e
 	return claims, nil
 
 }
+
+// verificationKey picks the key of a token by its kid, the algorithm has to be
+// the one of the key so a token can't choose how it is checked.
+func (w *JwtWrapper) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("missing signing key id")
+	}
+
+	var key *SigningKey
+	if w.Keys != nil {
+		key = w.Keys.Key(kid)
+	}
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing algorithm")
+	}
+
+	return key.Private.Public(), nil
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeys(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := NewSigningKey(algorithm, time.Now().Add(-time.Minute))
+			require.NoError(t, err)
+
+			w := JwtWrapper{Issuer: "user-service", ExpirationMinutes: 15, Keys: &KeyRing{}}
+			w.Keys.Set([]*SigningKey{key})
+
+			token, err := w.GenerateToken(1, 2, 3, "admin")
+			require.NoError(t, err)
+
+			claims, err := w.ValidateToken(token)
+			require.NoError(t, err)
+			require.Equal(t, int32(1), claims.UserId)
+			require.Equal(t, int32(2), claims.SessionId)
+			require.Equal(t, "admin", claims.Role)
+
+			// a stored key loads with the same kid and still verifies
+			encrypted, err := key.EncryptPrivateKey("signing-secret")
+			require.NoError(t, err)
+			loaded, err := DecryptSigningKey(algorithm, key.Kid, encrypted, "signing-secret", key.ActivatedAt)
+			require.NoError(t, err)
+			require.Equal(t, key.Kid, loaded.Kid)
+			require.Equal(t, key.JWK(), loaded.JWK())
+
+			other := JwtWrapper{Keys: &KeyRing{}}
+			other.Keys.Set([]*SigningKey{loaded})
+			_, err = other.ValidateToken(token)
+			require.NoError(t, err)
+
+			_, err = DecryptSigningKey("HS256", key.Kid, encrypted, "signing-secret", key.ActivatedAt)
+			require.Error(t, err)
+
+			// it only opens with the secret, and only as its own kid
+			_, err = DecryptSigningKey(algorithm, key.Kid, encrypted, "another-secret", key.ActivatedAt)
+			require.Error(t, err)
+			_, err = DecryptSigningKey(algorithm, "another-kid", encrypted, "signing-secret", key.ActivatedAt)
+			require.Error(t, err)
+			_, err = key.EncryptPrivateKey("")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, err := NewSigningKey(AlgorithmEdDSA, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	next, err := NewSigningKey(AlgorithmRS256, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	w := JwtWrapper{Issuer: "user-service", ExpirationMinutes: 15, Keys: &KeyRing{}}
+	w.Keys.Set([]*SigningKey{next, old})
+
+	// a published key only signs once it is active
+	require.Equal(t, old.Kid, w.Keys.Current().Kid)
+	token, err := w.GenerateToken(1, 2, 3, "user")
+	require.NoError(t, err)
+
+	next.ActivatedAt = time.Now().Add(-time.Minute)
+	w.Keys.Set([]*SigningKey{old, next})
+	require.Equal(t, next.Kid, w.Keys.Current().Kid)
+
+	// tokens of the previous key stay valid while it is published
+	_, err = w.ValidateToken(token)
+	require.NoError(t, err)
+
+	w.Keys.Set([]*SigningKey{next})
+	_, err = w.ValidateToken(token)
+	require.Error(t, err)
+
+	w.Keys.Set(nil)
+	_, err = w.GenerateToken(1, 2, 3, "user")
+	require.Error(t, err)
+}
+
+func TestValidateTokenAlgorithm(t *testing.T) {
+	key, err := NewSigningKey(AlgorithmRS256, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	w := JwtWrapper{ExpirationMinutes: 15, Keys: &KeyRing{}}
+	w.Keys.Set([]*SigningKey{key})
+
+	claims := &jwtClaims{
+		UserId:         1,
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()},
+	}
+
+	// tokens of the old shared secret carry no kid and aren't accepted anymore
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("r43t18sc"))
+	require.NoError(t, err)
+	_, err = w.ValidateToken(legacy)
+	require.Error(t, err)
+
+	// an HMAC token can't claim the kid of a public key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = key.Kid
+	signed, err := forged.SignedString([]byte(key.JWK().N))
+	require.NoError(t, err)
+	_, err = w.ValidateToken(signed)
+	require.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = func() map[string]struct{} {
+	passwords := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordList))
+	for scanner.Scan() {
+		password := strings.TrimSpace(scanner.Text())
+		if password != "" && !strings.HasPrefix(password, "#") {
+			passwords[strings.ToLower(password)] = struct{}{}
+		}
+	}
+
+	return passwords
+}()
+
+// PasswordPolicy is checked whenever a password is set: on registration, when
+// it's changed and when it's reset.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+}
+
+// Validate returns the error code for a password that breaks the policy, or an
+// empty string. Lengths are counted in characters.
+func (p PasswordPolicy) Validate(password string) string {
+	length := utf8.RuneCountInString(password)
+
+	if length < p.MinLength {
+		return "password-too-short"
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return "password-too-long"
+	}
+	if _, ok := commonPasswords[strings.ToLower(password)]; ok {
+		return "password-too-common"
+	}
+
+	return ""
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 16}
+
+	testCases := []struct {
+		password string
+		err      string
+	}{
+		{"correct horse", ""},
+		{"kata sandi", ""},
+		{"short", "password-too-short"},
+		{"sandi🔑🔑", "password-too-short"},
+		{"a very long passphrase", "password-too-long"},
+		{"password", "password-too-common"},
+		{"QWERTY123", "password-too-common"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.password, func(t *testing.T) {
+			require.Equal(t, tc.err, policy.Validate(tc.password))
+		})
+	}
+}
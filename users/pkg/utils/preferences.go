@@ -0,0 +1,21 @@
+package utils
+
+// SupportedCurrencies are the ISO 4217 codes amounts can be displayed in, the
+// transactions service knows their symbols.
+var SupportedCurrencies = map[string]bool{
+	"IDR": true,
+	"USD": true,
+	"EUR": true,
+	"GBP": true,
+	"SGD": true,
+	"MYR": true,
+	"AUD": true,
+	"JPY": true,
+}
+
+// SupportedLocales are the locales numbers and dates can be formatted for.
+var SupportedLocales = map[string]bool{
+	"id-ID": true,
+	"en-US": true,
+	"en-GB": true,
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 SHA1 test vectors, truncated to six digits
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(tc.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	step := TOTPStep(now)
+
+	previous, err := TOTPCode(secret, step-1)
+	require.NoError(t, err)
+	matched, ok := ValidateTOTP(secret, previous, now)
+	require.True(t, ok)
+	require.Equal(t, step-1, matched)
+
+	old, err := TOTPCode(secret, step-2)
+	require.NoError(t, err)
+	_, ok = ValidateTOTP(secret, old, now)
+	require.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now)
+	require.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Keuanganku", "user1@gmail.com", "JBSWY3DPEHPK3PXP")
+
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/Keuanganku:user1@gmail.com?"))
+	require.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	require.Contains(t, uri, "issuer=Keuanganku")
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	require.NoError(t, err)
+	require.Len(t, code, 11)
+	require.Equal(t, byte('-'), code[5])
+	require.Equal(t, NormalizeRecoveryCode(code), NormalizeRecoveryCode(" "+strings.ToUpper(code)))
+}